@@ -0,0 +1,88 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+func (c *awsEcrClinet) FetchPullThroughCacheRules() ([]*domain.PullThroughCacheRule, error) {
+	if c.cacheRules != nil {
+		return c.cacheRules, nil
+	}
+	input := &ecr.DescribePullThroughCacheRulesInput{
+		MaxResults: aws.Int64(100),
+	}
+	ret := make([]*domain.PullThroughCacheRule, 0)
+	for {
+		output, err := c.cli.DescribePullThroughCacheRules(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range output.PullThroughCacheRules {
+			ret = append(ret, newPullThroughCacheRule(r))
+		}
+		nextToken := aws.StringValue(output.NextToken)
+		if nextToken == "" {
+			break
+		}
+		input.SetNextToken(nextToken)
+	}
+	c.cacheRules = ret
+	return ret, nil
+}
+
+func (c *awsEcrClinet) CreatePullThroughCacheRule(in *domain.CreatePullThroughCacheRuleInput) (*domain.PullThroughCacheRule, error) {
+	input := &ecr.CreatePullThroughCacheRuleInput{
+		EcrRepositoryPrefix: aws.String(in.Prefix),
+		UpstreamRegistryUrl: aws.String(in.UpstreamUrl),
+	}
+	if in.CredentialArn != "" {
+		input.CredentialArn = aws.String(in.CredentialArn)
+	}
+	output, err := c.cli.CreatePullThroughCacheRule(input)
+	if err != nil {
+		return nil, err
+	}
+	rule := &domain.PullThroughCacheRule{
+		Prefix:        aws.StringValue(output.EcrRepositoryPrefix),
+		UpstreamUrl:   aws.StringValue(output.UpstreamRegistryUrl),
+		CredentialArn: aws.StringValue(output.CredentialArn),
+		RegistryId:    aws.StringValue(output.RegistryId),
+		CreatedAt:     aws.TimeValue(output.CreatedAt),
+	}
+	if c.cacheRules != nil {
+		c.cacheRules = append(c.cacheRules, rule)
+	}
+	return rule, nil
+}
+
+func (c *awsEcrClinet) DeletePullThroughCacheRule(prefix string) error {
+	input := &ecr.DeletePullThroughCacheRuleInput{
+		EcrRepositoryPrefix: aws.String(prefix),
+	}
+	if _, err := c.cli.DeletePullThroughCacheRule(input); err != nil {
+		return err
+	}
+	if c.cacheRules == nil {
+		return nil
+	}
+	ret := make([]*domain.PullThroughCacheRule, 0, len(c.cacheRules))
+	for _, r := range c.cacheRules {
+		if r.Prefix != prefix {
+			ret = append(ret, r)
+		}
+	}
+	c.cacheRules = ret
+	return nil
+}
+
+func newPullThroughCacheRule(r *ecr.PullThroughCacheRule) *domain.PullThroughCacheRule {
+	return &domain.PullThroughCacheRule{
+		Prefix:        aws.StringValue(r.EcrRepositoryPrefix),
+		UpstreamUrl:   aws.StringValue(r.UpstreamRegistryUrl),
+		CredentialArn: aws.StringValue(r.CredentialArn),
+		RegistryId:    aws.StringValue(r.RegistryId),
+		CreatedAt:     aws.TimeValue(r.CreatedAt),
+	}
+}
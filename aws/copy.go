@@ -0,0 +1,228 @@
+package aws
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/oci"
+)
+
+const (
+	batchCheckLayerAvailabilityLimit = 100
+	defaultLayerPartSize             = 10 * 1024 * 1024
+	layerAvailable                   = "AVAILABLE"
+)
+
+// destinationClient returns a client of the region of dst, which may be the current one.
+func (c *awsEcrClinet) destinationClient(dst *domain.CopyDestination) (*ecr.ECR, error) {
+	if dst.Region == "" || dst.Region == c.region {
+		return c.cli, nil
+	}
+	return createClient(dst.Region, c.profile)
+}
+
+func registryID(dst *domain.CopyDestination) *string {
+	if dst.RegistryId == "" {
+		return nil
+	}
+	return aws.String(dst.RegistryId)
+}
+
+func (c *awsEcrClinet) PlanImageCopy(repo string, img *domain.Image, dst *domain.CopyDestination) (*domain.CopyPlan, error) {
+	if err := domain.ValidateRepositoryName(dst.Repository); err != nil {
+		return nil, err
+	}
+	if err := domain.ValidateImageTag(dst.Tag); err != nil {
+		return nil, err
+	}
+	top, err := c.readManifest(repo, img.Digest)
+	if err != nil {
+		return nil, err
+	}
+	plan := &domain.CopyPlan{Repository: repo, Image: img, Destination: dst}
+	images := []*domain.CopyManifest{top}
+	if oci.IsIndex(top.MediaType) {
+		children, err := oci.IndexManifests(top.Body)
+		if err != nil {
+			return nil, err
+		}
+		images = nil
+		for _, d := range children {
+			m, err := c.readManifest(repo, d)
+			if err != nil {
+				return nil, err
+			}
+			images = append(images, m)
+		}
+		plan.Manifests = append(images, top)
+	} else {
+		plan.Manifests = images
+	}
+	seen := make(map[string]bool)
+	add := func(b *domain.CopyBlob) {
+		if !seen[b.Digest] {
+			seen[b.Digest] = true
+			plan.Blobs = append(plan.Blobs, b)
+		}
+	}
+	for _, cm := range images {
+		m, err := oci.ParseManifest(cm.Digest, cm.Body)
+		if err != nil {
+			return nil, err
+		}
+		add(&domain.CopyBlob{Digest: m.ConfigDigest, Kind: domain.CopyBlobConfig, SizeByte: m.ConfigSizeByte})
+		for _, l := range m.Layers {
+			add(&domain.CopyBlob{Digest: l.Digest, MediaType: l.MediaType, Kind: domain.CopyBlobLayer, SizeByte: l.SizeByte})
+		}
+	}
+	cli, err := c.destinationClient(dst)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLayerAvailability(cli, dst, plan.Blobs); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// readManifest reads a manifest as stored, to put it into the destination unchanged.
+func (c *awsEcrClinet) readManifest(repo, digest string) (*domain.CopyManifest, error) {
+	bodies, failures, err := c.batchGetImages(repo, []string{digest})
+	if err != nil {
+		return nil, err
+	}
+	if reason, ok := failures[digest]; ok {
+		return nil, fmt.Errorf("%s", reason)
+	}
+	bs := bodies[digest]
+	mt, err := oci.MediaType(bs)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.CopyManifest{Digest: digest, MediaType: mt, Body: bs}, nil
+}
+
+// checkLayerAvailability marks the blobs that the destination repository already has.
+func checkLayerAvailability(cli *ecr.ECR, dst *domain.CopyDestination, blobs []*domain.CopyBlob) error {
+	byDigest := make(map[string]*domain.CopyBlob, len(blobs))
+	for _, b := range blobs {
+		byDigest[b.Digest] = b
+	}
+	for start := 0; start < len(blobs); start += batchCheckLayerAvailabilityLimit {
+		end := start + batchCheckLayerAvailabilityLimit
+		if end > len(blobs) {
+			end = len(blobs)
+		}
+		input := &ecr.BatchCheckLayerAvailabilityInput{
+			RegistryId:     registryID(dst),
+			RepositoryName: aws.String(dst.Repository),
+		}
+		for _, b := range blobs[start:end] {
+			input.LayerDigests = append(input.LayerDigests, aws.String(b.Digest))
+		}
+		output, err := cli.BatchCheckLayerAvailability(input)
+		if err != nil {
+			return err
+		}
+		for _, l := range output.Layers {
+			if b, ok := byDigest[aws.StringValue(l.LayerDigest)]; ok {
+				b.Exists = aws.StringValue(l.LayerAvailability) == layerAvailable
+			}
+		}
+	}
+	return nil
+}
+
+func (c *awsEcrClinet) CopyImage(plan *domain.CopyPlan, progress func(i int, uploaded int64)) error {
+	dst := plan.Destination
+	cli, err := c.destinationClient(dst)
+	if err != nil {
+		return err
+	}
+	for i, b := range plan.Blobs {
+		if b.Exists {
+			continue
+		}
+		if err := c.uploadBlob(cli, plan.Repository, dst, b, func(n int64) { progress(i, n) }); err != nil {
+			return fmt.Errorf("upload %s: %v", b.Digest, err)
+		}
+	}
+	for i, m := range plan.Manifests {
+		input := &ecr.PutImageInput{
+			RegistryId:             registryID(dst),
+			RepositoryName:         aws.String(dst.Repository),
+			ImageManifest:          aws.String(string(m.Body)),
+			ImageManifestMediaType: aws.String(m.MediaType),
+			ImageDigest:            aws.String(m.Digest),
+		}
+		if i == len(plan.Manifests)-1 {
+			input.ImageTag = aws.String(dst.Tag)
+		}
+		if _, err := cli.PutImage(input); err != nil && !isErrorCode(err, ecr.ErrCodeImageAlreadyExistsException) {
+			return err
+		}
+	}
+	if dst.RegistryId == "" && (dst.Region == "" || dst.Region == c.region) {
+		c.imageCache.remove(dst.Repository)
+	}
+	return nil
+}
+
+// uploadBlob streams a blob from the source repository into the destination in the parts ECR asks for.
+func (c *awsEcrClinet) uploadBlob(cli *ecr.ECR, repo string, dst *domain.CopyDestination, b *domain.CopyBlob, progress func(int64)) error {
+	rc, err := c.openBlob(repo, b.Digest)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	initiated, err := cli.InitiateLayerUpload(&ecr.InitiateLayerUploadInput{
+		RegistryId:     registryID(dst),
+		RepositoryName: aws.String(dst.Repository),
+	})
+	if err != nil {
+		return err
+	}
+	partSize := aws.Int64Value(initiated.PartSize)
+	if partSize <= 0 {
+		partSize = defaultLayerPartSize
+	}
+	buf := make([]byte, partSize)
+	var uploaded int64
+	for {
+		n, err := io.ReadFull(rc, buf)
+		if n > 0 {
+			_, perr := cli.UploadLayerPart(&ecr.UploadLayerPartInput{
+				RegistryId:     registryID(dst),
+				RepositoryName: aws.String(dst.Repository),
+				UploadId:       initiated.UploadId,
+				PartFirstByte:  aws.Int64(uploaded),
+				PartLastByte:   aws.Int64(uploaded + int64(n) - 1),
+				LayerPartBlob:  buf[:n],
+			})
+			if perr != nil {
+				return perr
+			}
+			uploaded += int64(n)
+			progress(uploaded)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err = cli.CompleteLayerUpload(&ecr.CompleteLayerUploadInput{
+		RegistryId:     registryID(dst),
+		RepositoryName: aws.String(dst.Repository),
+		UploadId:       initiated.UploadId,
+		LayerDigests:   []*string{aws.String(b.Digest)},
+	})
+	if err != nil && !isErrorCode(err, ecr.ErrCodeLayerAlreadyExistsException) {
+		return err
+	}
+	return nil
+}
@@ -1,28 +1,136 @@
 package aws
 
 import (
+	"fmt"
+	"sort"
+	"sync"
+
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ecr"
 	"github.com/lusingander/ecr-browser/domain"
 )
 
+const (
+	batchDeleteImageLimit = 100
+)
+
 type awsEcrClinet struct {
-	cli *ecr.ECR
+	cli     *ecr.ECR
+	region  string
+	profile string
 	repositoryCache
-	imageCacheMap
+	*imageCache
+	*manifestCache
+	cacheRules []*domain.PullThroughCacheRule // nil until fetched
 }
 
 type repositoryCache []*domain.Repository
 
-type imageCacheMap map[string][]*domain.Image
+// imageCache is safe for concurrent use, so images of several repositories can be fetched at once.
+type imageCache struct {
+	mu sync.Mutex
+	m  map[string][]*domain.Image
+}
+
+func newImageCache() *imageCache {
+	return &imageCache{m: make(map[string][]*domain.Image)}
+}
+
+func (c *imageCache) get(repo string) ([]*domain.Image, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	imgs, ok := c.m[repo]
+	return imgs, ok
+}
 
-func NewAwsEcrClient() domain.ContainerClient {
+func (c *imageCache) set(repo string, imgs []*domain.Image) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.m[repo] = imgs
+}
+
+func (c *imageCache) remove(repo string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.m, repo)
+}
+
+// without drops deleted from the cached images of repo.
+func (c *imageCache) without(repo string, deleted map[*domain.Image]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cache, ok := c.m[repo]
+	if !ok {
+		return
+	}
+	ret := make([]*domain.Image, 0, len(cache))
+	for _, img := range cache {
+		if !deleted[img] {
+			ret = append(ret, img)
+		}
+	}
+	c.m[repo] = ret
+}
+
+func NewAwsEcrClient(region, profile string) (domain.ContainerClient, error) {
+	cli, err := createClient(region, profile)
+	if err != nil {
+		return nil, err
+	}
 	return &awsEcrClinet{
-		cli:             createClient(),
+		cli:             cli,
+		region:          region,
+		profile:         profile,
 		repositoryCache: make(repositoryCache, 0),
-		imageCacheMap:   make(imageCacheMap),
+		imageCache:      newImageCache(),
+		manifestCache:   newManifestCache(),
+	}, nil
+}
+
+func (c *awsEcrClinet) Region() string {
+	return c.region
+}
+
+func (c *awsEcrClinet) Regions() []string {
+	var ret []string
+	for id := range endpoints.AwsPartition().Services()[ecr.EndpointsID].Regions() {
+		ret = append(ret, id)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (c *awsEcrClinet) Profile() string {
+	return c.profile
+}
+
+func (c *awsEcrClinet) SetRegion(region string) error {
+	if _, ok := endpoints.AwsPartition().Services()[ecr.EndpointsID].Regions()[region]; !ok {
+		return fmt.Errorf("unknown region: %s", region)
+	}
+	return c.reset(region, c.profile)
+}
+
+func (c *awsEcrClinet) SetProfile(profile string) error {
+	return c.reset(c.region, profile)
+}
+
+func (c *awsEcrClinet) reset(region, profile string) error {
+	cli, err := createClient(region, profile)
+	if err != nil {
+		return err
 	}
+	c.cli = cli
+	c.region = region
+	c.profile = profile
+	c.repositoryCache = make(repositoryCache, 0)
+	c.imageCache = newImageCache()
+	c.manifestCache = newManifestCache()
+	c.cacheRules = nil
+	return nil
 }
 
 func (c *awsEcrClinet) FetchAllRepositories() ([]*domain.Repository, error) {
@@ -52,7 +160,7 @@ func (c *awsEcrClinet) FetchAllRepositories() ([]*domain.Repository, error) {
 }
 
 func (c *awsEcrClinet) FetchAllImages(repo string) ([]*domain.Image, error) {
-	if cache, ok := c.imageCacheMap[repo]; ok {
+	if cache, ok := c.imageCache.get(repo); ok {
 		return cache, nil
 	}
 	input := &ecr.DescribeImagesInput{
@@ -74,33 +182,285 @@ func (c *awsEcrClinet) FetchAllImages(repo string) ([]*domain.Image, error) {
 		}
 		input.SetNextToken(nextToken)
 	}
-	c.imageCacheMap[repo] = ret
+	c.imageCache.set(repo, ret)
 	return ret, nil
 }
 
+func (c *awsEcrClinet) CreateRepository(in *domain.CreateRepositoryInput) (*domain.Repository, error) {
+	input := &ecr.CreateRepositoryInput{
+		RepositoryName:     aws.String(in.Name),
+		ImageTagMutability: aws.String(in.TagMutability),
+		ImageScanningConfiguration: &ecr.ImageScanningConfiguration{
+			ScanOnPush: aws.Bool(in.ScanOnPush),
+		},
+		EncryptionConfiguration: &ecr.EncryptionConfiguration{
+			EncryptionType: aws.String(in.EncryptionType),
+		},
+	}
+	if in.KmsKey != "" {
+		input.EncryptionConfiguration.KmsKey = aws.String(in.KmsKey)
+	}
+	keys := make([]string, 0, len(in.Tags))
+	for k := range in.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		input.Tags = append(input.Tags, &ecr.Tag{Key: aws.String(k), Value: aws.String(in.Tags[k])})
+	}
+	output, err := c.cli.CreateRepository(input)
+	if err != nil {
+		return nil, err
+	}
+	repo := newRepository(output.Repository)
+	if len(c.repositoryCache) > 0 {
+		c.repositoryCache = append(c.repositoryCache, repo)
+	}
+	return repo, nil
+}
+
+func (c *awsEcrClinet) DeleteRepository(repo string, force bool) error {
+	input := &ecr.DeleteRepositoryInput{
+		RepositoryName: aws.String(repo),
+		Force:          aws.Bool(force),
+	}
+	if _, err := c.cli.DeleteRepository(input); err != nil {
+		return err
+	}
+	c.repositoryCache = c.repositoryCache.without(repo)
+	c.imageCache.remove(repo)
+	return nil
+}
+
+// without returns a new cache, so that lists built from the old one are not affected.
+func (c repositoryCache) without(repo string) repositoryCache {
+	ret := make(repositoryCache, 0, len(c))
+	for _, r := range c {
+		if r.Name != repo {
+			ret = append(ret, r)
+		}
+	}
+	return ret
+}
+
+func (c *awsEcrClinet) FetchRepositoryTags(repo *domain.Repository) (map[string]string, error) {
+	input := &ecr.ListTagsForResourceInput{
+		ResourceArn: aws.String(repo.Arn),
+	}
+	output, err := c.cli.ListTagsForResource(input)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string, len(output.Tags))
+	for _, t := range output.Tags {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+	return tags, nil
+}
+
+func (c *awsEcrClinet) SetTagMutability(repo *domain.Repository, mutability string) error {
+	input := &ecr.PutImageTagMutabilityInput{
+		RepositoryName:     aws.String(repo.Name),
+		ImageTagMutability: aws.String(mutability),
+	}
+	output, err := c.cli.PutImageTagMutability(input)
+	if err != nil {
+		return err
+	}
+	repo.TagMutability = aws.StringValue(output.ImageTagMutability)
+	return nil
+}
+
+func (c *awsEcrClinet) SetScanOnPush(repo *domain.Repository, scanOnPush bool) error {
+	input := &ecr.PutImageScanningConfigurationInput{
+		RepositoryName: aws.String(repo.Name),
+		ImageScanningConfiguration: &ecr.ImageScanningConfiguration{
+			ScanOnPush: aws.Bool(scanOnPush),
+		},
+	}
+	output, err := c.cli.PutImageScanningConfiguration(input)
+	if err != nil {
+		return err
+	}
+	repo.ScanOnPush = aws.BoolValue(output.ImageScanningConfiguration.ScanOnPush)
+	return nil
+}
+
+func (c *awsEcrClinet) DeleteImages(repo string, imgs []*domain.Image) ([]*domain.ImageDeleteFailure, error) {
+	byDigest := make(map[string]*domain.Image, len(imgs))
+	for _, img := range imgs {
+		byDigest[img.Digest] = img
+	}
+	var failures []*domain.ImageDeleteFailure
+	for start := 0; start < len(imgs); start += batchDeleteImageLimit {
+		end := start + batchDeleteImageLimit
+		if end > len(imgs) {
+			end = len(imgs)
+		}
+		input := &ecr.BatchDeleteImageInput{
+			RepositoryName: aws.String(repo),
+		}
+		for _, img := range imgs[start:end] {
+			input.ImageIds = append(input.ImageIds, &ecr.ImageIdentifier{ImageDigest: aws.String(img.Digest)})
+		}
+		output, err := c.cli.BatchDeleteImage(input)
+		if err != nil {
+			c.removeCachedImages(repo, imgs[:start], failures)
+			return failures, err
+		}
+		for _, f := range output.Failures {
+			failures = append(failures, &domain.ImageDeleteFailure{
+				Image:  byDigest[aws.StringValue(f.ImageId.ImageDigest)],
+				Reason: aws.StringValue(f.FailureReason),
+			})
+		}
+	}
+	c.removeCachedImages(repo, imgs, failures)
+	return failures, nil
+}
+
+// removeCachedImages drops the deleted images, all of imgs except failures, from the cache.
+func (c *awsEcrClinet) removeCachedImages(repo string, imgs []*domain.Image, failures []*domain.ImageDeleteFailure) {
+	deleted := make(map[*domain.Image]bool, len(imgs))
+	for _, img := range imgs {
+		deleted[img] = true
+	}
+	for _, f := range failures {
+		delete(deleted, f.Image)
+	}
+	c.imageCache.without(repo, deleted)
+}
+
+func (c *awsEcrClinet) FetchLifecyclePolicy(repo string) (string, error) {
+	input := &ecr.GetLifecyclePolicyInput{
+		RepositoryName: aws.String(repo),
+	}
+	output, err := c.cli.GetLifecyclePolicy(input)
+	if err != nil {
+		if isErrorCode(err, ecr.ErrCodeLifecyclePolicyNotFoundException) {
+			return "", nil
+		}
+		return "", err
+	}
+	return aws.StringValue(output.LifecyclePolicyText), nil
+}
+
+func (c *awsEcrClinet) PutLifecyclePolicy(repo, text string) error {
+	input := &ecr.PutLifecyclePolicyInput{
+		RepositoryName:      aws.String(repo),
+		LifecyclePolicyText: aws.String(text),
+	}
+	_, err := c.cli.PutLifecyclePolicy(input)
+	return err
+}
+
+func (c *awsEcrClinet) DeleteLifecyclePolicy(repo string) error {
+	input := &ecr.DeleteLifecyclePolicyInput{
+		RepositoryName: aws.String(repo),
+	}
+	_, err := c.cli.DeleteLifecyclePolicy(input)
+	if isErrorCode(err, ecr.ErrCodeLifecyclePolicyNotFoundException) {
+		return nil
+	}
+	return err
+}
+
+func (c *awsEcrClinet) FetchRepositoryPolicy(repo string) (string, error) {
+	input := &ecr.GetRepositoryPolicyInput{
+		RepositoryName: aws.String(repo),
+	}
+	output, err := c.cli.GetRepositoryPolicy(input)
+	if err != nil {
+		if isErrorCode(err, ecr.ErrCodeRepositoryPolicyNotFoundException) {
+			return "", nil
+		}
+		return "", err
+	}
+	return aws.StringValue(output.PolicyText), nil
+}
+
+func (c *awsEcrClinet) PutRepositoryPolicy(repo, text string) error {
+	input := &ecr.SetRepositoryPolicyInput{
+		RepositoryName: aws.String(repo),
+		PolicyText:     aws.String(text),
+	}
+	_, err := c.cli.SetRepositoryPolicy(input)
+	return err
+}
+
+func (c *awsEcrClinet) DeleteRepositoryPolicy(repo string) error {
+	input := &ecr.DeleteRepositoryPolicyInput{
+		RepositoryName: aws.String(repo),
+	}
+	_, err := c.cli.DeleteRepositoryPolicy(input)
+	if isErrorCode(err, ecr.ErrCodeRepositoryPolicyNotFoundException) {
+		return nil
+	}
+	return err
+}
+
+func isErrorCode(err error, code string) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code() == code
+	}
+	return false
+}
+
 func newRepository(r *ecr.Repository) *domain.Repository {
+	var scanOnPush bool
+	if r.ImageScanningConfiguration != nil {
+		scanOnPush = aws.BoolValue(r.ImageScanningConfiguration.ScanOnPush)
+	}
+	var encryptionType, kmsKey string
+	if r.EncryptionConfiguration != nil {
+		encryptionType = aws.StringValue(r.EncryptionConfiguration.EncryptionType)
+		kmsKey = aws.StringValue(r.EncryptionConfiguration.KmsKey)
+	}
 	return domain.NewRepository(
 		aws.StringValue(r.RepositoryName),
 		aws.StringValue(r.RepositoryUri),
 		aws.StringValue(r.RepositoryArn),
+		aws.StringValue(r.RegistryId),
 		aws.StringValue(r.ImageTagMutability),
+		scanOnPush,
+		encryptionType,
+		kmsKey,
 		aws.TimeValue(r.CreatedAt),
 	)
 }
 
 func newImage(i *ecr.ImageDetail) *domain.Image {
+	var scanStatus string
+	if i.ImageScanStatus != nil {
+		scanStatus = aws.StringValue(i.ImageScanStatus.Status)
+	}
 	return domain.NewImage(
 		aws.StringValueSlice(i.ImageTags),
 		aws.TimeValue(i.ImagePushedAt),
 		aws.StringValue(i.ImageDigest),
 		aws.Int64Value(i.ImageSizeInBytes),
+		scanStatus,
+		aws.TimeValue(i.LastRecordedPullTime),
+		aws.StringValue(i.ImageManifestMediaType),
+		aws.StringValue(i.ArtifactMediaType),
 	)
 }
 
-func createClient() *ecr.ECR {
-	sess := session.Must(session.NewSession(&aws.Config{
-		Region: aws.String(domain.TargetRegion),
-	}))
+func createClient(region, profile string) (*ecr.ECR, error) {
+	sess, err := createSession(region, profile)
+	if err != nil {
+		return nil, err
+	}
 	svc := ecr.New(sess)
-	return svc
+	return svc, nil
+}
+
+func createSession(region, profile string) (*session.Session, error) {
+	return session.NewSessionWithOptions(session.Options{
+		Config: aws.Config{
+			Region: aws.String(region),
+		},
+		Profile:           profile,
+		SharedConfigState: session.SharedConfigEnable,
+	})
 }
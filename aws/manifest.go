@@ -0,0 +1,239 @@
+package aws
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/oci"
+)
+
+const (
+	batchGetImageLimit = 100
+)
+
+// manifestCache is keyed by image digest, which identifies the content in any repository.
+// The configs of the cached manifests are read on first use.
+type manifestCache struct {
+	mu sync.Mutex
+	m  map[string]*domain.Manifest
+}
+
+func newManifestCache() *manifestCache {
+	return &manifestCache{m: make(map[string]*domain.Manifest)}
+}
+
+func (c *manifestCache) get(digest string) (*domain.Manifest, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.m[digest]
+	return m, ok
+}
+
+func (c *manifestCache) set(digest string, m *domain.Manifest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.m[digest] = m
+}
+
+func (c *awsEcrClinet) FetchImageManifest(repo string, img *domain.Image) (*domain.Manifest, error) {
+	m, ok := c.manifestCache.get(img.Digest)
+	if !ok {
+		ms, failures, err := c.fetchManifests(repo, []string{img.Digest})
+		if err != nil {
+			return nil, err
+		}
+		if reason, ok := failures[img.Digest]; ok {
+			return nil, fmt.Errorf("%s", reason)
+		}
+		m = ms[img.Digest]
+	}
+	if m.Config == nil && m.ConfigDigest != "" {
+		bs, err := c.downloadBlob(repo, m.ConfigDigest)
+		if err != nil {
+			return nil, err
+		}
+		if m.Config, err = oci.ParseConfig(bs); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (c *awsEcrClinet) FetchImageManifests(repo string, imgs []*domain.Image) ([]*domain.Manifest, error) {
+	ret := make([]*domain.Manifest, len(imgs))
+	var missing []string
+	for i, img := range imgs {
+		if m, ok := c.manifestCache.get(img.Digest); ok {
+			ret[i] = m
+		} else {
+			missing = append(missing, img.Digest)
+		}
+	}
+	ms, _, err := c.fetchManifests(repo, missing)
+	if err != nil {
+		return nil, err
+	}
+	for i, img := range imgs {
+		if ret[i] == nil {
+			ret[i] = ms[img.Digest]
+		}
+	}
+	return ret, nil
+}
+
+// fetchManifests reads and caches the manifests of digests, resolving indexes to a platform.
+// It returns the reasons the manifests of the other digests cannot be read.
+func (c *awsEcrClinet) fetchManifests(repo string, digests []string) (map[string]*domain.Manifest, map[string]string, error) {
+	bodies, failures, err := c.batchGetImages(repo, digests)
+	if err != nil {
+		return nil, nil, err
+	}
+	platforms := make(map[string]string)
+	var children []string
+	for d, bs := range bodies {
+		mt, err := oci.MediaType(bs)
+		if err != nil {
+			failures[d] = err.Error()
+			continue
+		}
+		if !oci.IsIndex(mt) {
+			continue
+		}
+		child, err := oci.SelectPlatform(bs)
+		if err != nil {
+			failures[d] = err.Error()
+			continue
+		}
+		platforms[d] = child
+		children = append(children, child)
+	}
+	childBodies, childFailures, err := c.batchGetImages(repo, children)
+	if err != nil {
+		return nil, nil, err
+	}
+	ret := make(map[string]*domain.Manifest, len(bodies))
+	for d, bs := range bodies {
+		if _, ok := failures[d]; ok {
+			continue
+		}
+		digest := d
+		if child, ok := platforms[d]; ok {
+			if reason, ok := childFailures[child]; ok {
+				failures[d] = reason
+				continue
+			}
+			digest, bs = child, childBodies[child]
+		}
+		m, err := oci.ParseManifest(digest, bs)
+		if err != nil {
+			failures[d] = err.Error()
+			continue
+		}
+		c.manifestCache.set(d, m)
+		ret[d] = m
+	}
+	return ret, failures, nil
+}
+
+func (c *awsEcrClinet) FetchIndexChildren(repo string, imgs []*domain.Image) ([]string, error) {
+	var indexes []string
+	for _, img := range imgs {
+		if oci.IsIndex(img.ManifestMediaType) {
+			indexes = append(indexes, img.Digest)
+		}
+	}
+	bodies, failures, err := c.batchGetImages(repo, indexes)
+	if err != nil {
+		return nil, err
+	}
+	var ret []string
+	for _, d := range indexes {
+		if reason, ok := failures[d]; ok {
+			return nil, fmt.Errorf("%s", reason)
+		}
+		children, err := oci.IndexManifests(bodies[d])
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, children...)
+	}
+	return ret, nil
+}
+
+// batchGetImages reads the manifests of digests, batchGetImageLimit at a time.
+func (c *awsEcrClinet) batchGetImages(repo string, digests []string) (map[string][]byte, map[string]string, error) {
+	bodies := make(map[string][]byte, len(digests))
+	failures := make(map[string]string)
+	for start := 0; start < len(digests); start += batchGetImageLimit {
+		end := start + batchGetImageLimit
+		if end > len(digests) {
+			end = len(digests)
+		}
+		input := &ecr.BatchGetImageInput{
+			RepositoryName:     aws.String(repo),
+			AcceptedMediaTypes: aws.StringSlice(oci.ManifestMediaTypes),
+		}
+		for _, d := range digests[start:end] {
+			input.ImageIds = append(input.ImageIds, &ecr.ImageIdentifier{ImageDigest: aws.String(d)})
+		}
+		output, err := c.cli.BatchGetImage(input)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, img := range output.Images {
+			bodies[aws.StringValue(img.ImageId.ImageDigest)] = []byte(aws.StringValue(img.ImageManifest))
+		}
+		for _, f := range output.Failures {
+			failures[aws.StringValue(f.ImageId.ImageDigest)] = fmt.Sprintf("%s: %s", aws.StringValue(f.FailureCode), aws.StringValue(f.FailureReason))
+		}
+	}
+	for _, d := range digests {
+		if _, ok := bodies[d]; !ok {
+			if _, ok := failures[d]; !ok {
+				failures[d] = fmt.Sprintf("manifest of %s not found", d)
+			}
+		}
+	}
+	return bodies, failures, nil
+}
+
+func (c *awsEcrClinet) FetchLayerBlob(repo, digest string) (io.ReadCloser, error) {
+	return c.openBlob(repo, digest)
+}
+
+// downloadBlob reads a blob, such as an image config.
+func (c *awsEcrClinet) downloadBlob(repo, digest string) ([]byte, error) {
+	rc, err := c.openBlob(repo, digest)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+// openBlob streams a blob from the URL ECR signs for it.
+func (c *awsEcrClinet) openBlob(repo, digest string) (io.ReadCloser, error) {
+	input := &ecr.GetDownloadUrlForLayerInput{
+		RepositoryName: aws.String(repo),
+		LayerDigest:    aws.String(digest),
+	}
+	output, err := c.cli.GetDownloadUrlForLayer(input)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.Get(aws.StringValue(output.DownloadUrl))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("download %s: %s", digest, resp.Status)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,238 @@
+package aws
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/service/ecrpublic"
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+const (
+	// publicRegion is the only region serving the ECR Public API.
+	publicRegion = endpoints.UsEast1RegionID
+
+	publicConsoleURL = "https://console.aws.amazon.com/ecr/repositories/public/%s/%s?region=%s"
+)
+
+type awsEcrPublicClient struct {
+	cli     *ecrpublic.ECRPublic
+	profile string
+	repositoryCache
+	*imageCache
+}
+
+func NewAwsEcrPublicClient(profile string) (domain.ContainerClient, error) {
+	cli, err := createPublicClient(profile)
+	if err != nil {
+		return nil, err
+	}
+	return &awsEcrPublicClient{
+		cli:             cli,
+		profile:         profile,
+		repositoryCache: make(repositoryCache, 0),
+		imageCache:      newImageCache(),
+	}, nil
+}
+
+func createPublicClient(profile string) (*ecrpublic.ECRPublic, error) {
+	sess, err := createSession(publicRegion, profile)
+	if err != nil {
+		return nil, err
+	}
+	return ecrpublic.New(sess), nil
+}
+
+func (c *awsEcrPublicClient) Region() string {
+	return publicRegion
+}
+
+func (c *awsEcrPublicClient) Regions() []string {
+	return []string{publicRegion}
+}
+
+func (c *awsEcrPublicClient) SetRegion(region string) error {
+	if region != publicRegion {
+		return fmt.Errorf("ECR Public is only available in %s", publicRegion)
+	}
+	return nil
+}
+
+func (c *awsEcrPublicClient) SetProfile(profile string) error {
+	cli, err := createPublicClient(profile)
+	if err != nil {
+		return err
+	}
+	c.cli = cli
+	c.profile = profile
+	c.repositoryCache = make(repositoryCache, 0)
+	c.imageCache = newImageCache()
+	return nil
+}
+
+func (c *awsEcrPublicClient) FetchAllRepositories() ([]*domain.Repository, error) {
+	if len(c.repositoryCache) > 0 {
+		return c.repositoryCache, nil
+	}
+	input := &ecrpublic.DescribeRepositoriesInput{
+		MaxResults: aws.Int64(100),
+	}
+	var ret []*domain.Repository
+	for {
+		output, err := c.cli.DescribeRepositories(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range output.Repositories {
+			ret = append(ret, newPublicRepository(r))
+		}
+		nextToken := aws.StringValue(output.NextToken)
+		if nextToken == "" {
+			break
+		}
+		input.SetNextToken(nextToken)
+	}
+	c.repositoryCache = ret
+	return ret, nil
+}
+
+// FetchAllImages lists the images with DescribeImages and takes their tags from DescribeImageTags,
+// which keeps the tags in the order they were created.
+func (c *awsEcrPublicClient) FetchAllImages(repo string) ([]*domain.Image, error) {
+	if cache, ok := c.imageCache.get(repo); ok {
+		return cache, nil
+	}
+	input := &ecrpublic.DescribeImagesInput{
+		MaxResults:     aws.Int64(100),
+		RepositoryName: aws.String(repo),
+	}
+	var ret []*domain.Image
+	for {
+		output, err := c.cli.DescribeImages(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range output.ImageDetails {
+			ret = append(ret, newPublicImage(i))
+		}
+		nextToken := aws.StringValue(output.NextToken)
+		if nextToken == "" {
+			break
+		}
+		input.SetNextToken(nextToken)
+	}
+	tags, err := c.fetchImageTags(repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, img := range ret {
+		if ts, ok := tags[img.Digest]; ok {
+			img.Tags = ts
+		}
+	}
+	c.imageCache.set(repo, ret)
+	return ret, nil
+}
+
+func (c *awsEcrPublicClient) fetchImageTags(repo string) (map[string][]string, error) {
+	input := &ecrpublic.DescribeImageTagsInput{
+		MaxResults:     aws.Int64(100),
+		RepositoryName: aws.String(repo),
+	}
+	ret := make(map[string][]string)
+	for {
+		output, err := c.cli.DescribeImageTags(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range output.ImageTagDetails {
+			if t.ImageDetail == nil {
+				continue
+			}
+			d := aws.StringValue(t.ImageDetail.ImageDigest)
+			ret[d] = append(ret[d], aws.StringValue(t.ImageTag))
+		}
+		nextToken := aws.StringValue(output.NextToken)
+		if nextToken == "" {
+			break
+		}
+		input.SetNextToken(nextToken)
+	}
+	return ret, nil
+}
+
+func (c *awsEcrPublicClient) FetchRepositoryTags(repo *domain.Repository) (map[string]string, error) {
+	input := &ecrpublic.ListTagsForResourceInput{
+		ResourceArn: aws.String(repo.Arn),
+	}
+	output, err := c.cli.ListTagsForResource(input)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string, len(output.Tags))
+	for _, t := range output.Tags {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+	return tags, nil
+}
+
+func (c *awsEcrPublicClient) FetchRepositoryCatalogData(repo *domain.Repository) (*domain.RepositoryCatalogData, error) {
+	input := &ecrpublic.GetRepositoryCatalogDataInput{
+		RepositoryName: aws.String(repo.Name),
+	}
+	output, err := c.cli.GetRepositoryCatalogData(input)
+	if err != nil {
+		return nil, err
+	}
+	d := output.CatalogData
+	if d == nil {
+		return &domain.RepositoryCatalogData{}, nil
+	}
+	return &domain.RepositoryCatalogData{
+		Description:          aws.StringValue(d.Description),
+		AboutText:            aws.StringValue(d.AboutText),
+		UsageText:            aws.StringValue(d.UsageText),
+		Architectures:        aws.StringValueSlice(d.Architectures),
+		OperatingSystems:     aws.StringValueSlice(d.OperatingSystems),
+		LogoURL:              aws.StringValue(d.LogoUrl),
+		MarketplaceCertified: aws.BoolValue(d.MarketplaceCertified),
+	}, nil
+}
+
+// RepositoryConsoleURL returns the gallery page of the repository,
+// or its page in the console if the URI is not a public.ecr.aws one.
+func (c *awsEcrPublicClient) RepositoryConsoleURL(repo *domain.Repository) string {
+	if url := repo.GalleryURL(); url != "" {
+		return url
+	}
+	return fmt.Sprintf(publicConsoleURL, repo.RegistryId, repo.Name, publicRegion)
+}
+
+func newPublicRepository(r *ecrpublic.Repository) *domain.Repository {
+	return domain.NewRepository(
+		aws.StringValue(r.RepositoryName),
+		aws.StringValue(r.RepositoryUri),
+		aws.StringValue(r.RepositoryArn),
+		aws.StringValue(r.RegistryId),
+		"",
+		false,
+		"",
+		"",
+		aws.TimeValue(r.CreatedAt),
+	)
+}
+
+func newPublicImage(i *ecrpublic.ImageDetail) *domain.Image {
+	return domain.NewImage(
+		aws.StringValueSlice(i.ImageTags),
+		aws.TimeValue(i.ImagePushedAt),
+		aws.StringValue(i.ImageDigest),
+		aws.Int64Value(i.ImageSizeInBytes),
+		"",
+		time.Time{},
+		aws.StringValue(i.ImageManifestMediaType),
+		aws.StringValue(i.ArtifactMediaType),
+	)
+}
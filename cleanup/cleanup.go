@@ -0,0 +1,156 @@
+package cleanup
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+// Rules selects the images proposed for deletion. A zero value disables each rule.
+type Rules struct {
+	Untagged      bool
+	NotPulledDays int    // images not pulled (or pushed) in this many days
+	TagPattern    string // with KeepNewest, all but the newest images with a tag matching this glob
+	KeepNewest    int
+	MinSizeByte   int64    // images at least this large
+	Keep          []string // globs of tags that are never proposed
+}
+
+// DefaultRules proposes untagged images and keeps the tags usually deployed.
+func DefaultRules() *Rules {
+	return &Rules{
+		Untagged: true,
+		Keep:     []string{"latest", "prod-*"},
+	}
+}
+
+// Validate checks that the patterns are valid globs.
+func (r *Rules) Validate() error {
+	for _, p := range append([]string{r.TagPattern}, r.Keep...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern: %s", p)
+		}
+	}
+	if r.KeepNewest < 0 || r.NotPulledDays < 0 || r.MinSizeByte < 0 {
+		return fmt.Errorf("numbers must not be negative")
+	}
+	return nil
+}
+
+// Candidate is an image proposed for deletion and why.
+type Candidate struct {
+	Image    *domain.Image
+	Reasons  []string
+	Selected bool
+}
+
+func (c *Candidate) Display() string {
+	mark := "[ ]"
+	if c.Selected {
+		mark = "[x]"
+	}
+	return mark + " " + c.Image.GetTag()
+}
+
+// Propose returns the images of imgs that match any rule and are not protected,
+// newest first. All candidates start selected.
+// children are the digests of the manifests listed by the indexes in the repository.
+// They are usually untagged, but deleting them would break the index, so they are never proposed.
+func Propose(r *Rules, imgs []*domain.Image, children []string, now time.Time) []*Candidate {
+	referenced := make(map[string]bool, len(children))
+	for _, d := range children {
+		referenced[d] = true
+	}
+
+	sorted := make([]*domain.Image, len(imgs))
+	copy(sorted, imgs)
+	domain.SortImages(sorted)
+
+	reasons := make(map[*domain.Image][]string)
+	add := func(img *domain.Image, reason string) {
+		reasons[img] = append(reasons[img], reason)
+	}
+	if r.Untagged {
+		for _, img := range sorted {
+			if len(img.Tags) == 0 {
+				add(img, "untagged")
+			}
+		}
+	}
+	if r.NotPulledDays > 0 {
+		threshold := now.AddDate(0, 0, -r.NotPulledDays)
+		for _, img := range sorted {
+			if img.LastUsedAt().Before(threshold) {
+				add(img, fmt.Sprintf("not pulled in %d days", r.NotPulledDays))
+			}
+		}
+	}
+	if r.TagPattern != "" {
+		n := 0
+		for _, img := range sorted {
+			if !matchAny(img.Tags, []string{r.TagPattern}) {
+				continue
+			}
+			n++
+			if n > r.KeepNewest {
+				add(img, fmt.Sprintf("beyond newest %d matching %s", r.KeepNewest, r.TagPattern))
+			}
+		}
+	}
+	if r.MinSizeByte > 0 {
+		for _, img := range sorted {
+			if img.SizeByte >= r.MinSizeByte {
+				add(img, fmt.Sprintf("larger than %s", humanize.Bytes(uint64(r.MinSizeByte))))
+			}
+		}
+	}
+
+	var ret []*Candidate
+	for _, img := range sorted {
+		rs, ok := reasons[img]
+		if !ok || Protected(r, img) || referenced[img.Digest] {
+			continue
+		}
+		ret = append(ret, &Candidate{Image: img, Reasons: rs, Selected: true})
+	}
+	return ret
+}
+
+// Protected reports whether img has a tag on the keep-list.
+func Protected(r *Rules, img *domain.Image) bool {
+	return matchAny(img.Tags, r.Keep)
+}
+
+func matchAny(tags, patterns []string) bool {
+	for _, t := range tags {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, t); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Selected returns the selected candidates.
+func Selected(cs []*Candidate) []*Candidate {
+	var ret []*Candidate
+	for _, c := range cs {
+		if c.Selected {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+// TotalSize returns the sum of the image sizes of cs.
+func TotalSize(cs []*Candidate) int64 {
+	var size int64
+	for _, c := range cs {
+		size += c.Image.SizeByte
+	}
+	return size
+}
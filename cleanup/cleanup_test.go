@@ -0,0 +1,101 @@
+package cleanup
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+var now = time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC)
+
+// testImage describes an image of the fixtures. pulledDaysAgo is negative if it was never pulled.
+type testImage struct {
+	digest        string
+	tag           string
+	pushedDaysAgo int
+	pulledDaysAgo int
+	size          int64
+}
+
+func images(ts ...testImage) []*domain.Image {
+	ret := make([]*domain.Image, len(ts))
+	for i, ti := range ts {
+		var tags []string
+		if ti.tag != "" {
+			tags = []string{ti.tag}
+		}
+		var pulledAt time.Time
+		if ti.pulledDaysAgo >= 0 {
+			pulledAt = now.AddDate(0, 0, -ti.pulledDaysAgo)
+		}
+		ret[i] = domain.NewImage(tags, now.AddDate(0, 0, -ti.pushedDaysAgo), ti.digest, ti.size, "", pulledAt, "", "")
+	}
+	return ret
+}
+
+func digests(cs []*Candidate) []string {
+	var ret []string
+	for _, c := range cs {
+		ret = append(ret, c.Image.Digest)
+	}
+	return ret
+}
+
+func TestPropose(t *testing.T) {
+	imgs := images(
+		testImage{"sha256:latest", "latest", 1, 0, 10},
+		testImage{"sha256:v3", "v3", 2, 1, 10},
+		testImage{"sha256:v2", "v2", 45, 40, 10},
+		testImage{"sha256:v1", "v1", 4, -1, 10},
+		testImage{"sha256:untagged", "", 5, -1, 10},
+		testImage{"sha256:prod-1", "prod-1", 60, -1, 10},
+		testImage{"sha256:big", "big", 6, 2, 1000},
+	)
+	tests := []struct {
+		rules *Rules
+		want  []string
+	}{
+		{&Rules{Untagged: true}, []string{"sha256:untagged"}},
+		{&Rules{NotPulledDays: 30, Keep: []string{"prod-*"}}, []string{"sha256:v2"}},
+		{&Rules{TagPattern: "v*", KeepNewest: 1}, []string{"sha256:v1", "sha256:v2"}},
+		{&Rules{MinSizeByte: 100}, []string{"sha256:big"}},
+		{&Rules{Untagged: true, NotPulledDays: 30, Keep: []string{"latest", "prod-*"}}, []string{"sha256:untagged", "sha256:v2"}},
+	}
+	for _, test := range tests {
+		if got := digests(Propose(test.rules, imgs, nil, now)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Propose(%+v) = %q; want = %q", test.rules, got, test.want)
+		}
+	}
+}
+
+func TestProposeReasons(t *testing.T) {
+	tests := []struct {
+		rules *Rules
+		want  []string
+	}{
+		{&Rules{Untagged: true, NotPulledDays: 30, MinSizeByte: 100}, []string{"untagged", "not pulled in 30 days", "larger than 100 B"}},
+		{&Rules{NotPulledDays: 30}, []string{"not pulled in 30 days"}},
+	}
+	imgs := images(testImage{"sha256:untagged", "", 50, -1, 1000})
+	for _, test := range tests {
+		cs := Propose(test.rules, imgs, nil, now)
+		if len(cs) != 1 || !reflect.DeepEqual(cs[0].Reasons, test.want) {
+			t.Errorf("Propose(%+v) = %+v; want reasons = %q", test.rules, cs, test.want)
+		}
+	}
+}
+
+func TestProposeIndexChildren(t *testing.T) {
+	index := domain.NewImage([]string{"v1"}, now.AddDate(0, 0, -3), "sha256:index", 0, "", time.Time{}, "application/vnd.oci.image.index.v1+json", "")
+	amd := domain.NewImage(nil, now.AddDate(0, 0, -3), "sha256:amd64", 10, "", time.Time{}, "", "")
+	arm := domain.NewImage(nil, now.AddDate(0, 0, -3), "sha256:arm64", 10, "", time.Time{}, "", "")
+	orphan := domain.NewImage(nil, now.AddDate(0, 0, -9), "sha256:orphan", 10, "", time.Time{}, "", "")
+	imgs := []*domain.Image{index, amd, arm, orphan}
+
+	got := digests(Propose(&Rules{Untagged: true}, imgs, []string{"sha256:amd64", "sha256:arm64"}, now))
+	if want := []string{"sha256:orphan"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Propose() = %q; want = %q", got, want)
+	}
+}
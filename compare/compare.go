@@ -0,0 +1,277 @@
+// Package compare finds what changed between two images.
+package compare
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/util"
+)
+
+type Status int
+
+const (
+	Same Status = iota
+	Added
+	Removed
+	Changed
+)
+
+// Mark returns the diff prefix of s.
+func (s Status) Mark() string {
+	switch s {
+	case Added:
+		return "+"
+	case Removed:
+		return "-"
+	case Changed:
+		return "~"
+	default:
+		return " "
+	}
+}
+
+// Side is one of the images compared.
+type Side struct {
+	Repository string
+	Image      *domain.Image
+	Manifest   *domain.Manifest
+}
+
+// Name is repository:tag with the first tag, or repository@digest if untagged.
+func (s *Side) Name() string {
+	if len(s.Image.Tags) > 0 {
+		return s.Repository + ":" + s.Image.Tags[0]
+	}
+	d := s.Image.Digest
+	if len(d) > 19 {
+		d = d[:19]
+	}
+	return s.Repository + "@" + d
+}
+
+func (s *Side) config() *domain.ImageConfig {
+	if s.Manifest == nil || s.Manifest.Config == nil {
+		return &domain.ImageConfig{}
+	}
+	return s.Manifest.Config
+}
+
+func (s *Side) layers() []*domain.Layer {
+	if s.Manifest == nil {
+		return nil
+	}
+	return s.Manifest.Layers
+}
+
+type LayerChange struct {
+	Layer  *domain.Layer
+	Status Status
+}
+
+// ValueChange is a change of a keyed value, such as a tag, an env var or a label.
+type ValueChange struct {
+	Key    string
+	From   string
+	To     string
+	Status Status
+}
+
+func (c *ValueChange) String() string {
+	switch c.Status {
+	case Added:
+		return fmt.Sprintf("+ %s: %s", c.Key, c.To)
+	case Removed:
+		return fmt.Sprintf("- %s: %s", c.Key, c.From)
+	case Changed:
+		return fmt.Sprintf("~ %s: %s -> %s", c.Key, c.From, c.To)
+	default:
+		return fmt.Sprintf("  %s: %s", c.Key, c.To)
+	}
+}
+
+// Result is what changed from one image to another.
+type Result struct {
+	From, To  *Side
+	SizeDelta int64
+	Tags      []*ValueChange
+	Layers    []*LayerChange // the layers of To in order, then the removed ones
+	Config    []*ValueChange
+	Env       []*ValueChange
+	Labels    []*ValueChange
+	History   []string // lines prefixed like util.DiffLines
+}
+
+// Compare compares from with to. A side without a manifest (or config) compares as empty.
+func Compare(from, to *Side) *Result {
+	fc, tc := from.config(), to.config()
+	return &Result{
+		From:      from,
+		To:        to,
+		SizeDelta: to.Image.SizeByte - from.Image.SizeByte,
+		Tags:      compareValues(tagMap(from.Image.Tags), tagMap(to.Image.Tags)),
+		Layers:    compareLayers(from.layers(), to.layers()),
+		Config:    compareValues(configMap(fc), configMap(tc)),
+		Env:       compareValues(envMap(fc.Env), envMap(tc.Env)),
+		Labels:    compareValues(fc.Labels, tc.Labels),
+		History:   util.DiffLines(historyLines(fc), historyLines(tc)),
+	}
+}
+
+func compareLayers(from, to []*domain.Layer) []*LayerChange {
+	inFrom := make(map[string]bool, len(from))
+	for _, l := range from {
+		inFrom[l.Digest] = true
+	}
+	inTo := make(map[string]bool, len(to))
+	var ret []*LayerChange
+	for _, l := range to {
+		inTo[l.Digest] = true
+		s := Added
+		if inFrom[l.Digest] {
+			s = Same
+		}
+		ret = append(ret, &LayerChange{l, s})
+	}
+	for _, l := range from {
+		if !inTo[l.Digest] {
+			ret = append(ret, &LayerChange{l, Removed})
+		}
+	}
+	return ret
+}
+
+// LayerCounts returns how many layers were added, removed and are shared.
+func (r *Result) LayerCounts() (added, removed, shared int) {
+	for _, c := range r.Layers {
+		switch c.Status {
+		case Added:
+			added++
+		case Removed:
+			removed++
+		default:
+			shared++
+		}
+	}
+	return
+}
+
+// SharedSize returns the total size of the layers both images use.
+func (r *Result) SharedSize() int64 {
+	var size int64
+	for _, c := range r.Layers {
+		if c.Status == Same {
+			size += c.Layer.SizeByte
+		}
+	}
+	return size
+}
+
+// Changes counts the values that are not the same.
+func Changes(cs []*ValueChange) int {
+	n := 0
+	for _, c := range cs {
+		if c.Status != Same {
+			n++
+		}
+	}
+	return n
+}
+
+// HistoryChanges counts the added and removed history lines.
+func (r *Result) HistoryChanges() int {
+	n := 0
+	for _, l := range r.History {
+		if !strings.HasPrefix(l, "  ") {
+			n++
+		}
+	}
+	return n
+}
+
+func compareValues(from, to map[string]string) []*ValueChange {
+	keys := make(map[string]bool, len(from)+len(to))
+	for k := range from {
+		keys[k] = true
+	}
+	for k := range to {
+		keys[k] = true
+	}
+	var ret []*ValueChange
+	for k := range keys {
+		f, inFrom := from[k]
+		t, inTo := to[k]
+		c := &ValueChange{Key: k, From: f, To: t}
+		switch {
+		case !inFrom:
+			c.Status = Added
+		case !inTo:
+			c.Status = Removed
+		case f != t:
+			c.Status = Changed
+		}
+		ret = append(ret, c)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Key < ret[j].Key })
+	return ret
+}
+
+func tagMap(tags []string) map[string]string {
+	m := make(map[string]string, len(tags))
+	for _, t := range tags {
+		m[t] = t
+	}
+	return m
+}
+
+func envMap(env []string) map[string]string {
+	m := make(map[string]string, len(env))
+	for _, e := range env {
+		kv := strings.SplitN(e, "=", 2)
+		if len(kv) == 2 {
+			m[kv[0]] = kv[1]
+		} else {
+			m[kv[0]] = ""
+		}
+	}
+	return m
+}
+
+func configMap(c *domain.ImageConfig) map[string]string {
+	m := make(map[string]string)
+	add := func(k, v string) {
+		if v != "" {
+			m[k] = v
+		}
+	}
+	if c.OS != "" || c.Architecture != "" {
+		add("platform", c.OS+"/"+c.Architecture)
+	}
+	add("entrypoint", quoteAll(c.Entrypoint))
+	add("cmd", quoteAll(c.Cmd))
+	add("workdir", c.WorkingDir)
+	add("user", c.User)
+	add("ports", strings.Join(c.ExposedPorts, ", "))
+	return m
+}
+
+func quoteAll(ss []string) string {
+	if len(ss) == 0 {
+		return ""
+	}
+	qs := make([]string, len(ss))
+	for i, s := range ss {
+		qs[i] = fmt.Sprintf("%q", s)
+	}
+	return "[" + strings.Join(qs, ", ") + "]"
+}
+
+func historyLines(c *domain.ImageConfig) []string {
+	ls := make([]string, len(c.History))
+	for i, h := range c.History {
+		ls[i] = h.CreatedBy
+	}
+	return ls
+}
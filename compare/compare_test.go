@@ -0,0 +1,70 @@
+package compare
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+func side(tags []string, size int64, layers []string, env []string, cmd []string, history []string) *Side {
+	m := &domain.Manifest{Config: &domain.ImageConfig{OS: "linux", Architecture: "amd64", Env: env, Cmd: cmd}}
+	for _, l := range layers {
+		m.Layers = append(m.Layers, &domain.Layer{Digest: l, SizeByte: 10})
+	}
+	for _, h := range history {
+		m.Config.History = append(m.Config.History, &domain.HistoryEntry{CreatedBy: h})
+	}
+	return &Side{"app", domain.NewImage(tags, time.Time{}, "", size, "", time.Time{}, "", ""), m}
+}
+
+func TestCompare(t *testing.T) {
+	from := side([]string{"v1", "latest"}, 100, []string{"base", "deps1", "app1"},
+		[]string{"PATH=/bin", "VERSION=1"}, []string{"serve"}, []string{"FROM base", "COPY deps", "COPY app"})
+	to := side([]string{"v2"}, 130, []string{"base", "deps1", "app2", "extra"},
+		[]string{"PATH=/bin", "VERSION=2", "DEBUG=1"}, []string{"serve", "--debug"}, []string{"FROM base", "COPY deps", "COPY app", "RUN extra"})
+	r := Compare(from, to)
+
+	if r.SizeDelta != 30 {
+		t.Errorf("SizeDelta = %v; want = %v", r.SizeDelta, 30)
+	}
+	var tags []string
+	for _, c := range r.Tags {
+		tags = append(tags, c.String())
+	}
+	if want := []string{"- latest: latest", "- v1: v1", "+ v2: v2"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("Tags = %q; want = %q", tags, want)
+	}
+	if a, d, s := r.LayerCounts(); a != 2 || d != 1 || s != 2 {
+		t.Errorf("LayerCounts() = %v, %v, %v; want = 2, 1, 2", a, d, s)
+	}
+	if got := r.Layers[len(r.Layers)-1]; got.Layer.Digest != "app1" || got.Status != Removed {
+		t.Errorf("last layer = %v %v; want = app1 removed", got.Layer.Digest, got.Status)
+	}
+	if got := r.SharedSize(); got != 20 {
+		t.Errorf("SharedSize() = %v; want = %v", got, 20)
+	}
+	var env []string
+	for _, c := range r.Env {
+		env = append(env, c.String())
+	}
+	if want := []string{"+ DEBUG: 1", "  PATH: /bin", "~ VERSION: 1 -> 2"}; !reflect.DeepEqual(env, want) {
+		t.Errorf("Env = %q; want = %q", env, want)
+	}
+	if got := Changes(r.Config); got != 1 {
+		t.Errorf("Changes(Config) = %v; want = %v", got, 1)
+	}
+	if got := r.HistoryChanges(); got != 1 {
+		t.Errorf("HistoryChanges() = %v; want = %v", got, 1)
+	}
+}
+
+func TestCompare_noManifest(t *testing.T) {
+	from := &Side{"app", domain.NewImage(nil, time.Time{}, "", 1, "", time.Time{}, "", ""), nil}
+	to := side([]string{"v1"}, 1, []string{"base"}, nil, nil, nil)
+	r := Compare(from, to)
+	if a, d, s := r.LayerCounts(); a != 1 || d != 0 || s != 0 {
+		t.Errorf("LayerCounts() = %v, %v, %v; want = 1, 0, 0", a, d, s)
+	}
+}
@@ -0,0 +1,98 @@
+// Package cost estimates the monthly storage cost of images.
+package cost
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+const (
+	// DefaultPricePerGBMonth is the ECR storage price in USD, the same in every commercial region.
+	DefaultPricePerGBMonth = 0.10
+
+	bytesPerGB = 1 << 30
+)
+
+// PriceTable is the storage price per GB-month, by region.
+// Regions not in the table cost Default.
+type PriceTable struct {
+	Default float64            `json:"default"`
+	Regions map[string]float64 `json:"regions"`
+}
+
+var prices = &PriceTable{Default: DefaultPricePerGBMonth}
+
+// LoadPriceFile replaces the price table with the JSON file at path, e.g.
+// {"default": 0.10, "regions": {"ap-northeast-1": 0.10}}.
+func LoadPriceFile(path string) error {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	t, err := ParsePriceTable(bs)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	prices = t
+	return nil
+}
+
+func ParsePriceTable(bs []byte) (*PriceTable, error) {
+	t := &PriceTable{Default: DefaultPricePerGBMonth}
+	dec := json.NewDecoder(bytes.NewReader(bs))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(t); err != nil {
+		return nil, err
+	}
+	if t.Default < 0 {
+		return nil, fmt.Errorf("default price must not be negative")
+	}
+	for r, p := range t.Regions {
+		if p < 0 {
+			return nil, fmt.Errorf("price of %s must not be negative", r)
+		}
+	}
+	return t, nil
+}
+
+// PricePerGBMonth returns the storage price in region.
+func PricePerGBMonth(region string) float64 {
+	if p, ok := prices.Regions[region]; ok {
+		return p
+	}
+	return prices.Default
+}
+
+// Monthly returns the cost of storing sizeByte bytes in region for a month.
+func Monthly(sizeByte int64, region string) float64 {
+	return float64(sizeByte) / bytesPerGB * PricePerGBMonth(region)
+}
+
+// StoredSize returns the size of imgs counting each digest once,
+// since an image with several tags is stored once.
+func StoredSize(imgs []*domain.Image) int64 {
+	seen := make(map[string]bool, len(imgs))
+	var size int64
+	for _, img := range imgs {
+		if img.Digest != "" {
+			if seen[img.Digest] {
+				continue
+			}
+			seen[img.Digest] = true
+		}
+		size += img.SizeByte
+	}
+	return size
+}
+
+// Format formats a cost in USD.
+func Format(usd float64) string {
+	if usd > 0 && usd < 0.01 {
+		return "<$0.01"
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
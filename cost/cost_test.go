@@ -0,0 +1,72 @@
+package cost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+func TestParsePriceTable(t *testing.T) {
+	tests := []struct {
+		json    string
+		region  string
+		want    float64
+		wantErr bool
+	}{
+		{`{}`, "us-east-1", DefaultPricePerGBMonth, false},
+		{`{"default": 0.2}`, "us-east-1", 0.2, false},
+		{`{"regions": {"us-east-1": 0.05}}`, "us-east-1", 0.05, false},
+		{`{"regions": {"us-east-1": 0.05}}`, "eu-west-1", DefaultPricePerGBMonth, false},
+		{`{"default": -1}`, "", 0, true},
+		{`{"price": 1}`, "", 0, true},
+	}
+	for _, test := range tests {
+		got, err := ParsePriceTable([]byte(test.json))
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParsePriceTable(%v) error = %v; want error = %v", test.json, err, test.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		prices = got
+		if p := PricePerGBMonth(test.region); p != test.want {
+			t.Errorf("ParsePriceTable(%v): PricePerGBMonth(%v) = %v; want = %v", test.json, test.region, p, test.want)
+		}
+	}
+	prices = &PriceTable{Default: DefaultPricePerGBMonth}
+}
+
+func TestMonthly(t *testing.T) {
+	if got := Monthly(5*bytesPerGB, "us-east-1"); got != 0.5 {
+		t.Errorf("Monthly() = %v; want = %v", got, 0.5)
+	}
+}
+
+func TestStoredSize(t *testing.T) {
+	imgs := []*domain.Image{
+		domain.NewImage([]string{"v1"}, time.Time{}, "sha256:a", 10, "", time.Time{}, "", ""),
+		domain.NewImage([]string{"latest"}, time.Time{}, "sha256:a", 10, "", time.Time{}, "", ""),
+		domain.NewImage(nil, time.Time{}, "sha256:b", 5, "", time.Time{}, "", ""),
+	}
+	if got := StoredSize(imgs); got != 15 {
+		t.Errorf("StoredSize() = %v; want = %v", got, 15)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		usd  float64
+		want string
+	}{
+		{0, "$0.00"},
+		{0.001, "<$0.01"},
+		{12.345, "$12.35"},
+	}
+	for _, test := range tests {
+		if got := Format(test.usd); got != test.want {
+			t.Errorf("Format(%v) = %v; want = %v", test.usd, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Package dedup analyzes how the images of a repository share layers.
+package dedup
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+// LayerUsage is a layer and the images that use it.
+type LayerUsage struct {
+	Layer  *domain.Layer
+	Images []*domain.Image
+}
+
+func (u *LayerUsage) Display() string {
+	return u.Layer.ShortDigest()
+}
+
+// ImageUsage is how an image shares its layers with the other images.
+type ImageUsage struct {
+	Image    *domain.Image
+	Manifest *domain.Manifest // nil if the manifest could not be read
+	// UniqueByte is the size of the layers no other image uses,
+	// which deleting the image would free.
+	UniqueByte int64
+	SharedByte int64
+	// DuplicateOf is the earliest pushed image with the same config and layers, if any.
+	DuplicateOf *domain.Image
+}
+
+func (u *ImageUsage) Display() string {
+	return u.Image.GetTag()
+}
+
+// Layers returns the number of layers of the image.
+func (u *ImageUsage) Layers() int {
+	if u.Manifest == nil {
+		return 0
+	}
+	return len(u.Manifest.Layers)
+}
+
+// Analysis is the layer sharing of a repository.
+type Analysis struct {
+	Images []*ImageUsage
+	Layers []*LayerUsage // most shared first
+	// StoredByte is the size of the distinct layers, what the repository actually stores.
+	StoredByte int64
+	// ImageByte is the sum of the image sizes, as if no layers were shared.
+	ImageByte int64
+}
+
+// Analyze analyzes imgs, whose manifests are ms in the same order.
+func Analyze(imgs []*domain.Image, ms []*domain.Manifest) *Analysis {
+	a := &Analysis{}
+	layers := make(map[string]*LayerUsage)
+	var order []string
+	for i, img := range imgs {
+		u := &ImageUsage{Image: img, Manifest: ms[i]}
+		a.Images = append(a.Images, u)
+		a.ImageByte += img.SizeByte
+		if u.Manifest == nil {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, l := range u.Manifest.Layers {
+			if seen[l.Digest] {
+				continue
+			}
+			seen[l.Digest] = true
+			lu, ok := layers[l.Digest]
+			if !ok {
+				lu = &LayerUsage{Layer: l}
+				layers[l.Digest] = lu
+				order = append(order, l.Digest)
+				a.StoredByte += l.SizeByte
+			}
+			lu.Images = append(lu.Images, img)
+		}
+	}
+	for _, d := range order {
+		a.Layers = append(a.Layers, layers[d])
+	}
+	sort.SliceStable(a.Layers, func(i, j int) bool {
+		return len(a.Layers[i].Images) > len(a.Layers[j].Images)
+	})
+	for _, u := range a.Images {
+		if u.Manifest == nil {
+			continue
+		}
+		for _, l := range u.Manifest.Layers {
+			if len(layers[l.Digest].Images) == 1 {
+				u.UniqueByte += l.SizeByte
+			} else {
+				u.SharedByte += l.SizeByte
+			}
+		}
+	}
+	a.findDuplicates()
+	return a
+}
+
+// findDuplicates marks the images whose content is identical to an earlier pushed one.
+func (a *Analysis) findDuplicates() {
+	byPush := make([]*ImageUsage, len(a.Images))
+	copy(byPush, a.Images)
+	sort.SliceStable(byPush, func(i, j int) bool {
+		return byPush[i].Image.PushedAt.Before(byPush[j].Image.PushedAt)
+	})
+	first := make(map[string]*domain.Image)
+	for _, u := range byPush {
+		k := contentKey(u.Manifest)
+		if k == "" {
+			continue
+		}
+		if img, ok := first[k]; ok {
+			u.DuplicateOf = img
+			continue
+		}
+		first[k] = u.Image
+	}
+}
+
+func contentKey(m *domain.Manifest) string {
+	if m == nil || m.ConfigDigest == "" {
+		return ""
+	}
+	ds := []string{m.ConfigDigest}
+	for _, l := range m.Layers {
+		ds = append(ds, l.Digest)
+	}
+	return strings.Join(ds, " ")
+}
+
+// Duplicates returns the number of images identical to another one.
+func (a *Analysis) Duplicates() int {
+	n := 0
+	for _, u := range a.Images {
+		if u.DuplicateOf != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Unreadable returns the number of images whose manifest could not be read.
+func (a *Analysis) Unreadable() int {
+	n := 0
+	for _, u := range a.Images {
+		if u.Manifest == nil {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,78 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+var now = time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC)
+
+// testImage describes an image of the fixtures with its manifest, nil if it cannot be read.
+type testImage struct {
+	digest        string
+	tag           string
+	pushedDaysAgo int
+	manifest      *domain.Manifest
+}
+
+func images(ts ...testImage) ([]*domain.Image, []*domain.Manifest) {
+	imgs := make([]*domain.Image, len(ts))
+	ms := make([]*domain.Manifest, len(ts))
+	for i, ti := range ts {
+		imgs[i] = domain.NewImage([]string{ti.tag}, now.AddDate(0, 0, -ti.pushedDaysAgo), ti.digest, 10, "", time.Time{}, "", "")
+		ms[i] = ti.manifest
+	}
+	return imgs, ms
+}
+
+func manifest(config string, layers ...string) *domain.Manifest {
+	m := &domain.Manifest{ConfigDigest: config}
+	for _, l := range layers {
+		m.Layers = append(m.Layers, &domain.Layer{Digest: l, SizeByte: int64(len(l))})
+	}
+	return m
+}
+
+func TestAnalyze(t *testing.T) {
+	imgs, ms := images(
+		testImage{"sha256:1", "v3", 0, manifest("c3", "base", "app3")},
+		testImage{"sha256:2", "v2", 1, manifest("c2", "base", "app22")},
+		testImage{"sha256:3", "v2-again", 0, manifest("c2", "base", "app22")},
+		testImage{"sha256:4", "v1", 2, manifest("c1", "base", "app1")},
+		testImage{"sha256:5", "broken", 3, nil},
+	)
+	a := Analyze(imgs, ms)
+
+	if got := a.Layers[0]; got.Layer.Digest != "base" || len(got.Images) != 4 {
+		t.Errorf("Layers[0] = %v used by %v; want = base used by 4", got.Layer.Digest, len(got.Images))
+	}
+	if got := a.Images[2].DuplicateOf; got != imgs[1] {
+		t.Errorf("v2-again DuplicateOf = %v; want = v2", got)
+	}
+	if got := a.Images[1].DuplicateOf; got != nil {
+		t.Errorf("v2 DuplicateOf = %v; want = nil", got)
+	}
+	tests := []struct {
+		name string
+		got  int64
+		want int64
+	}{
+		{"len(Layers)", int64(len(a.Layers)), 4},
+		// base(4) + app3(4) + app22(5) + app1(4)
+		{"StoredByte", a.StoredByte, 17},
+		{"ImageByte", a.ImageByte, 50},
+		{"v3 UniqueByte", a.Images[0].UniqueByte, 4},
+		{"v3 SharedByte", a.Images[0].SharedByte, 4},
+		{"v2 UniqueByte", a.Images[1].UniqueByte, 0},
+		{"v2 SharedByte", a.Images[1].SharedByte, 9},
+		{"Duplicates()", int64(a.Duplicates()), 1},
+		{"Unreadable()", int64(a.Unreadable()), 1},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s = %v; want = %v", test.name, test.got, test.want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Package dockerfile reconstructs a Dockerfile-like build history from an image config.
+package dockerfile
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+const (
+	shellPrefix    = "/bin/sh -c "
+	nopPrefix      = "#(nop)"
+	buildkitSuffix = "# buildkit"
+	runInstruction = "RUN"
+	continuation   = " \\"
+	indent         = "    "
+)
+
+var instructions = map[string]bool{
+	"ADD": true, "ARG": true, "CMD": true, "COPY": true, "ENTRYPOINT": true, "ENV": true,
+	"EXPOSE": true, "FROM": true, "HEALTHCHECK": true, "LABEL": true, "MAINTAINER": true,
+	"ONBUILD": true, "RUN": true, "SHELL": true, "STOPSIGNAL": true, "USER": true,
+	"VOLUME": true, "WORKDIR": true,
+}
+
+// Step is a build step of the image.
+type Step struct {
+	Instruction string
+	Args        string
+	BuildArgs   []string // build args the RUN step was executed with, as KEY=VALUE
+	History     *domain.HistoryEntry
+	Layer       *domain.Layer // nil for empty-layer steps, or if the layers do not match the history
+}
+
+// Empty reports whether the step did not create a layer.
+func (s *Step) Empty() bool {
+	return s.History.EmptyLayer
+}
+
+func (s *Step) String() string {
+	if s.Args == "" {
+		return s.Instruction
+	}
+	return s.Instruction + " " + s.Args
+}
+
+// SizeStr returns the size of the layer created by the step.
+func (s *Step) SizeStr() string {
+	switch {
+	case s.Empty():
+		return "0 B"
+	case s.Layer == nil:
+		return "-"
+	default:
+		return s.Layer.SizeStr()
+	}
+}
+
+// Lines returns the step as Dockerfile lines, breaking RUN commands at each &&.
+func (s *Step) Lines() []string {
+	if s.Instruction != runInstruction {
+		return []string{s.String()}
+	}
+	cmds := strings.Split(s.Args, " && ")
+	ls := make([]string, len(cmds))
+	for i, c := range cmds {
+		l := indent + strings.TrimSpace(c)
+		if i == 0 {
+			l = s.Instruction + " " + strings.TrimSpace(c)
+		}
+		if i < len(cmds)-1 {
+			l += " &&" + continuation
+		}
+		ls[i] = l
+	}
+	return ls
+}
+
+// Reconstruct returns the build steps of the image in the order they were executed.
+func Reconstruct(m *domain.Manifest) []*Step {
+	if m.Config == nil {
+		return nil
+	}
+	layers := m.LayerHistory()
+	byHistory := make(map[*domain.HistoryEntry]*domain.Layer, len(layers))
+	for i, h := range layers {
+		if h != nil {
+			byHistory[h] = m.Layers[i]
+		}
+	}
+	steps := make([]*Step, len(m.Config.History))
+	for i, h := range m.Config.History {
+		s := Parse(h.CreatedBy)
+		s.History = h
+		s.Layer = byHistory[h]
+		steps[i] = s
+	}
+	return steps
+}
+
+// Parse un-escapes the created_by command of a history entry into an instruction.
+//
+// Docker records instructions other than RUN as "/bin/sh -c #(nop) INSTRUCTION args",
+// RUN as "/bin/sh -c command" prefixed with "|N KEY=VALUE..." when build args were set,
+// and BuildKit records "INSTRUCTION args # buildkit".
+func Parse(createdBy string) *Step {
+	s := strings.TrimSpace(createdBy)
+	s = strings.TrimSpace(strings.TrimSuffix(s, buildkitSuffix))
+	if rest := strings.TrimPrefix(s, runInstruction+" "); rest != s && (strings.HasPrefix(rest, "|") || strings.HasPrefix(rest, shellPrefix)) {
+		s = rest
+	}
+	step := &Step{}
+	s, step.BuildArgs = trimBuildArgs(s)
+	if strings.HasPrefix(s, shellPrefix) {
+		s = strings.TrimSpace(strings.TrimPrefix(s, shellPrefix))
+		if !strings.HasPrefix(s, nopPrefix) {
+			step.Instruction, step.Args = runInstruction, s
+			return step
+		}
+		s = strings.TrimSpace(strings.TrimPrefix(s, nopPrefix))
+	}
+	i := strings.IndexAny(s, " \t")
+	if i < 0 {
+		i = len(s)
+	}
+	if instr := strings.ToUpper(s[:i]); instructions[instr] {
+		step.Instruction, step.Args = instr, strings.TrimSpace(s[i:])
+	} else {
+		step.Instruction, step.Args = runInstruction, s
+	}
+	if step.Instruction == "EXPOSE" {
+		step.Args = exposedPorts(step.Args)
+	}
+	return step
+}
+
+// trimBuildArgs removes the "|N KEY=VALUE..." prefix of a RUN command.
+func trimBuildArgs(s string) (string, []string) {
+	if !strings.HasPrefix(s, "|") {
+		return s, nil
+	}
+	fs := strings.SplitN(s[1:], " ", 2)
+	n, err := strconv.Atoi(fs[0])
+	if err != nil || len(fs) < 2 {
+		return s, nil
+	}
+	rest := fs[1]
+	args := make([]string, 0, n)
+	for ; n > 0; n-- {
+		fs := strings.SplitN(rest, " ", 2)
+		args = append(args, fs[0])
+		if len(fs) < 2 {
+			return "", args
+		}
+		rest = fs[1]
+	}
+	return rest, args
+}
+
+// exposedPorts converts the "map[8080/tcp:{}]" recorded by older Docker versions into ports.
+func exposedPorts(s string) string {
+	if !strings.HasPrefix(s, "map[") || !strings.HasSuffix(s, "]") {
+		return s
+	}
+	fs := strings.Fields(s[len("map[") : len(s)-1])
+	for i, f := range fs {
+		fs[i] = strings.TrimSuffix(f, ":{}")
+	}
+	return strings.Join(fs, " ")
+}
+
+// Render returns the steps as a Dockerfile, each preceded by a comment with the size of its layer.
+func Render(steps []*Step) []string {
+	var ls []string
+	for i, s := range steps {
+		if i > 0 {
+			ls = append(ls, "")
+		}
+		ls = append(ls, "# "+s.comment())
+		for _, b := range s.BuildArgs {
+			ls = append(ls, "ARG "+b)
+		}
+		ls = append(ls, s.Lines()...)
+	}
+	return ls
+}
+
+func (s *Step) comment() string {
+	switch {
+	case s.Empty():
+		return "(empty layer)"
+	case s.Layer == nil:
+		return "layer of unknown size"
+	default:
+		return fmt.Sprintf("%s (%s)", humanize.Bytes(uint64(s.Layer.SizeByte)), s.Layer.ShortDigest())
+	}
+}
@@ -0,0 +1,89 @@
+package dockerfile
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		createdBy string
+		want      string
+		buildArgs []string
+	}{
+		{"/bin/sh -c #(nop) ADD file:4b03b5f5 in / ", "ADD file:4b03b5f5 in /", nil},
+		{`/bin/sh -c #(nop)  CMD ["bash"]`, `CMD ["bash"]`, nil},
+		{"/bin/sh -c apt-get update && apt-get install -y curl", "RUN apt-get update && apt-get install -y curl", nil},
+		{"|2 VERSION=1.2 DEBUG=0 /bin/sh -c make install", "RUN make install", []string{"VERSION=1.2", "DEBUG=0"}},
+		{"/bin/sh -c #(nop)  EXPOSE map[8080/tcp:{} 9090/tcp:{}]", "EXPOSE 8080/tcp 9090/tcp", nil},
+		{"COPY . . # buildkit", "COPY . .", nil},
+		{"RUN /bin/sh -c go build ./... # buildkit", "RUN go build ./...", nil},
+		{"RUN |1 TARGET=prod /bin/sh -c make # buildkit", "RUN make", []string{"TARGET=prod"}},
+		{"WORKDIR /app", "WORKDIR /app", nil},
+		{"echo hello", "RUN echo hello", nil},
+	}
+	for _, tt := range tests {
+		got := Parse(tt.createdBy)
+		if got.String() != tt.want {
+			t.Errorf("Parse(%q) = %q; want = %q", tt.createdBy, got.String(), tt.want)
+		}
+		if !reflect.DeepEqual(got.BuildArgs, tt.buildArgs) {
+			t.Errorf("Parse(%q).BuildArgs = %q; want = %q", tt.createdBy, got.BuildArgs, tt.buildArgs)
+		}
+	}
+}
+
+func TestReconstruct(t *testing.T) {
+	m := &domain.Manifest{
+		Layers: []*domain.Layer{
+			{Digest: "sha256:aaaaaaaaaaaaaaaa", SizeByte: 1000},
+			{Digest: "sha256:bbbbbbbbbbbbbbbb", SizeByte: 2000},
+		},
+		Config: &domain.ImageConfig{History: []*domain.HistoryEntry{
+			{CreatedBy: "/bin/sh -c #(nop) ADD file:abc in / "},
+			{CreatedBy: `/bin/sh -c #(nop)  CMD ["bash"]`, EmptyLayer: true},
+			{CreatedBy: "/bin/sh -c apt-get update && apt-get install -y curl"},
+		}},
+	}
+	steps := Reconstruct(m)
+	if len(steps) != 3 {
+		t.Fatalf("len(steps) = %v; want = %v", len(steps), 3)
+	}
+	if steps[0].Layer != m.Layers[0] || steps[1].Layer != nil || steps[2].Layer != m.Layers[1] {
+		t.Errorf("layers = %v, %v, %v; want = the layers in order, nil for the empty step", steps[0].Layer, steps[1].Layer, steps[2].Layer)
+	}
+	if got := steps[1].SizeStr(); got != "0 B" {
+		t.Errorf("SizeStr() = %v; want = %v", got, "0 B")
+	}
+	want := []string{
+		"# 1.0 kB (aaaaaaaaaaaa)",
+		"ADD file:abc in /",
+		"",
+		"# (empty layer)",
+		`CMD ["bash"]`,
+		"",
+		"# 2.0 kB (bbbbbbbbbbbb)",
+		"RUN apt-get update && \\",
+		"    apt-get install -y curl",
+	}
+	if got := Render(steps); !reflect.DeepEqual(got, want) {
+		t.Errorf("Render() = %q; want = %q", got, want)
+	}
+}
+
+func TestReconstructMismatchedLayers(t *testing.T) {
+	m := &domain.Manifest{
+		Layers: []*domain.Layer{{Digest: "sha256:aaaaaaaaaaaaaaaa", SizeByte: 1000}},
+		Config: &domain.ImageConfig{History: []*domain.HistoryEntry{
+			{CreatedBy: "/bin/sh -c #(nop) ADD file:abc in / "},
+			{CreatedBy: "/bin/sh -c make"},
+		}},
+	}
+	for _, s := range Reconstruct(m) {
+		if s.Layer != nil || s.SizeStr() != "-" {
+			t.Errorf("%v: Layer = %v, SizeStr() = %v; want = nil, -", s, s.Layer, s.SizeStr())
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	minCachePrefixLength = 2
+	maxCachePrefixLength = 30
+
+	cacheCredentialSecretPrefix = "ecr-pullthroughcache/"
+)
+
+// upstreamsWithCredentials are the upstream registries ECR only caches from with credentials.
+var upstreamsWithCredentials = []string{"registry-1.docker.io", "ghcr.io", "registry.gitlab.com", ".azurecr.io"}
+
+// PullThroughCacheRule makes ECR create repositories under Prefix
+// and fill them with images pulled from the upstream registry on demand.
+type PullThroughCacheRule struct {
+	Prefix        string
+	UpstreamUrl   string
+	CredentialArn string // Secrets Manager secret, empty for public upstreams
+	RegistryId    string
+	CreatedAt     time.Time
+}
+
+func (r *PullThroughCacheRule) Display() string {
+	return r.Prefix + " -> " + r.UpstreamUrl
+}
+
+func (r *PullThroughCacheRule) CreatedAtStr() string {
+	if r.CreatedAt.IsZero() {
+		return noValue
+	}
+	return formatTime(r.CreatedAt)
+}
+
+func (r *PullThroughCacheRule) CreatedAtShortStr() string {
+	if r.CreatedAt.IsZero() {
+		return noValue
+	}
+	return formatShortTime(r.CreatedAt)
+}
+
+// Caches reports whether repo was created by the rule: cached repositories are
+// named after the upstream repository under the prefix, e.g. docker-hub/library/nginx.
+func (r *PullThroughCacheRule) Caches(repo string) bool {
+	return strings.HasPrefix(repo, r.Prefix+"/")
+}
+
+// UpstreamRepository returns the repository of the upstream registry that repo caches.
+func (r *PullThroughCacheRule) UpstreamRepository(repo string) string {
+	return r.UpstreamUrl + "/" + strings.TrimPrefix(repo, r.Prefix+"/")
+}
+
+// FindPullThroughCacheRule returns the rule that created repo, or nil.
+func FindPullThroughCacheRule(rules []*PullThroughCacheRule, repo string) *PullThroughCacheRule {
+	for _, r := range rules {
+		if r.Caches(repo) {
+			return r
+		}
+	}
+	return nil
+}
+
+type CreatePullThroughCacheRuleInput struct {
+	Prefix        string
+	UpstreamUrl   string
+	CredentialArn string
+}
+
+// Validate checks the input against the rules ECR applies when creating a rule.
+func (in *CreatePullThroughCacheRuleInput) Validate() error {
+	var errs []string
+	if len(in.Prefix) < minCachePrefixLength || len(in.Prefix) > maxCachePrefixLength {
+		errs = append(errs, fmt.Sprintf("prefix must be %d to %d characters", minCachePrefixLength, maxCachePrefixLength))
+	} else if !repositoryNamePattern.MatchString(in.Prefix) {
+		errs = append(errs, "prefix must be lowercase letters, digits and . _ - separated by /")
+	}
+	if in.UpstreamUrl == "" {
+		errs = append(errs, "upstream url is required")
+	} else if strings.Contains(in.UpstreamUrl, "://") || strings.Contains(in.UpstreamUrl, "/") {
+		errs = append(errs, "upstream url must be a host name such as quay.io, without scheme or path")
+	}
+	if in.CredentialArn != "" {
+		if !strings.HasPrefix(in.CredentialArn, "arn:") || !strings.Contains(in.CredentialArn, ":secret:"+cacheCredentialSecretPrefix) {
+			errs = append(errs, fmt.Sprintf("credential arn must be a Secrets Manager secret named %s...", cacheCredentialSecretPrefix))
+		}
+	} else if requiresCredential(in.UpstreamUrl) {
+		errs = append(errs, fmt.Sprintf("%s requires a credential arn", in.UpstreamUrl))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func requiresCredential(upstream string) bool {
+	for _, u := range upstreamsWithCredentials {
+		if upstream == u || strings.HasPrefix(u, ".") && strings.HasSuffix(upstream, u) {
+			return true
+		}
+	}
+	return false
+}
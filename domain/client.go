@@ -1,6 +1,115 @@
 package domain
 
+import "io"
+
 type ContainerClient interface {
 	FetchAllRepositories() ([]*Repository, error)
 	FetchAllImages(repo string) ([]*Image, error)
 }
+
+// ImageDeleteClient is implemented by clients that can delete images.
+// DeleteImages removes the deleted images from the cache,
+// and returns the images it could not delete.
+type ImageDeleteClient interface {
+	DeleteImages(repo string, imgs []*Image) ([]*ImageDeleteFailure, error)
+}
+
+type ImageDeleteFailure struct {
+	Image  *Image
+	Reason string
+}
+
+// ImageManifestClient is implemented by clients that can read the layers and config of images.
+// For multi-platform images, the manifest of linux/amd64 (or else the first platform) is returned.
+type ImageManifestClient interface {
+	FetchImageManifest(repo string, img *Image) (*Manifest, error)
+	// FetchImageManifests reads the manifests of imgs in batches, without their configs.
+	// The manifests are in the order of imgs, nil for the images whose manifest cannot be read.
+	FetchImageManifests(repo string, imgs []*Image) ([]*Manifest, error)
+}
+
+// ImageIndexClient is implemented by clients that can read the manifests listed by image indexes.
+type ImageIndexClient interface {
+	// FetchIndexChildren returns the digests of the manifests listed by the indexes among imgs.
+	FetchIndexChildren(repo string, imgs []*Image) ([]string, error)
+}
+
+// LayerBlobClient is implemented by clients that can download layer blobs.
+// The blob is returned as stored, usually a gzip compressed tar archive.
+type LayerBlobClient interface {
+	FetchLayerBlob(repo, digest string) (io.ReadCloser, error)
+}
+
+// LifecyclePolicyClient is implemented by clients that can read and write lifecycle policies.
+// FetchLifecyclePolicy returns an empty string if the repository has no policy.
+type LifecyclePolicyClient interface {
+	FetchLifecyclePolicy(repo string) (string, error)
+	PutLifecyclePolicy(repo, text string) error
+	DeleteLifecyclePolicy(repo string) error
+}
+
+// RepositoryPolicyClient is implemented by clients that can read and write repository permissions policies.
+// FetchRepositoryPolicy returns an empty string if the repository has no policy.
+type RepositoryPolicyClient interface {
+	FetchRepositoryPolicy(repo string) (string, error)
+	PutRepositoryPolicy(repo, text string) error
+	DeleteRepositoryPolicy(repo string) error
+}
+
+// RepositoryAdminClient is implemented by clients that can create and delete repositories.
+// Both keep the cached repository list up to date.
+type RepositoryAdminClient interface {
+	CreateRepository(input *CreateRepositoryInput) (*Repository, error)
+	// DeleteRepository fails if the repository has images, unless force is set.
+	DeleteRepository(repo string, force bool) error
+}
+
+// RepositoryTagClient is implemented by clients that can read the resource tags of repositories.
+type RepositoryTagClient interface {
+	FetchRepositoryTags(repo *Repository) (map[string]string, error)
+}
+
+// RepositoryCatalogClient is implemented by clients of public registries,
+// whose repositories have a gallery page.
+type RepositoryCatalogClient interface {
+	FetchRepositoryCatalogData(repo *Repository) (*RepositoryCatalogData, error)
+}
+
+// ConsoleClient is implemented by clients whose repositories are not shown in the ECR console.
+// An empty URL means that the repository has no web page.
+type ConsoleClient interface {
+	RepositoryConsoleURL(repo *Repository) string
+}
+
+// RepositorySettingsClient is implemented by clients that can change repository settings.
+// They update the cached repository in place.
+type RepositorySettingsClient interface {
+	SetTagMutability(repo *Repository, mutability string) error
+	SetScanOnPush(repo *Repository, scanOnPush bool) error
+}
+
+// PullThroughCacheClient is implemented by clients that can manage the pull-through cache rules of the registry.
+type PullThroughCacheClient interface {
+	FetchPullThroughCacheRules() ([]*PullThroughCacheRule, error)
+	CreatePullThroughCacheRule(in *CreatePullThroughCacheRuleInput) (*PullThroughCacheRule, error)
+	DeletePullThroughCacheRule(prefix string) error
+}
+
+type CreateRepositoryInput struct {
+	Name           string
+	TagMutability  string
+	ScanOnPush     bool
+	EncryptionType string
+	KmsKey         string // only for EncryptionTypeKMS, empty for the AWS managed key
+	Tags           map[string]string
+}
+
+// SessionClient is implemented by clients whose region and credentials
+// can be switched at runtime. Switching discards all cached data.
+type SessionClient interface {
+	Region() string
+	Regions() []string
+	Profile() string
+	SetRegion(region string) error
+	SetProfile(profile string) error
+}
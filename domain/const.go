@@ -5,7 +5,12 @@ import "github.com/aws/aws-sdk-go/aws/endpoints"
 const (
 	TargetRegion = endpoints.ApNortheast1RegionID
 
-	datetimeFormat = "2006-01-02 15:04:05"
+	datetimeFormat      = "2006-01-02 15:04:05 MST"
+	shortDatetimeFormat = "2006-01-02 15:04"
+	dateFormat          = "2006-01-02"
 
-	noTag = "<untagged>"
+	noTag     = "<untagged>"
+	noScan    = "-"
+	noValue   = "-"
+	notPulled = "never"
 )
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	CopyBlobConfig = "config"
+	CopyBlobLayer  = "layer"
+)
+
+var imageTagPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+
+// CopyDestination is where an image is copied to.
+// Empty Region and RegistryId mean those of the source.
+type CopyDestination struct {
+	Region     string
+	RegistryId string
+	Repository string
+	Tag        string
+}
+
+func (d *CopyDestination) String() string {
+	s := d.Repository + ":" + d.Tag
+	if d.RegistryId != "" {
+		s = d.RegistryId + "/" + s
+	}
+	if d.Region != "" {
+		s += " (" + d.Region + ")"
+	}
+	return s
+}
+
+// CopyBlob is a config or layer of the image to copy.
+type CopyBlob struct {
+	Digest    string
+	MediaType string
+	Kind      string
+	SizeByte  int64
+	Exists    bool // already in the destination, so it is not uploaded
+}
+
+func (b *CopyBlob) ShortDigest() string {
+	return shortDigest(b.Digest)
+}
+
+func (b *CopyBlob) SizeStr() string {
+	return humanize.Bytes(uint64(b.SizeByte))
+}
+
+// CopyManifest is a manifest to put into the destination as read from the source.
+type CopyManifest struct {
+	Digest    string
+	MediaType string
+	Body      []byte
+}
+
+// CopyPlan is what copying an image transfers, shown before the copy starts.
+type CopyPlan struct {
+	Repository  string
+	Image       *Image
+	Destination *CopyDestination
+	// Manifests are put in order: the manifests of the platforms of an index first,
+	// and last the manifest of the image, which is tagged.
+	Manifests []*CopyManifest
+	Blobs     []*CopyBlob
+}
+
+// Transfer returns the number and the total size of the blobs to upload.
+func (p *CopyPlan) Transfer() (int, int64) {
+	return p.count(false)
+}
+
+// Skipped returns the number and the total size of the blobs the destination already has.
+func (p *CopyPlan) Skipped() (int, int64) {
+	return p.count(true)
+}
+
+func (p *CopyPlan) count(exists bool) (int, int64) {
+	var n int
+	var size int64
+	for _, b := range p.Blobs {
+		if b.Exists == exists {
+			n++
+			size += b.SizeByte
+		}
+	}
+	return n, size
+}
+
+// ImageCopyClient is implemented by clients that can copy images into another repository,
+// possibly in another region or registry (account).
+type ImageCopyClient interface {
+	// PlanImageCopy reads the manifests of img and checks which blobs the destination has.
+	PlanImageCopy(repo string, img *Image, dst *CopyDestination) (*CopyPlan, error)
+	// CopyImage uploads the missing blobs and puts the manifests.
+	// progress is called with the index of a blob in plan.Blobs and the bytes uploaded so far.
+	CopyImage(plan *CopyPlan, progress func(i int, uploaded int64)) error
+}
+
+// ValidateImageTag checks tag against the rules of Docker image tags.
+func ValidateImageTag(tag string) error {
+	if !imageTagPattern.MatchString(tag) {
+		return fmt.Errorf("tag must be up to 128 letters, digits and . _ - not starting with . or -")
+	}
+	return nil
+}
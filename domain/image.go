@@ -9,18 +9,26 @@ import (
 )
 
 type Image struct {
-	Tags     []string
-	PushedAt time.Time
-	Digest   string
-	SizeByte int64
+	Tags              []string
+	PushedAt          time.Time
+	Digest            string
+	SizeByte          int64
+	ScanStatus        string
+	LastPulledAt      time.Time // zero if no pull has been recorded
+	ManifestMediaType string
+	ArtifactMediaType string
 }
 
-func NewImage(tags []string, pushedAt time.Time, digest string, sizeByte int64) *Image {
+func NewImage(tags []string, pushedAt time.Time, digest string, sizeByte int64, scanStatus string, lastPulledAt time.Time, manifestMediaType string, artifactMediaType string) *Image {
 	return &Image{
-		Tags:     tags,
-		PushedAt: pushedAt,
-		Digest:   digest,
-		SizeByte: sizeByte,
+		Tags:              tags,
+		PushedAt:          pushedAt,
+		Digest:            digest,
+		SizeByte:          sizeByte,
+		ScanStatus:        scanStatus,
+		LastPulledAt:      lastPulledAt,
+		ManifestMediaType: manifestMediaType,
+		ArtifactMediaType: artifactMediaType,
 	}
 }
 
@@ -40,14 +48,72 @@ func (i *Image) GetTags() []string {
 }
 
 func (i *Image) PushedAtStr() string {
-	// TODO: consider timezone
-	return i.PushedAt.Format(datetimeFormat)
+	if i.PushedAt.IsZero() {
+		return noValue
+	}
+	return formatTime(i.PushedAt)
+}
+
+func (i *Image) PushedAtShortStr() string {
+	if i.PushedAt.IsZero() {
+		return noValue
+	}
+	return formatShortTime(i.PushedAt)
+}
+
+func (i *Image) LastPulledAtStr() string {
+	if i.LastPulledAt.IsZero() {
+		return notPulled
+	}
+	return formatTime(i.LastPulledAt)
+}
+
+func (i *Image) LastPulledAtShortStr() string {
+	if i.LastPulledAt.IsZero() {
+		return notPulled
+	}
+	return formatShortTime(i.LastPulledAt)
+}
+
+// LastUsedAt returns when the image was last pulled, or pushed if it has not been pulled since.
+func (i *Image) LastUsedAt() time.Time {
+	if i.LastPulledAt.After(i.PushedAt) {
+		return i.LastPulledAt
+	}
+	return i.PushedAt
+}
+
+func (i *Image) ManifestMediaTypeStr() string {
+	return mediaTypeStr(i.ManifestMediaType)
+}
+
+func (i *Image) ArtifactMediaTypeStr() string {
+	return mediaTypeStr(i.ArtifactMediaType)
+}
+
+// ArtifactMediaTypeShortStr drops the common prefix, e.g. "oci.image.config.v1+json".
+func (i *Image) ArtifactMediaTypeShortStr() string {
+	return strings.TrimPrefix(i.ArtifactMediaTypeStr(), "application/vnd.")
+}
+
+func mediaTypeStr(t string) string {
+	if t == "" {
+		return noValue
+	}
+	return t
 }
 
 func (i *Image) SizeStr() string {
 	return humanize.Bytes(uint64(i.SizeByte))
 }
 
+func (i *Image) ScanStatusStr() string {
+	if i.ScanStatus == "" {
+		return noScan
+	}
+	return i.ScanStatus
+}
+
 func imageSorter(imgs []*Image) func(int, int) bool {
 	return func(i, j int) bool { return imgs[i].PushedAt.After(imgs[j].PushedAt) }
 }
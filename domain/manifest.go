@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Manifest is the content of an image: its layers and its config.
+type Manifest struct {
+	Digest         string
+	MediaType      string
+	ConfigDigest   string
+	ConfigSizeByte int64
+	Layers         []*Layer
+	Config         *ImageConfig
+}
+
+// Layer is a layer blob of an image, identified by its digest.
+type Layer struct {
+	Digest    string
+	SizeByte  int64
+	MediaType string
+}
+
+func (l *Layer) Display() string {
+	return l.ShortDigest()
+}
+
+// ShortDigest drops the algorithm and keeps 12 hex digits, like docker does.
+func (l *Layer) ShortDigest() string {
+	return shortDigest(l.Digest)
+}
+
+func shortDigest(d string) string {
+	if i := strings.Index(d, ":"); i >= 0 {
+		d = d[i+1:]
+	}
+	if len(d) > 12 {
+		d = d[:12]
+	}
+	return d
+}
+
+func (l *Layer) SizeStr() string {
+	return humanize.Bytes(uint64(l.SizeByte))
+}
+
+// ImageConfig is the runtime configuration and build history of an image.
+type ImageConfig struct {
+	Architecture string
+	OS           string
+	CreatedAt    time.Time
+	Env          []string
+	Labels       map[string]string
+	Entrypoint   []string
+	Cmd          []string
+	WorkingDir   string
+	User         string
+	ExposedPorts []string
+	History      []*HistoryEntry
+}
+
+// HistoryEntry is a build step. Steps with EmptyLayer set do not add a layer.
+type HistoryEntry struct {
+	CreatedAt  time.Time
+	CreatedBy  string
+	Comment    string
+	EmptyLayer bool
+}
+
+// LabelKeys returns the label keys in order.
+func (c *ImageConfig) LabelKeys() []string {
+	keys := make([]string, 0, len(c.Labels))
+	for k := range c.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// TotalSize returns the sum of the layer sizes.
+func (m *Manifest) TotalSize() int64 {
+	var size int64
+	for _, l := range m.Layers {
+		size += l.SizeByte
+	}
+	return size
+}
+
+// LayerHistory returns the build step that created each layer, in the order of the layers.
+// The steps are unknown (nil) if the history does not match the layers.
+func (m *Manifest) LayerHistory() []*HistoryEntry {
+	ret := make([]*HistoryEntry, len(m.Layers))
+	if m.Config == nil {
+		return ret
+	}
+	var steps []*HistoryEntry
+	for _, h := range m.Config.History {
+		if !h.EmptyLayer {
+			steps = append(steps, h)
+		}
+	}
+	if len(steps) != len(m.Layers) {
+		return ret
+	}
+	return steps
+}
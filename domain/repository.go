@@ -1,41 +1,131 @@
 package domain
 
 import (
-	"sort"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 )
 
+const (
+	TagMutabilityMutable   = "MUTABLE"
+	TagMutabilityImmutable = "IMMUTABLE"
+
+	EncryptionTypeAES256 = "AES256"
+	EncryptionTypeKMS    = "KMS"
+
+	publicRegistryHost = "public.ecr.aws/"
+	galleryURL         = "https://gallery.ecr.aws/"
+
+	minRepositoryNameLength = 2
+	maxRepositoryNameLength = 256
+)
+
+var (
+	TagMutabilities = []string{TagMutabilityMutable, TagMutabilityImmutable}
+	EncryptionTypes = []string{EncryptionTypeAES256, EncryptionTypeKMS}
+
+	repositoryNamePattern = regexp.MustCompile(`^(?:[a-z0-9]+(?:[._-][a-z0-9]+)*/)*[a-z0-9]+(?:[._-][a-z0-9]+)*$`)
+)
+
 type Repository struct {
-	Name          string
-	Uri           string
-	Arn           string
-	TagMutability string
-	CreatedAt     time.Time
+	Name           string
+	Uri            string
+	Arn            string
+	RegistryId     string
+	TagMutability  string
+	ScanOnPush     bool
+	EncryptionType string
+	KmsKey         string
+	CreatedAt      time.Time
 }
 
-func NewRepository(name string, uri string, arn string, tagMutability string, createdAt time.Time) *Repository {
+func NewRepository(name string, uri string, arn string, registryId string, tagMutability string, scanOnPush bool, encryptionType string, kmsKey string, createdAt time.Time) *Repository {
 	return &Repository{
-		Name:          name,
-		Uri:           uri,
-		Arn:           arn,
-		TagMutability: tagMutability,
-		CreatedAt:     createdAt,
+		Name:           name,
+		Uri:            uri,
+		Arn:            arn,
+		RegistryId:     registryId,
+		TagMutability:  tagMutability,
+		ScanOnPush:     scanOnPush,
+		EncryptionType: encryptionType,
+		KmsKey:         kmsKey,
+		CreatedAt:      createdAt,
 	}
 }
 
+// RepositoryCatalogData is what the gallery shows about a public repository.
+type RepositoryCatalogData struct {
+	Description          string
+	AboutText            string // markdown
+	UsageText            string // markdown
+	Architectures        []string
+	OperatingSystems     []string
+	LogoURL              string
+	MarketplaceCertified bool
+}
+
+// ValidateRepositoryName checks name against the ECR repository naming rules.
+func ValidateRepositoryName(name string) error {
+	if len(name) < minRepositoryNameLength || len(name) > maxRepositoryNameLength {
+		return fmt.Errorf("repository name must be %d to %d characters", minRepositoryNameLength, maxRepositoryNameLength)
+	}
+	if !repositoryNamePattern.MatchString(name) {
+		return fmt.Errorf("repository name must be lowercase letters, digits and . _ - separated by /")
+	}
+	return nil
+}
+
 func (r *Repository) Display() string {
 	return r.Name
 }
 
-func (r *Repository) CreatedAtStr() string {
-	// TODO: consider timezone
-	return r.CreatedAt.Format(datetimeFormat)
+// GalleryURL returns the ECR Public Gallery page of a public repository, whose path is
+// the registry alias and name in the repository URI, or an empty string for other repositories.
+func (r *Repository) GalleryURL() string {
+	if !strings.HasPrefix(r.Uri, publicRegistryHost) {
+		return ""
+	}
+	return galleryURL + strings.TrimPrefix(r.Uri, publicRegistryHost)
+}
+
+func (r *Repository) ScanOnPushStr() string {
+	return EnabledStr(r.ScanOnPush)
+}
+
+func EnabledStr(b bool) string {
+	if b {
+		return "enabled"
+	}
+	return "disabled"
 }
 
-func repositorySorter(repos []*Repository) func(int, int) bool {
-	return func(i, j int) bool { return repos[i].Name < repos[j].Name }
+func (r *Repository) EncryptionStr() string {
+	if r.KmsKey != "" {
+		return fmt.Sprintf("%s (%s)", r.EncryptionType, r.KmsKey)
+	}
+	return r.EncryptionType
 }
 
-func SortRepositories(repos []*Repository) {
-	sort.Slice(repos, repositorySorter(repos))
+// ToggledTagMutability returns the other tag mutability setting.
+func (r *Repository) ToggledTagMutability() string {
+	if r.TagMutability == TagMutabilityImmutable {
+		return TagMutabilityMutable
+	}
+	return TagMutabilityImmutable
+}
+
+// CreatedAtStr returns "-" if the creation time is unknown, as in registries other than ECR.
+func (r *Repository) CreatedAtStr() string {
+	if r.CreatedAt.IsZero() {
+		return noValue
+	}
+	return formatTime(r.CreatedAt)
+}
+
+func (r *Repository) CreatedAtShortStr() string {
+	if r.CreatedAt.IsZero() {
+		return noValue
+	}
+	return formatShortTime(r.CreatedAt)
 }
@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	LocalTimeZone = "local"
+)
+
+var (
+	displayLocation = time.Local
+	relativeTime    = false
+)
+
+// SetDisplayTimeZone sets the zone used to display times.
+// name is "local", "UTC" or an IANA zone name such as "Asia/Tokyo".
+func SetDisplayTimeZone(name string) error {
+	if strings.EqualFold(name, LocalTimeZone) {
+		displayLocation = time.Local
+		return nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return err
+	}
+	displayLocation = loc
+	return nil
+}
+
+// zoneinfoDirs are where the zoneinfo database is installed on Unix systems.
+var zoneinfoDirs = []string{"/usr/share/zoneinfo", "/usr/share/lib/zoneinfo", "/usr/lib/locale/TZ"}
+
+var (
+	timeZoneNamesOnce sync.Once
+	timeZoneNames     []string
+)
+
+// TimeZoneNames returns local, UTC and the zones of the zoneinfo database of the system, if installed.
+// Zones that can only be loaded from $GOROOT or a ZONEINFO zip file are not listed.
+func TimeZoneNames() []string {
+	timeZoneNamesOnce.Do(func() {
+		timeZoneNames = []string{LocalTimeZone, "UTC"}
+		for _, dir := range zoneinfoDirs {
+			if zones := readZoneNames(dir); len(zones) > 0 {
+				timeZoneNames = append(timeZoneNames, zones...)
+				break
+			}
+		}
+	})
+	return timeZoneNames
+}
+
+// readZoneNames lists the zone files under dir, skipping the tables and the posix/right variants.
+func readZoneNames(dir string) []string {
+	var ret []string
+	filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, p)
+		name := filepath.ToSlash(rel)
+		if info.IsDir() {
+			if name == "posix" || name == "right" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if name[0] < 'A' || name[0] > 'Z' || strings.Contains(name, ".") || name == "UTC" {
+			return nil
+		}
+		ret = append(ret, name)
+		return nil
+	})
+	sort.Strings(ret)
+	return ret
+}
+
+func DisplayTimeZone() string {
+	if displayLocation == time.Local {
+		return LocalTimeZone
+	}
+	return displayLocation.String()
+}
+
+// ToggleRelativeTime switches between absolute and relative ("3 days ago") times
+// and reports whether relative times are now enabled.
+func ToggleRelativeTime() bool {
+	relativeTime = !relativeTime
+	return relativeTime
+}
+
+func formatShortTime(t time.Time) string {
+	if relativeTime {
+		return humanize.Time(t)
+	}
+	return t.In(displayLocation).Format(shortDatetimeFormat)
+}
+
+func formatTime(t time.Time) string {
+	abs := t.In(displayLocation).Format(datetimeFormat)
+	if relativeTime {
+		return humanize.Time(t) + " (" + abs + ")"
+	}
+	return abs
+}
+
+// FormatTime formats t like the times of images and repositories.
+func FormatTime(t time.Time) string {
+	return formatTime(t)
+}
+
+// FormatDate formats the date of t in the display time zone.
+func FormatDate(t time.Time) string {
+	return t.In(displayLocation).Format(dateFormat)
+}
@@ -3,7 +3,7 @@ module github.com/lusingander/ecr-browser
 go 1.13
 
 require (
-	github.com/aws/aws-sdk-go v1.33.21
+	github.com/aws/aws-sdk-go v1.48.0
 	github.com/dustin/go-humanize v1.0.0
 	github.com/eihigh/goban v0.0.0-20190801102221-2682b1cd4874
 	github.com/gdamore/tcell v1.1.4
@@ -0,0 +1,153 @@
+package iam
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	Version2012 = "2012-10-17"
+	Version2008 = "2008-10-17"
+
+	EffectAllow = "Allow"
+	EffectDeny  = "Deny"
+
+	PrincipalAWS     = "AWS"
+	PrincipalService = "Service"
+
+	// Everyone is the wildcard principal and action.
+	Everyone = "*"
+)
+
+// Document is an IAM policy document, as attached to an ECR repository.
+type Document struct {
+	Version   string     `json:"Version"`
+	Id        string     `json:"Id,omitempty"`
+	Statement Statements `json:"Statement"`
+}
+
+// Statements accepts a single statement object as well as an array of them.
+type Statements []*Statement
+
+type Statement struct {
+	Sid          string                                `json:"Sid,omitempty"`
+	Effect       string                                `json:"Effect"`
+	Principal    *Principal                            `json:"Principal,omitempty"`
+	NotPrincipal *Principal                            `json:"NotPrincipal,omitempty"`
+	Action       StringList                            `json:"Action,omitempty"`
+	NotAction    StringList                            `json:"NotAction,omitempty"`
+	Resource     StringList                            `json:"Resource,omitempty"`
+	NotResource  StringList                            `json:"NotResource,omitempty"`
+	Condition    map[string]map[string]json.RawMessage `json:"Condition,omitempty"` // values may be strings, numbers or booleans, or arrays of them
+
+	raw json.RawMessage
+}
+
+// Principal is either the wildcard "*" or a map from principal type to identifiers.
+type Principal struct {
+	Any    bool
+	Values map[string]StringList
+}
+
+// StringList accepts a single string as well as an array of strings.
+type StringList []string
+
+// Parse reads a policy document, rejecting fields IAM does not know.
+func Parse(text string) (*Document, error) {
+	var d Document
+	if err := decodeStrict([]byte(text), &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func decodeStrict(bs []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(bs))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+func (ss *Statements) UnmarshalJSON(bs []byte) error {
+	var raws []json.RawMessage
+	if bytes.HasPrefix(bytes.TrimSpace(bs), []byte("{")) {
+		raws = []json.RawMessage{bs}
+	} else if err := json.Unmarshal(bs, &raws); err != nil {
+		return err
+	}
+	*ss = make(Statements, 0, len(raws))
+	for _, raw := range raws {
+		var s Statement
+		if err := decodeStrict(raw, &s); err != nil {
+			return err
+		}
+		s.raw = raw
+		*ss = append(*ss, &s)
+	}
+	return nil
+}
+
+func (p *Principal) UnmarshalJSON(bs []byte) error {
+	var s string
+	if err := json.Unmarshal(bs, &s); err == nil {
+		if s != Everyone {
+			return fmt.Errorf("principal must be %q or an object", Everyone)
+		}
+		p.Any = true
+		return nil
+	}
+	return json.Unmarshal(bs, &p.Values)
+}
+
+func (p *Principal) MarshalJSON() ([]byte, error) {
+	if p.Any {
+		return json.Marshal(Everyone)
+	}
+	return json.Marshal(p.Values)
+}
+
+func (l *StringList) UnmarshalJSON(bs []byte) error {
+	var s string
+	if err := json.Unmarshal(bs, &s); err == nil {
+		*l = StringList{s}
+		return nil
+	}
+	var ss []string
+	if err := json.Unmarshal(bs, &ss); err != nil {
+		return err
+	}
+	*l = ss
+	return nil
+}
+
+// Display returns the Sid, or a summary if the statement has none.
+func (s *Statement) Display() string {
+	if s.Sid != "" {
+		return fmt.Sprintf("%s %s", s.Effect, s.Sid)
+	}
+	actions := s.Action
+	if len(actions) == 0 {
+		actions = s.NotAction
+	}
+	return fmt.Sprintf("%s %s", s.Effect, strings.Join(DescribeActions(actions), ", "))
+}
+
+// JSON returns the statement as written in the document, indented.
+func (s *Statement) JSON() string {
+	if s.raw == nil {
+		bs, _ := json.MarshalIndent(s, "", "  ")
+		return string(bs)
+	}
+	return Format(string(s.raw))
+}
+
+// Format indents a JSON document, keeping its keys in the original order.
+// It returns text as is if it is not valid JSON.
+func Format(text string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(strings.TrimSpace(text)), "", "  "); err != nil {
+		return text
+	}
+	return buf.String()
+}
@@ -0,0 +1,85 @@
+package iam
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const crossAccountPull = `{
+  "Version": "2012-10-17",
+  "Statement": {
+    "Sid": "CrossAccountPull",
+    "Effect": "Allow",
+    "Principal": {"AWS": ["arn:aws:iam::210987654321:root", "123456789012"]},
+    "Action": ["ecr:BatchGetImage", "ecr:GetDownloadUrlForLayer", "ecr:BatchCheckLayerAvailability", "ecr:DescribeImages"]
+  }
+}`
+
+func TestParseAndValidate(t *testing.T) {
+	tests := []struct {
+		policy string
+		err    string
+	}{
+		{crossAccountPull, ""},
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"ecr:*"}]}`, ""},
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Deny","Principal":"*","Action":"ecr:*","Condition":{"Bool":{"aws:SecureTransport":false}}}]}`, ""},
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"ecr:*","Condition":{"NumericLessThan":{"aws:MultiFactorAuthAge":3600},"StringEquals":{"aws:PrincipalOrgID":["o-a","o-b"]}}}]}`, ""},
+		{`{"Version":"2012-10-17","Statement":[]}`, "at least one statement"},
+		{`{"Version":"2020-01-01","Statement":[{"Effect":"Allow","Principal":"*","Action":"ecr:*"}]}`, "Version must be"},
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"allow","Principal":"*","Action":"ecr:*"}]}`, "Effect must be"},
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"ecr:*"}]}`, "Principal is required"},
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*"}]}`, "Action is required"},
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject"}]}`, "not an ecr action"},
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"alice"},"Action":"ecr:*"}]}`, "must be an account ID or an ARN"},
+		{`{"Version":"2012-10-17","Statement":[{"Sid":"a","Effect":"Allow","Principal":"*","Action":"ecr:*"},{"Sid":"a","Effect":"Deny","Principal":"*","Action":"ecr:*"}]}`, "Sid must be unique"},
+		{`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principals":"*","Action":"ecr:*"}]}`, "unknown field"},
+	}
+	for _, test := range tests {
+		d, err := Parse(test.policy)
+		if err == nil {
+			err = Validate(d)
+		}
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("Validate(%s) error = %v; want = nil", test.policy, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Validate(%s) error = %v; want containing %q", test.policy, err, test.err)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	d, err := Parse(crossAccountPull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := d.Summary("123456789012")
+	want := []string{
+		"Allow AWS arn:aws:iam::210987654321:root (cross-account): pull, ecr:DescribeImages",
+		"Allow AWS 123456789012: pull, ecr:DescribeImages",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Summary() = %q; want = %q", got, want)
+	}
+}
+
+func TestAccountID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"123456789012", "123456789012"},
+		{"arn:aws:iam::123456789012:role/ci", "123456789012"},
+		{"arn:aws:ecr:ap-northeast-1:123456789012:repository/app", "123456789012"},
+		{"*", ""},
+	}
+	for _, test := range tests {
+		if got := AccountID(test.id); got != test.want {
+			t.Errorf("AccountID(%q) = %v; want = %v", test.id, got, test.want)
+		}
+	}
+}
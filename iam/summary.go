@@ -0,0 +1,169 @@
+package iam
+
+import (
+	"sort"
+	"strings"
+)
+
+var (
+	// actionGroups name the sets of actions a client needs for common operations.
+	actionGroups = []struct {
+		name    string
+		actions []string
+	}{
+		{"pull", []string{"ecr:BatchGetImage", "ecr:GetDownloadUrlForLayer", "ecr:BatchCheckLayerAvailability"}},
+		{"push", []string{"ecr:PutImage", "ecr:InitiateLayerUpload", "ecr:UploadLayerPart", "ecr:CompleteLayerUpload"}},
+	}
+)
+
+// Grant is what a single principal gets from a statement.
+type Grant struct {
+	Effect       string
+	Principal    string
+	Actions      []string
+	Not          bool // the statement uses NotPrincipal or NotAction
+	CrossAccount bool
+	Conditional  bool
+}
+
+func (g *Grant) String() string {
+	var sb strings.Builder
+	sb.WriteString(g.Effect + " " + g.Principal)
+	if g.CrossAccount {
+		sb.WriteString(" (cross-account)")
+	}
+	sb.WriteString(": " + strings.Join(DescribeActions(g.Actions), ", "))
+	if g.Not {
+		sb.WriteString(" (with Not* exclusions)")
+	}
+	if g.Conditional {
+		sb.WriteString(" (with conditions)")
+	}
+	return sb.String()
+}
+
+// Grants lists the grants of s, one per principal.
+// account is the owner of the repository, used to detect cross-account access.
+func (s *Statement) Grants(account string) []*Grant {
+	p := s.Principal
+	if p == nil {
+		p = s.NotPrincipal
+	}
+	actions := s.Action
+	if len(actions) == 0 {
+		actions = s.NotAction
+	}
+	var ret []*Grant
+	for _, name := range principalNames(p) {
+		id := strings.TrimPrefix(name, PrincipalAWS+" ")
+		owner := AccountID(id)
+		ret = append(ret, &Grant{
+			Effect:       s.Effect,
+			Principal:    name,
+			Actions:      actions,
+			Not:          s.NotPrincipal != nil || len(s.NotAction) > 0,
+			CrossAccount: id == Everyone || (owner != "" && owner != account),
+			Conditional:  len(s.Condition) > 0,
+		})
+	}
+	return ret
+}
+
+// Grants lists the grants of all statements of d.
+func (d *Document) Grants(account string) []*Grant {
+	var ret []*Grant
+	for _, s := range d.Statement {
+		ret = append(ret, s.Grants(account)...)
+	}
+	return ret
+}
+
+func principalNames(p *Principal) []string {
+	if p == nil {
+		return nil
+	}
+	if p.Any {
+		return []string{PrincipalAWS + " " + Everyone}
+	}
+	types := make([]string, 0, len(p.Values))
+	for t := range p.Values {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	var ret []string
+	for _, t := range types {
+		for _, id := range p.Values[t] {
+			ret = append(ret, t+" "+id)
+		}
+	}
+	return ret
+}
+
+// AccountID returns the account of an account ID or ARN, or "" if it has none.
+func AccountID(id string) string {
+	if accountIDPattern.MatchString(id) {
+		return id
+	}
+	// arn:partition:service:region:account:resource
+	if parts := strings.SplitN(id, ":", 6); len(parts) == 6 && parts[0] == "arn" {
+		return parts[4]
+	}
+	return ""
+}
+
+// DescribeActions replaces complete groups of actions by their names, such as "pull".
+func DescribeActions(actions []string) []string {
+	if contains(actions, Everyone) || contains(actions, "ecr:*") {
+		return []string{"all actions"}
+	}
+	rest := append([]string{}, actions...)
+	var ret []string
+	for _, g := range actionGroups {
+		if !containsAll(rest, g.actions) {
+			continue
+		}
+		ret = append(ret, g.name)
+		rest = remove(rest, g.actions)
+	}
+	return append(ret, rest...)
+}
+
+func containsAll(ss, xs []string) bool {
+	for _, x := range xs {
+		if !containsFold(ss, x) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(ss []string, s string) bool {
+	for _, x := range ss {
+		if strings.EqualFold(x, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func remove(ss, xs []string) []string {
+	ret := ss[:0]
+	for _, s := range ss {
+		if !containsFold(xs, s) {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
+
+// Summary describes the grants of d, one per line.
+func (d *Document) Summary(account string) []string {
+	var ret []string
+	for _, g := range d.Grants(account) {
+		ret = append(ret, g.String())
+	}
+	if len(ret) == 0 {
+		ret = append(ret, "no principals")
+	}
+	return ret
+}
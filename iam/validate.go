@@ -0,0 +1,106 @@
+package iam
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	accountIDPattern = regexp.MustCompile(`^[0-9]{12}$`)
+
+	principalTypes = []string{PrincipalAWS, PrincipalService, "Federated", "CanonicalUser"}
+)
+
+// Validate checks d as ECR would when it is set as a repository policy.
+func Validate(d *Document) error {
+	var errs []string
+	if d.Version != Version2012 && d.Version != Version2008 {
+		errs = append(errs, fmt.Sprintf("Version must be %s or %s", Version2012, Version2008))
+	}
+	if len(d.Statement) == 0 {
+		errs = append(errs, "policy must have at least one statement")
+	}
+	sids := make(map[string]bool)
+	for i, s := range d.Statement {
+		name := fmt.Sprintf("statement %d", i+1)
+		if s.Sid != "" {
+			name = fmt.Sprintf("statement %s", s.Sid)
+			if sids[s.Sid] {
+				errs = append(errs, fmt.Sprintf("%s: Sid must be unique", name))
+			}
+			sids[s.Sid] = true
+		}
+		for _, e := range validateStatement(s) {
+			errs = append(errs, fmt.Sprintf("%s: %s", name, e))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func validateStatement(s *Statement) []string {
+	var errs []string
+	if s.Effect != EffectAllow && s.Effect != EffectDeny {
+		errs = append(errs, fmt.Sprintf("Effect must be %s or %s", EffectAllow, EffectDeny))
+	}
+	switch {
+	case s.Principal == nil && s.NotPrincipal == nil:
+		errs = append(errs, "Principal is required")
+	case s.Principal != nil && s.NotPrincipal != nil:
+		errs = append(errs, "Principal and NotPrincipal cannot both be set")
+	}
+	for _, p := range []*Principal{s.Principal, s.NotPrincipal} {
+		if p != nil {
+			errs = append(errs, validatePrincipal(p)...)
+		}
+	}
+	switch {
+	case len(s.Action) == 0 && len(s.NotAction) == 0:
+		errs = append(errs, "Action is required")
+	case len(s.Action) > 0 && len(s.NotAction) > 0:
+		errs = append(errs, "Action and NotAction cannot both be set")
+	}
+	for _, a := range append(append(StringList{}, s.Action...), s.NotAction...) {
+		if a != Everyone && !strings.HasPrefix(strings.ToLower(a), "ecr:") {
+			errs = append(errs, fmt.Sprintf("action %q is not an ecr action", a))
+		}
+	}
+	return errs
+}
+
+func validatePrincipal(p *Principal) []string {
+	if p.Any {
+		return nil
+	}
+	var errs []string
+	if len(p.Values) == 0 {
+		errs = append(errs, "Principal must not be empty")
+	}
+	for t, ids := range p.Values {
+		if !contains(principalTypes, t) {
+			errs = append(errs, fmt.Sprintf("principal type %q must be one of %s", t, strings.Join(principalTypes, ", ")))
+			continue
+		}
+		if t != PrincipalAWS {
+			continue
+		}
+		for _, id := range ids {
+			if id != Everyone && !accountIDPattern.MatchString(id) && !strings.HasPrefix(id, "arn:") {
+				errs = append(errs, fmt.Sprintf("principal %q must be an account ID or an ARN", id))
+			}
+		}
+	}
+	return errs
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package layerfs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	cacheDirName = "ecr-browser"
+	blobDirName  = "blobs"
+)
+
+// Cache keeps blobs on disk by digest, like an OCI layout: <dir>/<algorithm>/<hex>.
+type Cache struct {
+	dir string
+}
+
+func NewCache(dir string) *Cache {
+	return &Cache{dir}
+}
+
+// DefaultCacheDir returns the blob directory in the user cache directory.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, cacheDirName, blobDirName), nil
+}
+
+func (c *Cache) path(digest string) (string, error) {
+	kv := strings.SplitN(digest, ":", 2)
+	if len(kv) != 2 || kv[0] == "" || kv[1] == "" || strings.ContainsAny(digest, `/\.`) {
+		return "", fmt.Errorf("invalid digest: %s", digest)
+	}
+	return filepath.Join(c.dir, kv[0], kv[1]), nil
+}
+
+// Open opens the blob of digest, fetching it first if it is not cached.
+// Fetched sha256 blobs are verified before they are cached.
+func (c *Cache) Open(digest string, fetch func() (io.ReadCloser, error)) (io.ReadCloser, error) {
+	p, err := c.path(digest)
+	if err != nil {
+		return nil, err
+	}
+	if f, err := os.Open(p); err == nil {
+		return f, nil
+	}
+	if err := c.store(p, digest, fetch); err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+// store writes to a temporary file that is renamed once complete,
+// so an interrupted download is never taken for the blob.
+func (c *Cache) store(p, digest string, fetch func() (io.ReadCloser, error)) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	rc, err := fetch()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	tmp, err := ioutil.TempFile(filepath.Dir(p), ".download-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	var h hash.Hash
+	w := io.Writer(tmp)
+	if strings.HasPrefix(digest, "sha256:") {
+		h = sha256.New()
+		w = io.MultiWriter(tmp, h)
+	}
+	if _, err := io.Copy(w, rc); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if h != nil {
+		if got := "sha256:" + hex.EncodeToString(h.Sum(nil)); got != digest {
+			return fmt.Errorf("digest mismatch: got %s, want %s", got, digest)
+		}
+	}
+	return os.Rename(tmp.Name(), p)
+}
@@ -0,0 +1,131 @@
+// Package layerfs indexes the files in image layers and merges them into an image filesystem.
+package layerfs
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+type FileType int
+
+const (
+	TypeFile FileType = iota
+	TypeDir
+	TypeSymlink
+	TypeHardlink
+	TypeOther
+)
+
+func (t FileType) String() string {
+	switch t {
+	case TypeDir:
+		return "directory"
+	case TypeSymlink:
+		return "symlink"
+	case TypeHardlink:
+		return "hardlink"
+	case TypeOther:
+		return "other"
+	default:
+		return "file"
+	}
+}
+
+// Entry is a file in a layer.
+type Entry struct {
+	Path     string // relative to the root, without a leading "/" or "./"
+	Type     FileType
+	Size     int64
+	Mode     os.FileMode
+	Linkname string
+	// Whiteout means the layer deletes Path from the layers below.
+	Whiteout bool
+	// Opaque means the directory Path hides the contents it has in the layers below.
+	Opaque bool
+}
+
+// Read indexes the files of a layer blob, a tar archive that may be gzip compressed.
+func Read(r io.Reader) ([]*Entry, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zstdMagic))
+	var tr *tar.Reader
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		tr = tar.NewReader(gr)
+	case bytes.HasPrefix(magic, zstdMagic):
+		return nil, fmt.Errorf("zstd compressed layers are not supported")
+	default:
+		tr = tar.NewReader(br)
+	}
+	var es []*Entry
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return es, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if e := newEntry(h); e != nil {
+			es = append(es, e)
+		}
+	}
+}
+
+func newEntry(h *tar.Header) *Entry {
+	p := cleanPath(h.Name)
+	if p == "" {
+		return nil
+	}
+	dir, base := path.Split(p)
+	dir = strings.TrimSuffix(dir, "/")
+	if base == whiteoutOpaque {
+		return &Entry{Path: dir, Type: TypeDir, Mode: os.ModeDir | 0755, Opaque: true}
+	}
+	if strings.HasPrefix(base, whiteoutPrefix) {
+		return &Entry{Path: path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix)), Whiteout: true}
+	}
+	e := &Entry{Path: p, Size: h.Size, Mode: h.FileInfo().Mode(), Linkname: h.Linkname}
+	switch h.Typeflag {
+	case tar.TypeDir:
+		e.Type = TypeDir
+		e.Size = 0
+	case tar.TypeSymlink:
+		e.Type = TypeSymlink
+	case tar.TypeLink:
+		e.Type = TypeHardlink
+		e.Linkname = cleanPath(h.Linkname)
+	case tar.TypeReg, tar.TypeRegA:
+		e.Type = TypeFile
+	default:
+		e.Type = TypeOther
+	}
+	return e
+}
+
+func cleanPath(p string) string {
+	p = path.Clean("/" + p)
+	return strings.TrimPrefix(p, "/")
+}
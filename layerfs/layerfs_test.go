@@ -0,0 +1,233 @@
+package layerfs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+type fixtureFile struct {
+	name     string
+	typeflag byte
+	content  string
+	linkname string
+}
+
+// fixtureTarball builds a gzip compressed layer and returns it with its digest.
+func fixtureTarball(t *testing.T, files ...fixtureFile) ([]byte, string) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, f := range files {
+		h := &tar.Header{Name: f.name, Typeflag: f.typeflag, Mode: 0644, Size: int64(len(f.content)), Linkname: f.linkname}
+		if f.typeflag == tar.TypeDir {
+			h.Mode = 0755
+		}
+		if f.typeflag != tar.TypeReg {
+			h.Size = 0
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(f.content)); err != nil && h.Size > 0 {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gw.Close()
+	return buf.Bytes(), fmt.Sprintf("sha256:%x", sha256.Sum256(buf.Bytes()))
+}
+
+var (
+	lowerFiles = []fixtureFile{
+		{"./etc/", tar.TypeDir, "", ""},
+		{"./etc/os-release", tar.TypeReg, "debian", ""},
+		{"./tmp/", tar.TypeDir, "", ""},
+		{"./tmp/build.log", tar.TypeReg, "12345", ""},
+		{"./var/cache/", tar.TypeDir, "", ""},
+		{"./var/cache/a", tar.TypeReg, "aa", ""},
+		{"./bin/sh", tar.TypeSymlink, "", "/bin/dash"},
+	}
+	upperFiles = []fixtureFile{
+		{"tmp/.wh.build.log", tar.TypeReg, "", ""},
+		{"var/cache/.wh..wh..opq", tar.TypeReg, "", ""},
+		{"var/cache/b", tar.TypeReg, "b", ""},
+		{"app/server", tar.TypeReg, "binary", ""},
+		{"etc/os-release", tar.TypeReg, "debian 10", ""},
+	}
+)
+
+// blobServer is a stand-in for the URLs ECR signs for layers.
+func blobServer(t *testing.T, blobs map[string][]byte, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		bs, ok := blobs[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(bs)
+	}))
+}
+
+func httpFetch(url string) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		resp, err := http.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%s", resp.Status)
+		}
+		return resp.Body, nil
+	}
+}
+
+func readCached(t *testing.T, c *Cache, srv *httptest.Server, digest string) []*Entry {
+	rc, err := c.Open(digest, httpFetch(srv.URL+"/"+digest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	es, err := Read(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return es
+}
+
+func TestCacheAndRead(t *testing.T) {
+	lower, lowerDigest := fixtureTarball(t, lowerFiles...)
+	requests := 0
+	srv := blobServer(t, map[string][]byte{lowerDigest: lower}, &requests)
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "layerfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c := NewCache(dir)
+
+	es := readCached(t, c, srv, lowerDigest)
+	if len(es) != len(lowerFiles) {
+		t.Errorf("len(Read()) = %v; want = %v", len(es), len(lowerFiles))
+	}
+	if e := es[6]; e.Path != "bin/sh" || e.Type != TypeSymlink || e.Linkname != "/bin/dash" {
+		t.Errorf("Read()[6] = %+v; want = bin/sh -> /bin/dash", e)
+	}
+	readCached(t, c, srv, lowerDigest)
+	if requests != 1 {
+		t.Errorf("requests = %v; want = %v", requests, 1)
+	}
+
+	bad := "sha256:" + strings.Repeat("0", 64)
+	srvBad := blobServer(t, map[string][]byte{bad: lower}, &requests)
+	defer srvBad.Close()
+	if _, err := c.Open(bad, httpFetch(srvBad.URL+"/"+bad)); err == nil {
+		t.Errorf("Open() with a mismatching blob error = nil; want error")
+	}
+	if _, err := c.Open("../etc/passwd", nil); err == nil {
+		t.Errorf("Open(../etc/passwd) error = nil; want error")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	upper, _ := fixtureTarball(t, upperFiles...)
+	es, err := Read(bytes.NewReader(upper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := Build(es)
+	if n, ok := root.Find("tmp/build.log"); !ok || !n.Entry.Whiteout {
+		t.Errorf("Find(tmp/build.log) = %v, %v; want a whiteout", n, ok)
+	}
+	if n, ok := root.Find("var/cache"); !ok || !n.Entry.Opaque {
+		t.Errorf("Find(var/cache) = %v, %v; want an opaque directory", n, ok)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	lower, _ := fixtureTarball(t, lowerFiles...)
+	upper, _ := fixtureTarball(t, upperFiles...)
+	var layers [][]*Entry
+	for _, bs := range [][]byte{lower, upper} {
+		es, err := Read(bytes.NewReader(bs))
+		if err != nil {
+			t.Fatal(err)
+		}
+		layers = append(layers, es)
+	}
+	root := Merge(layers)
+
+	if _, ok := root.Find("tmp/build.log"); ok {
+		t.Errorf("Find(tmp/build.log) found a whited out file")
+	}
+	if _, ok := root.Find("var/cache/a"); ok {
+		t.Errorf("Find(var/cache/a) found a file under an opaque directory")
+	}
+	if n, ok := root.Find("var/cache/b"); !ok || n.Layer != 1 {
+		t.Errorf("Find(var/cache/b) = %v, %v; want layer 1", n, ok)
+	}
+	if n, ok := root.Find("etc/os-release"); !ok || n.Layer != 1 || n.Size() != 9 {
+		t.Errorf("Find(etc/os-release) = %v, %v; want the upper one", n, ok)
+	}
+	if n, ok := root.Find("bin/sh"); !ok || n.Layer != 0 {
+		t.Errorf("Find(bin/sh) = %v, %v; want layer 0", n, ok)
+	}
+	var names []string
+	for _, c := range root.Children() {
+		names = append(names, c.Name)
+	}
+	if got, want := strings.Join(names, ","), "app,bin,etc,tmp,var"; got != want {
+		t.Errorf("Children() = %v; want = %v", got, want)
+	}
+	if got := root.Size(); got != int64(len("debian 10")+len("b")+len("binary")) {
+		t.Errorf("Size() = %v; want = %v", got, 16)
+	}
+	if got := root.Children()[0].Path(); got != "/app" {
+		t.Errorf("Path() = %v; want = %v", got, "/app")
+	}
+}
+
+func TestMerge_markerAfterSibling(t *testing.T) {
+	lower, _ := fixtureTarball(t, lowerFiles...)
+	// markers may come after the entries they sit next to, e.g. when sorted by name
+	upper, _ := fixtureTarball(t,
+		fixtureFile{"tmp/a.log", tar.TypeReg, "a", ""},
+		fixtureFile{"tmp/.wh.build.log", tar.TypeReg, "", ""},
+		fixtureFile{"var/cache/b", tar.TypeReg, "b", ""},
+		fixtureFile{"var/cache/.wh..wh..opq", tar.TypeReg, "", ""},
+	)
+	var layers [][]*Entry
+	for _, bs := range [][]byte{lower, upper} {
+		es, err := Read(bytes.NewReader(bs))
+		if err != nil {
+			t.Fatal(err)
+		}
+		layers = append(layers, es)
+	}
+	root := Merge(layers)
+
+	if _, ok := root.Find("tmp/build.log"); ok {
+		t.Errorf("Find(tmp/build.log) found a whited out file")
+	}
+	if n, ok := root.Find("tmp/a.log"); !ok || n.Layer != 1 {
+		t.Errorf("Find(tmp/a.log) = %v, %v; want layer 1", n, ok)
+	}
+	if _, ok := root.Find("var/cache/a"); ok {
+		t.Errorf("Find(var/cache/a) found a file under an opaque directory")
+	}
+	if n, ok := root.Find("var/cache/b"); !ok || n.Layer != 1 {
+		t.Errorf("Find(var/cache/b) = %v, %v; want layer 1", n, ok)
+	}
+}
@@ -0,0 +1,170 @@
+package layerfs
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// Node is a file or directory in a tree built from layers.
+type Node struct {
+	Name string
+	// Entry is nil for a directory only implied by the paths of its children.
+	Entry *Entry
+	// Layer is the index of the layer the node last came from.
+	Layer    int
+	parent   *Node
+	children map[string]*Node
+}
+
+func newRoot() *Node {
+	return &Node{Layer: -1, children: make(map[string]*Node)}
+}
+
+// Path returns the absolute path of n.
+func (n *Node) Path() string {
+	if n.parent == nil {
+		return "/"
+	}
+	return path.Join(n.parent.Path(), n.Name)
+}
+
+func (n *Node) Parent() *Node {
+	return n.parent
+}
+
+func (n *Node) IsDir() bool {
+	return n.Entry == nil || n.Entry.Type == TypeDir
+}
+
+// Children returns the children of n, directories first, each sorted by name.
+func (n *Node) Children() []*Node {
+	ret := make([]*Node, 0, len(n.children))
+	for _, c := range n.children {
+		ret = append(ret, c)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if a, b := ret[i].IsDir(), ret[j].IsDir(); a != b {
+			return a
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+// Size returns the size of a file, or the total size of the files under a directory.
+func (n *Node) Size() int64 {
+	if !n.IsDir() {
+		return n.Entry.Size
+	}
+	var size int64
+	for _, c := range n.children {
+		size += c.Size()
+	}
+	return size
+}
+
+// Count returns the number of files under n, including n.
+func (n *Node) Count() int {
+	count := 1
+	for _, c := range n.children {
+		count += c.Count()
+	}
+	return count
+}
+
+// Find returns the node at p, relative to n.
+func (n *Node) Find(p string) (*Node, bool) {
+	cur := n
+	for _, name := range splitPath(p) {
+		c, ok := cur.children[name]
+		if !ok {
+			return nil, false
+		}
+		cur = c
+	}
+	return cur, true
+}
+
+// dir returns the directory at p, creating the missing ones.
+func (n *Node) dir(names []string) *Node {
+	cur := n
+	for _, name := range names {
+		c, ok := cur.children[name]
+		if !ok || !c.IsDir() {
+			c = &Node{Name: name, Layer: cur.Layer, parent: cur, children: make(map[string]*Node)}
+			cur.children[name] = c
+		}
+		cur = c
+	}
+	return cur
+}
+
+func splitPath(p string) []string {
+	p = cleanPath(p)
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// put adds e from layer to the tree, replacing the file at its path.
+// A directory keeps the children it has.
+func (n *Node) put(e *Entry, layer int) {
+	names := splitPath(e.Path)
+	if len(names) == 0 {
+		return
+	}
+	parent := n.dir(names[:len(names)-1])
+	name := names[len(names)-1]
+	if old, ok := parent.children[name]; ok && old.IsDir() && e.Type == TypeDir && !e.Whiteout {
+		old.Entry, old.Layer = e, layer
+		return
+	}
+	parent.children[name] = &Node{Name: name, Entry: e, Layer: layer, parent: parent, children: make(map[string]*Node)}
+}
+
+func (n *Node) remove(p string) {
+	names := splitPath(p)
+	if len(names) == 0 {
+		return
+	}
+	if parent, ok := n.Find(strings.Join(names[:len(names)-1], "/")); ok {
+		delete(parent.children, names[len(names)-1])
+	}
+}
+
+// Build builds the tree of a single layer. Whiteouts and opaque directories are kept as nodes.
+func Build(es []*Entry) *Node {
+	root := newRoot()
+	for _, e := range es {
+		root.put(e, 0)
+	}
+	return root
+}
+
+// Merge builds the filesystem of an image from its layers, lowest first,
+// applying whiteouts and opaque directories. Each node records the layer it last came from.
+// Whiteouts and opaque directories only hide the lower layers, wherever they are in the
+// tar, so they are applied before the other entries of their layer.
+func Merge(layers [][]*Entry) *Node {
+	root := newRoot()
+	for i, es := range layers {
+		for _, e := range es {
+			switch {
+			case e.Whiteout:
+				root.remove(e.Path)
+			case e.Opaque:
+				d := root.dir(splitPath(e.Path))
+				d.children = make(map[string]*Node)
+				d.Layer = i
+			}
+		}
+		for _, e := range es {
+			if !e.Whiteout && !e.Opaque {
+				root.put(e, i)
+			}
+		}
+	}
+	return root
+}
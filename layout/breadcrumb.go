@@ -30,6 +30,10 @@ func (b *Breadcrumb) Pop() string {
 	return e
 }
 
+func (b *Breadcrumb) Len() int {
+	return len(b.elements)
+}
+
 func (b *Breadcrumb) View() {
 	goban.NewBox(b.x, b.y, b.w, 1).Puts(strings.Join(b.elements, breadcrumbSep))
 }
@@ -0,0 +1,74 @@
+package layout
+
+import (
+	"github.com/eihigh/goban"
+)
+
+const (
+	confirmFooter = "y: yes  n: no  j/k: scroll"
+)
+
+// ConfirmDialog shows lines and asks the user to answer yes or no.
+type ConfirmDialog struct {
+	parent *goban.Box
+	es     goban.Events
+	title  string
+	lines  []string
+	top    int
+}
+
+func NewConfirmDialog(parent *goban.Box, es goban.Events, title string, lines []string) *ConfirmDialog {
+	return &ConfirmDialog{parent: parent, es: es, title: title, lines: lines}
+}
+
+func (d *ConfirmDialog) box() *goban.Box {
+	w := textWidth(confirmFooter)
+	for _, l := range d.lines {
+		if lw := textWidth(l); lw > w {
+			w = lw
+		}
+	}
+	return dialogBox(d.parent, w+6, len(d.lines)+5)
+}
+
+func (d *ConfirmDialog) height() int {
+	return d.box().Size.Y - 5
+}
+
+func (d *ConfirmDialog) View() {
+	dialog := d.box()
+	b := dialog.Enclose(d.title)
+	body := goban.NewBox(b.Pos.X+2, b.Pos.Y+1, b.Size.X-4, b.Size.Y-3)
+	for i := d.top; i < len(d.lines) && i < d.top+body.Size.Y; i++ {
+		body.Puts(d.lines[i] + "\x1b[0m")
+	}
+	PrintScrollBar(goban.NewBox(dialog.Pos.X, dialog.Pos.Y+1, dialog.Size.X, body.Size.Y+2), body.Size.Y, len(d.lines), d.top)
+	goban.NewBox(b.Pos.X+2, b.Pos.Y+b.Size.Y-1, b.Size.X-4, 1).Print(confirmFooter)
+}
+
+// Display shows the dialog and blocks until the user answers.
+func (d *ConfirmDialog) Display() bool {
+	goban.PushView(d)
+	defer goban.RemoveView(d)
+	for {
+		goban.Show()
+		key := d.es.ReadKey()
+		switch key.Rune() {
+		case 'y':
+			return true
+		case 'n', 'q':
+			return false
+		case 'j':
+			if d.top < len(d.lines)-d.height() {
+				d.top++
+			}
+		case 'k':
+			if d.top > 0 {
+				d.top--
+			}
+		}
+		if isCancelKey(key) {
+			return false
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package layout
+
+import (
+	"regexp"
+
+	"github.com/eihigh/goban"
+	"github.com/mattn/go-runewidth"
+)
+
+var (
+	escapeSequence = regexp.MustCompile("\x1b\\[[0-9;]*m")
+)
+
+// textWidth returns the display width of s, ignoring escape sequences.
+func textWidth(s string) int {
+	return runewidth.StringWidth(escapeSequence.ReplaceAllString(s, ""))
+}
+
+// dialogBox returns a cleared box of the given content size centered in parent,
+// shrunk to fit inside it.
+func dialogBox(parent *goban.Box, w, h int) *goban.Box {
+	if max := parent.Size.X - 4; w > max {
+		w = max
+	}
+	if max := parent.Size.Y - 2; h > max {
+		h = max
+	}
+	b := goban.NewBox(0, 0, w, h).CenterOf(parent)
+	b.Clear()
+	return b
+}
@@ -0,0 +1,145 @@
+package layout
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eihigh/goban"
+	"github.com/gdamore/tcell"
+)
+
+const (
+	formFooter = "Enter: edit  Space: next option  s: save  Esc: cancel"
+	formWidth  = 70
+)
+
+type FormField struct {
+	Label   string
+	Value   string
+	Options []string // if set, Space cycles through them and Tab completes them
+}
+
+// FormDialog lets the user fill in a fixed set of fields.
+type FormDialog struct {
+	parent *goban.Box
+	es     goban.Events
+	title  string
+	fields []*FormField
+	cur    int
+}
+
+func NewFormDialog(parent *goban.Box, es goban.Events, title string, fields []*FormField) *FormDialog {
+	return &FormDialog{parent: parent, es: es, title: title, fields: fields}
+}
+
+func (d *FormDialog) labelWidth() int {
+	w := 0
+	for _, f := range d.fields {
+		if l := textWidth(f.Label); l > w {
+			w = l
+		}
+	}
+	return w
+}
+
+func (d *FormDialog) body() *goban.Box {
+	b := dialogBox(d.parent, formWidth, len(d.fields)+5).InsideSides(1, 1, 1, 1)
+	return goban.NewBox(b.Pos.X+2, b.Pos.Y+1, b.Size.X-4, b.Size.Y-1)
+}
+
+func (d *FormDialog) View() {
+	dialogBox(d.parent, formWidth, len(d.fields)+5).Enclose(d.title)
+	body := d.body()
+	lw := d.labelWidth()
+	for i, f := range d.fields {
+		cursor := "  "
+		if i == d.cur {
+			cursor = "> "
+		}
+		body.Puts(fmt.Sprintf("%s%-*s  %s", cursor, lw, f.Label, f.Value))
+	}
+	body.Puts("")
+	body.Print(formFooter)
+}
+
+// Display shows the form and blocks until it is saved or cancelled.
+// It returns true if the user saved the form.
+func (d *FormDialog) Display() bool {
+	goban.PushView(d)
+	defer goban.RemoveView(d)
+	for {
+		goban.Show()
+		key := d.es.ReadKey()
+		switch key.Key() {
+		case tcell.KeyEnter:
+			d.edit()
+			continue
+		case tcell.KeyDown:
+			d.move(1)
+			continue
+		case tcell.KeyUp:
+			d.move(-1)
+			continue
+		}
+		if isCancelKey(key) {
+			return false
+		}
+		switch key.Rune() {
+		case 'j':
+			d.move(1)
+		case 'k':
+			d.move(-1)
+		case ' ':
+			d.nextOption()
+		case 's':
+			return true
+		case 'q':
+			return false
+		}
+	}
+}
+
+func (d *FormDialog) move(n int) {
+	d.cur = (d.cur + n + len(d.fields)) % len(d.fields)
+}
+
+func (d *FormDialog) nextOption() {
+	f := d.fields[d.cur]
+	if len(f.Options) == 0 {
+		return
+	}
+	for i, o := range f.Options {
+		if o == f.Value {
+			f.Value = f.Options[(i+1)%len(f.Options)]
+			return
+		}
+	}
+	f.Value = f.Options[0]
+}
+
+func (d *FormDialog) edit() {
+	f := d.fields[d.cur]
+	body := d.body()
+	lw := d.labelWidth()
+	x := body.Pos.X + 2 + lw + 2
+	line := goban.NewBox(x, body.Pos.Y+d.cur, body.Size.X-(x-body.Pos.X), 1)
+	input := NewInputLine(line, d.es, "")
+	if len(f.Options) > 0 {
+		input.Completion(func(s string) []string {
+			var ret []string
+			for _, o := range f.Options {
+				if strings.HasPrefix(o, s) {
+					ret = append(ret, o)
+				}
+			}
+			return ret
+		})
+	}
+	if v, ok := input.Read(f.Value); ok {
+		f.Value = v
+	}
+}
+
+func isCancelKey(key *tcell.EventKey) bool {
+	return key.Key() == tcell.KeyEscape || key.Key() == tcell.KeyCtrlC
+}
@@ -0,0 +1,80 @@
+package layout
+
+import (
+	"fmt"
+
+	"github.com/eihigh/goban"
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	helpTitle  = "HELP"
+	helpFooter = "Press any key to close"
+)
+
+type KeyHelp struct {
+	Key         string
+	Description string
+}
+
+type KeyHelpGroup struct {
+	Title string
+	Keys  []*KeyHelp
+}
+
+type HelpDialog struct {
+	parent *goban.Box
+	es     goban.Events
+	groups []*KeyHelpGroup
+}
+
+func NewHelpDialog(parent *goban.Box, es goban.Events, groups []*KeyHelpGroup) *HelpDialog {
+	return &HelpDialog{parent, es, groups}
+}
+
+func (d *HelpDialog) View() {
+	lines := d.lines()
+	w := runewidth.StringWidth(helpFooter)
+	for _, l := range lines {
+		if lw := runewidth.StringWidth(l); lw > w {
+			w = lw
+		}
+	}
+	dialog := goban.NewBox(0, 0, w+6, len(lines)+4).CenterOf(d.parent)
+	dialog.Clear()
+	b := dialog.Enclose(helpTitle)
+	b = goban.NewBox(b.Pos.X+2, b.Pos.Y+1, b.Size.X-4, b.Size.Y-1)
+	for _, l := range lines {
+		b.Puts(l)
+	}
+}
+
+func (d *HelpDialog) lines() []string {
+	kw := 0
+	for _, g := range d.groups {
+		for _, k := range g.Keys {
+			if l := runewidth.StringWidth(k.Key); l > kw {
+				kw = l
+			}
+		}
+	}
+	var lines []string
+	for i, g := range d.groups {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, g.Title)
+		for _, k := range g.Keys {
+			lines = append(lines, fmt.Sprintf("  %-*s  %s", kw, k.Key, k.Description))
+		}
+	}
+	return append(lines, "", helpFooter)
+}
+
+// Display shows the dialog and blocks until any key is pressed.
+func (d *HelpDialog) Display() {
+	goban.PushView(d)
+	defer goban.RemoveView(d)
+	goban.Show()
+	d.es.ReadKey()
+}
@@ -0,0 +1,35 @@
+package layout
+
+import "github.com/eihigh/goban"
+
+type HintBar struct {
+	x, y, w int
+	hint    string
+	message string
+}
+
+func NewHintBar(x, y, w int) *HintBar {
+	return &HintBar{x: x, y: y, w: w}
+}
+
+func (h *HintBar) SetHint(s string) {
+	h.hint = s
+}
+
+// SetMessage shows s instead of the hint until ClearMessage is called.
+func (h *HintBar) SetMessage(s string) {
+	h.message = s
+}
+
+func (h *HintBar) ClearMessage() {
+	h.message = ""
+}
+
+func (h *HintBar) View() {
+	b := goban.NewBox(h.x, h.y, h.w, 1)
+	if h.message != "" {
+		b.Print(h.message)
+		return
+	}
+	b.Print(h.hint)
+}
@@ -0,0 +1,167 @@
+package layout
+
+import (
+	"github.com/eihigh/goban"
+	"github.com/gdamore/tcell"
+)
+
+type InputLine struct {
+	box      *goban.Box
+	es       goban.Events
+	prompt   string
+	input    []rune
+	onChange func(string)
+	complete func(string) []string
+	history  *History
+
+	candidates []string
+	candidate  int
+}
+
+func NewInputLine(box *goban.Box, es goban.Events, prompt string) *InputLine {
+	return &InputLine{box: box, es: es, prompt: prompt}
+}
+
+// OnChange registers f to be called with the current input on every edit.
+func (l *InputLine) OnChange(f func(string)) *InputLine {
+	l.onChange = f
+	return l
+}
+
+// Completion registers f to return the candidate lines for the current input.
+// Pressing Tab cycles through them.
+func (l *InputLine) Completion(f func(string) []string) *InputLine {
+	l.complete = f
+	return l
+}
+
+// History makes Up/Down walk through h, and records accepted lines to it.
+func (l *InputLine) History(h *History) *InputLine {
+	l.history = h
+	return l
+}
+
+func (l *InputLine) View() {
+	l.box.Clear()
+	b := goban.NewBox(l.box.Pos.X, l.box.Pos.Y, l.box.Size.X, l.box.Size.Y)
+	b.Print(l.prompt + string(l.input) + "_")
+}
+
+// Read waits for the user to type a line starting from init.
+// It returns false if the input was cancelled with Esc.
+func (l *InputLine) Read(init string) (string, bool) {
+	l.input = []rune(init)
+	if l.history != nil {
+		l.history.rewind()
+	}
+	goban.PushView(l)
+	defer goban.RemoveView(l)
+	for {
+		goban.Show()
+		key := l.es.ReadKey()
+		if key.Key() != tcell.KeyTab {
+			l.candidates = nil
+		}
+		switch key.Key() {
+		case tcell.KeyEnter:
+			if l.history != nil {
+				l.history.Add(string(l.input))
+			}
+			return string(l.input), true
+		case tcell.KeyEscape, tcell.KeyCtrlC:
+			return "", false
+		case tcell.KeyBackspace, tcell.KeyBackspace2:
+			if len(l.input) == 0 {
+				continue
+			}
+			l.input = l.input[:len(l.input)-1]
+		case tcell.KeyCtrlU:
+			l.input = l.input[:0]
+		case tcell.KeyTab:
+			if !l.completeNext() {
+				continue
+			}
+		case tcell.KeyUp:
+			if l.history == nil {
+				continue
+			}
+			l.input = []rune(l.history.prev(string(l.input)))
+		case tcell.KeyDown:
+			if l.history == nil {
+				continue
+			}
+			l.input = []rune(l.history.next())
+		case tcell.KeyRune:
+			l.input = append(l.input, key.Rune())
+		default:
+			continue
+		}
+		if l.onChange != nil {
+			l.onChange(string(l.input))
+		}
+	}
+}
+
+func (l *InputLine) completeNext() bool {
+	if l.complete == nil {
+		return false
+	}
+	if l.candidates == nil {
+		l.candidates = l.complete(string(l.input))
+		l.candidate = 0
+	}
+	if len(l.candidates) == 0 {
+		return false
+	}
+	l.input = []rune(l.candidates[l.candidate%len(l.candidates)])
+	l.candidate++
+	return true
+}
+
+// History keeps the lines entered into an InputLine, newest last.
+type History struct {
+	entries []string
+	pos     int
+	draft   string
+}
+
+func NewHistory() *History {
+	return &History{}
+}
+
+func (h *History) Add(s string) {
+	if s == "" {
+		return
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1] == s {
+		return
+	}
+	h.entries = append(h.entries, s)
+}
+
+func (h *History) rewind() {
+	h.pos = len(h.entries)
+}
+
+func (h *History) prev(current string) string {
+	if h.pos == len(h.entries) {
+		h.draft = current
+	}
+	if h.pos > 0 {
+		h.pos--
+	}
+	if h.pos == len(h.entries) {
+		return h.draft
+	}
+	return h.entries[h.pos]
+}
+
+func (h *History) next() string {
+	if h.pos < len(h.entries) {
+		h.pos++
+	}
+	if h.pos == len(h.entries) {
+		return h.draft
+	}
+	return h.entries[h.pos]
+}
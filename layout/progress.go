@@ -0,0 +1,78 @@
+package layout
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/eihigh/goban"
+	"github.com/lusingander/ecr-browser/util"
+)
+
+const (
+	progressBarWidth = 30
+)
+
+// ProgressDialog is a LoadingDialog that shows how much of the work is done.
+// Set may be called from any goroutine.
+type ProgressDialog struct {
+	parent  *goban.Box
+	es      goban.Events
+	message string
+	ch      chan bool
+	changed chan bool
+
+	mu          sync.Mutex
+	done, total int
+}
+
+func NewProgressDialog(parent *goban.Box, es goban.Events, message string, total int) *ProgressDialog {
+	return &ProgressDialog{
+		parent:  parent,
+		es:      es,
+		message: message,
+		ch:      make(chan bool),
+		changed: make(chan bool, 1),
+		total:   total,
+	}
+}
+
+func (d *ProgressDialog) View() {
+	d.mu.Lock()
+	done, total := d.done, d.total
+	d.mu.Unlock()
+	dialog := goban.NewBox(0, 0, progressBarWidth+10, 7).CenterOf(d.parent).Enclose("")
+	area := goban.NewBox(0, 0, progressBarWidth, 3).CenterOf(dialog)
+	area.Puts(d.message)
+	area.Puts(fmt.Sprintf("%d/%d", done, total))
+	area.Puts(util.Bar(int64(done), int64(total), progressBarWidth))
+}
+
+func (d *ProgressDialog) Display() {
+	goban.PushView(d)
+	defer goban.RemoveView(d)
+	goban.Show()
+	for {
+		select {
+		case <-d.ch:
+			return
+		case <-d.changed:
+			goban.Show()
+		case <-d.es:
+			// do nothing
+		}
+	}
+}
+
+func (d *ProgressDialog) Set(done int) {
+	d.mu.Lock()
+	d.done = done
+	d.mu.Unlock()
+	select {
+	case d.changed <- true:
+	default:
+	}
+}
+
+func (d *ProgressDialog) Close() {
+	d.ch <- true
+}
@@ -0,0 +1,185 @@
+package layout
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/eihigh/goban"
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	scrollBar = "│" // ║ (2551) or │ (2503)
+)
+
+// PrintScrollBar draws a scroll bar inside the right border of b,
+// for a view showing height of total lines starting from top.
+func PrintScrollBar(b *goban.Box, height, total, top int) {
+	if height <= 0 || total <= height {
+		return
+	}
+	barLength := height * height / total
+	if barLength < 1 {
+		barLength = 1
+	}
+	x := b.Pos.X + b.Size.X - 2
+	y := b.Pos.Y + 1 + calcScrollTopPos(height, total, top, barLength)
+	w := 1
+	s := goban.NewBox(x, y, w, barLength)
+	for i := 0; i < barLength; i++ {
+		s.Puts(scrollBar)
+	}
+}
+
+func calcScrollTopPos(height, total, top, barLength int) int {
+	barMaxMove := float64(height - barLength)
+	viewTopMaxMove := float64(total - height)
+	currentViewTop := float64(top)
+	return int((currentViewTop / viewTopMaxMove) * barMaxMove)
+}
+
+// ScrollView is an enclosed pane that soft-wraps its lines to the box width
+// and scrolls vertically.
+type ScrollView struct {
+	box     *goban.Box
+	title   string
+	lines   []string
+	top     int
+	focused bool
+}
+
+func NewScrollView(box *goban.Box, title string) *ScrollView {
+	return &ScrollView{box: box, title: title}
+}
+
+// SetLines replaces the content and scrolls back to the top.
+func (v *ScrollView) SetLines(lines []string) {
+	v.lines = lines
+	v.top = 0
+}
+
+func (v *ScrollView) SetFocus(focused bool) {
+	v.focused = focused
+}
+
+func (v *ScrollView) View() {
+	b := v.box.Enclose(FocusTitle(v.title, v.focused))
+	ls := v.wrapped()
+	v.clampTop(len(ls))
+	for i := v.top; i < len(ls) && i < v.top+v.height(); i++ {
+		b.Puts(ls[i])
+	}
+	PrintScrollBar(v.box, v.height(), len(ls), v.top)
+}
+
+func (v *ScrollView) ScrollDown() {
+	v.top++
+	v.clampTop(len(v.wrapped()))
+}
+
+func (v *ScrollView) ScrollUp() {
+	v.top--
+	v.clampTop(len(v.wrapped()))
+}
+
+func (v *ScrollView) ScrollTop() {
+	v.top = 0
+}
+
+func (v *ScrollView) ScrollBottom() {
+	l := len(v.wrapped())
+	v.top = l
+	v.clampTop(l)
+}
+
+func (v *ScrollView) clampTop(total int) {
+	if max := total - v.height(); v.top > max {
+		v.top = max
+	}
+	if v.top < 0 {
+		v.top = 0
+	}
+}
+
+func (v *ScrollView) height() int {
+	return v.box.Size.Y - 2
+}
+
+// width leaves a column for the scroll bar.
+func (v *ScrollView) width() int {
+	return v.box.Size.X - 3
+}
+
+func (v *ScrollView) wrapped() []string {
+	var ret []string
+	for _, l := range v.lines {
+		ret = append(ret, WrapLine(l, v.width())...)
+	}
+	return ret
+}
+
+// WrapLine splits s into lines no wider than w, preferring to break at spaces.
+// Continuation lines keep the indentation of s and the style set by escape sequences.
+func WrapLine(s string, w int) []string {
+	if w <= 0 || textWidth(s) <= w {
+		return []string{s}
+	}
+	body := strings.TrimLeft(s, " ")
+	indent := s[:len(s)-len(body)]
+	iw := len(indent)
+	if iw >= w {
+		indent, iw = "", 0
+	}
+	var ret []string
+	style := ""
+	for body != "" {
+		line, rest, last := splitAt(body, w-iw)
+		ret = append(ret, indent+style+line)
+		if last != "" {
+			style = last
+		}
+		body = strings.TrimLeft(rest, " ")
+	}
+	return ret
+}
+
+// splitAt also returns the last escape sequence in the first part.
+func splitAt(s string, w int) (string, string, string) {
+	width := 0
+	lastSpace := -1
+	last := ""
+	for i := 0; i < len(s); {
+		if s[i] == 0x1b {
+			if loc := escapeSequence.FindStringIndex(s[i:]); loc != nil && loc[0] == 0 {
+				last = s[i : i+loc[1]]
+				i += loc[1]
+				continue
+			}
+		}
+		r, n := utf8.DecodeRuneInString(s[i:])
+		rw := runewidth.RuneWidth(r)
+		if width+rw > w {
+			if width == 0 {
+				return s[:i+n], s[i+n:], last
+			}
+			if lastSpace > 0 {
+				return s[:lastSpace], s[lastSpace:], last
+			}
+			return s[:i], s[i:], last
+		}
+		if r == ' ' {
+			lastSpace = i
+		}
+		width += rw
+		i += n
+	}
+	return s, "", last
+}
+
+// FocusTitle decorates title when the pane has focus.
+func FocusTitle(title string, focused bool) string {
+	if focused {
+		return "\x1b[1m" + title + "\x1b[0m"
+	}
+	return title
+}
@@ -0,0 +1,27 @@
+package layout
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWrapLine(t *testing.T) {
+	tests := []struct {
+		s    string
+		w    int
+		want []string
+	}{
+		{"  short", 10, []string{"  short"}},
+		{"  arn:aws:ecr:ap-northeast-1", 12, []string{"  arn:aws:ec", "  r:ap-north", "  east-1"}},
+		{"  v1 v2 v3 v4", 8, []string{"  v1 v2", "  v3 v4"}},
+		{"abcdef", 0, []string{"abcdef"}},
+		{"\x1b[32mabc\x1b[0m", 3, []string{"\x1b[32mabc\x1b[0m"}},
+		{"  \x1b[32mabcdef", 5, []string{"  \x1b[32mabc", "  \x1b[32mdef"}},
+	}
+	for _, test := range tests {
+		got := WrapLine(test.s, test.w)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("WrapLine(%q, %v) = %q; want = %q", test.s, test.w, got, test.want)
+		}
+	}
+}
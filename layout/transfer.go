@@ -0,0 +1,110 @@
+package layout
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/eihigh/goban"
+	"github.com/lusingander/ecr-browser/util"
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	transferLabelWidth = 20
+	transferMaxRows    = 10
+)
+
+// TransferDialog is a ProgressDialog with a bar for each of several transfers.
+// Set may be called from any goroutine.
+type TransferDialog struct {
+	parent  *goban.Box
+	es      goban.Events
+	message string
+	labels  []string
+	ch      chan bool
+	changed chan bool
+
+	mu      sync.Mutex
+	done    []int64
+	totals  []int64
+	current int
+}
+
+// NewTransferDialog returns a dialog of the transfers of totals bytes, labeled with labels.
+func NewTransferDialog(parent *goban.Box, es goban.Events, message string, labels []string, totals []int64) *TransferDialog {
+	return &TransferDialog{
+		parent:  parent,
+		es:      es,
+		message: message,
+		labels:  labels,
+		ch:      make(chan bool),
+		changed: make(chan bool, 1),
+		done:    make([]int64, len(totals)),
+		totals:  totals,
+	}
+}
+
+func (d *TransferDialog) View() {
+	d.mu.Lock()
+	done := append([]int64(nil), d.done...)
+	current := d.current
+	d.mu.Unlock()
+	rows := len(d.labels)
+	if rows > transferMaxRows {
+		rows = transferMaxRows
+	}
+	// scroll so that the transfer in progress stays in sight
+	start := current - rows/2
+	if max := len(d.labels) - rows; start > max {
+		start = max
+	}
+	if start < 0 {
+		start = 0
+	}
+	w := transferLabelWidth + progressBarWidth + 7
+	dialog := dialogBox(d.parent, w+4, rows+4).Enclose("")
+	area := goban.NewBox(0, 0, w, rows+2).CenterOf(dialog)
+	area.Puts(d.message)
+	area.Puts("")
+	for i := start; i < start+rows; i++ {
+		label := runewidth.FillRight(runewidth.Truncate(d.labels[i], transferLabelWidth, "…"), transferLabelWidth)
+		bar := runewidth.FillRight(util.Bar(done[i], d.totals[i], progressBarWidth), progressBarWidth)
+		var pct int64 = 100
+		if d.totals[i] > 0 {
+			pct = done[i] * 100 / d.totals[i]
+		}
+		area.Puts(fmt.Sprintf("%s %s %4d%%", label, bar, pct))
+	}
+}
+
+func (d *TransferDialog) Display() {
+	goban.PushView(d)
+	defer goban.RemoveView(d)
+	goban.Show()
+	for {
+		select {
+		case <-d.ch:
+			return
+		case <-d.changed:
+			goban.Show()
+		case <-d.es:
+			// do nothing
+		}
+	}
+}
+
+// Set records that done bytes of the i-th transfer are done.
+func (d *TransferDialog) Set(i int, done int64) {
+	d.mu.Lock()
+	d.done[i] = done
+	d.current = i
+	d.mu.Unlock()
+	select {
+	case d.changed <- true:
+	default:
+	}
+}
+
+func (d *TransferDialog) Close() {
+	d.ch <- true
+}
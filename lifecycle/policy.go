@@ -0,0 +1,101 @@
+package lifecycle
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	TagStatusTagged   = "tagged"
+	TagStatusUntagged = "untagged"
+	TagStatusAny      = "any"
+
+	CountTypeImageCountMoreThan = "imageCountMoreThan"
+	CountTypeSinceImagePushed   = "sinceImagePushed"
+
+	CountUnitDays = "days"
+
+	ActionTypeExpire = "expire"
+)
+
+var (
+	TagStatuses = []string{TagStatusTagged, TagStatusUntagged, TagStatusAny}
+	CountTypes  = []string{CountTypeImageCountMoreThan, CountTypeSinceImagePushed}
+)
+
+// Policy is an ECR lifecycle policy document.
+type Policy struct {
+	Rules []*Rule `json:"rules"`
+}
+
+type Rule struct {
+	RulePriority int       `json:"rulePriority"`
+	Description  string    `json:"description,omitempty"`
+	Selection    Selection `json:"selection"`
+	Action       Action    `json:"action"`
+}
+
+type Selection struct {
+	TagStatus     string   `json:"tagStatus"`
+	TagPrefixList []string `json:"tagPrefixList,omitempty"`
+	// TagPatternList is like TagPrefixList, but * matches any characters anywhere in the tag.
+	TagPatternList []string `json:"tagPatternList,omitempty"`
+	CountType      string   `json:"countType"`
+	CountUnit      string   `json:"countUnit,omitempty"`
+	CountNumber    int      `json:"countNumber"`
+}
+
+type Action struct {
+	Type string `json:"type"`
+}
+
+// Parse reads a policy document and sorts its rules by priority.
+func Parse(text string) (*Policy, error) {
+	var p Policy
+	if err := json.Unmarshal([]byte(text), &p); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(p.Rules, func(i, j int) bool { return p.Rules[i].RulePriority < p.Rules[j].RulePriority })
+	return &p, nil
+}
+
+func (r *Rule) Display() string {
+	return fmt.Sprintf("#%d %s", r.RulePriority, r.Summary())
+}
+
+// Summary describes the rule in a single sentence.
+func (r *Rule) Summary() string {
+	s := r.Selection
+	var target string
+	switch s.TagStatus {
+	case TagStatusTagged:
+		target = "tagged images"
+		var patterns []string
+		for _, p := range s.TagPrefixList {
+			patterns = append(patterns, p+"*")
+		}
+		patterns = append(patterns, s.TagPatternList...)
+		if len(patterns) > 0 {
+			target = fmt.Sprintf("images tagged %s", strings.Join(patterns, " and "))
+		}
+	case TagStatusUntagged:
+		target = "untagged images"
+	default:
+		target = "all images"
+	}
+	switch s.CountType {
+	case CountTypeImageCountMoreThan:
+		return fmt.Sprintf("%s %s beyond the newest %d", r.Action.Type, target, s.CountNumber)
+	case CountTypeSinceImagePushed:
+		return fmt.Sprintf("%s %s pushed more than %d %s ago", r.Action.Type, target, s.CountNumber, s.CountUnit)
+	}
+	return fmt.Sprintf("%s %s", r.Action.Type, target)
+}
+
+// JSON returns the rule as indented JSON.
+func (r *Rule) JSON() string {
+	bs, _ := json.MarshalIndent(r, "", "  ")
+	return string(bs)
+}
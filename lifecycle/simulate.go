@@ -0,0 +1,133 @@
+package lifecycle
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+// Result is the outcome of a simulation for a single image.
+// ExpiredBy is nil if no rule expires the image.
+type Result struct {
+	Image     *domain.Image
+	ExpiredBy *Rule
+}
+
+// Simulate evaluates the policy against imgs as ECR would at now,
+// and returns a result for every image in the order given.
+//
+// Rules are evaluated from the lowest priority number. Once an image matches
+// the tag selection of a rule, rules with a higher number never consider it.
+func Simulate(p *Policy, imgs []*domain.Image, now time.Time) []*Result {
+	results := make([]*Result, len(imgs))
+	claimed := make([]bool, len(imgs))
+	for i, img := range imgs {
+		results[i] = &Result{Image: img}
+	}
+	for _, r := range p.Rules {
+		var selected []int
+		for i, img := range imgs {
+			if !claimed[i] && r.Selection.matchTags(img) {
+				selected = append(selected, i)
+				claimed[i] = true
+			}
+		}
+		for _, i := range r.Selection.expire(imgs, selected, now) {
+			results[i].ExpiredBy = r
+		}
+	}
+	return results
+}
+
+// Expired returns the images that some rule expires.
+func Expired(results []*Result) []*Result {
+	var ret []*Result
+	for _, r := range results {
+		if r.ExpiredBy != nil {
+			ret = append(ret, r)
+		}
+	}
+	return ret
+}
+
+func (s *Selection) matchTags(img *domain.Image) bool {
+	switch s.TagStatus {
+	case TagStatusUntagged:
+		return len(img.Tags) == 0
+	case TagStatusTagged:
+		if len(img.Tags) == 0 {
+			return false
+		}
+		// like ECR, an image must have a tag with each of the prefixes and patterns
+		for _, p := range s.TagPrefixList {
+			if !hasTag(img.Tags, func(t string) bool { return strings.HasPrefix(t, p) }) {
+				return false
+			}
+		}
+		for _, p := range s.TagPatternList {
+			if !hasTag(img.Tags, func(t string) bool { return matchTagPattern(p, t) }) {
+				return false
+			}
+		}
+		return true
+	case TagStatusAny:
+		return true
+	}
+	return false
+}
+
+func hasTag(tags []string, match func(string) bool) bool {
+	for _, t := range tags {
+		if match(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTagPattern reports whether tag matches pattern, in which * matches any characters.
+func matchTagPattern(pattern, tag string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == tag
+	}
+	if !strings.HasPrefix(tag, parts[0]) {
+		return false
+	}
+	tag = tag[len(parts[0]):]
+	for _, p := range parts[1 : len(parts)-1] {
+		i := strings.Index(tag, p)
+		if i < 0 {
+			return false
+		}
+		tag = tag[i+len(p):]
+	}
+	return strings.HasSuffix(tag, parts[len(parts)-1])
+}
+
+func (s *Selection) expire(imgs []*domain.Image, selected []int, now time.Time) []int {
+	switch s.CountType {
+	case CountTypeImageCountMoreThan:
+		sorted := make([]int, len(selected))
+		copy(sorted, selected)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return imgs[sorted[i]].PushedAt.After(imgs[sorted[j]].PushedAt)
+		})
+		if len(sorted) <= s.CountNumber {
+			return nil
+		}
+		return sorted[s.CountNumber:]
+	case CountTypeSinceImagePushed:
+		limit := now.Add(-time.Duration(s.CountNumber) * 24 * time.Hour)
+		var ret []int
+		for _, i := range selected {
+			if imgs[i].PushedAt.Before(limit) {
+				ret = append(ret, i)
+			}
+		}
+		return ret
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package lifecycle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+var (
+	now = time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func image(daysAgo int, tags ...string) *domain.Image {
+	return domain.NewImage(tags, now.AddDate(0, 0, -daysAgo), "", 0, "", time.Time{}, "", "")
+}
+
+func expiredRules(results []*Result) []int {
+	ret := make([]int, len(results))
+	for i, r := range results {
+		if r.ExpiredBy != nil {
+			ret[i] = r.ExpiredBy.RulePriority
+		}
+	}
+	return ret
+}
+
+func TestSimulate(t *testing.T) {
+	imgs := []*domain.Image{
+		image(1, "prod-3"),
+		image(2),
+		image(3, "prod-2", "latest"),
+		image(20),
+		image(30, "prod-1"),
+		image(40, "dev-1"),
+		image(50, "dev-0"),
+	}
+	tests := []struct {
+		name   string
+		policy string
+		want   []int
+	}{
+		{
+			"untagged since pushed",
+			`{"rules":[{"rulePriority":1,"selection":{"tagStatus":"untagged","countType":"sinceImagePushed","countUnit":"days","countNumber":14},"action":{"type":"expire"}}]}`,
+			[]int{0, 0, 0, 1, 0, 0, 0},
+		},
+		{
+			"tag prefix count",
+			`{"rules":[{"rulePriority":1,"selection":{"tagStatus":"tagged","tagPrefixList":["prod"],"countType":"imageCountMoreThan","countNumber":1},"action":{"type":"expire"}}]}`,
+			[]int{0, 0, 1, 0, 1, 0, 0},
+		},
+		{
+			"every tag prefix",
+			`{"rules":[{"rulePriority":1,"selection":{"tagStatus":"tagged","tagPrefixList":["prod","latest"],"countType":"sinceImagePushed","countUnit":"days","countNumber":2},"action":{"type":"expire"}}]}`,
+			[]int{0, 0, 1, 0, 0, 0, 0},
+		},
+		{
+			"tag pattern",
+			`{"rules":[{"rulePriority":1,"selection":{"tagStatus":"tagged","tagPatternList":["*-1"],"countType":"imageCountMoreThan","countNumber":1},"action":{"type":"expire"}}]}`,
+			[]int{0, 0, 0, 0, 0, 1, 0},
+		},
+		{
+			"higher priority rule claims images",
+			`{"rules":[
+				{"rulePriority":2,"selection":{"tagStatus":"any","countType":"imageCountMoreThan","countNumber":2},"action":{"type":"expire"}},
+				{"rulePriority":1,"selection":{"tagStatus":"tagged","tagPrefixList":["prod"],"countType":"imageCountMoreThan","countNumber":10},"action":{"type":"expire"}}
+			]}`,
+			[]int{0, 0, 0, 0, 0, 2, 2},
+		},
+		{
+			"tagged without prefix",
+			`{"rules":[{"rulePriority":1,"selection":{"tagStatus":"tagged","countType":"sinceImagePushed","countUnit":"days","countNumber":35},"action":{"type":"expire"}}]}`,
+			[]int{0, 0, 0, 0, 0, 1, 1},
+		},
+	}
+	for _, test := range tests {
+		p, err := Parse(test.policy)
+		if err != nil {
+			t.Fatalf("%s: Parse() error = %v", test.name, err)
+		}
+		got := expiredRules(Simulate(p, imgs, now))
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%s: expired by = %v; want = %v", test.name, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestRule_Summary(t *testing.T) {
+	tests := []struct {
+		selection string
+		want      string
+	}{
+		{`"tagStatus":"tagged","tagPrefixList":["v1","v2"],"countType":"imageCountMoreThan","countNumber":5`, "expire images tagged v1* and v2* beyond the newest 5"},
+		{`"tagStatus":"tagged","tagPatternList":["prod-*-release"],"countType":"imageCountMoreThan","countNumber":5`, "expire images tagged prod-*-release beyond the newest 5"},
+	}
+	for _, test := range tests {
+		p, err := Parse(`{"rules":[{"rulePriority":1,"selection":{` + test.selection + `},"action":{"type":"expire"}}]}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p.Rules[0].Summary(); got != test.want {
+			t.Errorf("Summary() = %v; want = %v", got, test.want)
+		}
+	}
+}
+
+func TestMatchTagPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		tag     string
+		want    bool
+	}{
+		{"prod", "prod", true},
+		{"prod", "prod-1", false},
+		{"prod*", "prod-1", true},
+		{"*-1", "prod-1", true},
+		{"*-1", "prod-10", false},
+		{"v*.*-rc", "v1.2-rc", true},
+		{"v*.*-rc", "v1-rc", false},
+		{"a*a", "a", false},
+		{"*", "", true},
+	}
+	for _, test := range tests {
+		if got := matchTagPattern(test.pattern, test.tag); got != test.want {
+			t.Errorf("matchTagPattern(%q, %q) = %v; want = %v", test.pattern, test.tag, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package lifecycle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	minRulePriority = 1
+	maxRulePriority = 999
+
+	maxTagPatternWildcards = 4
+)
+
+// ParseStrict is like Parse, but rejects unknown fields
+// and checks the policy against the ECR lifecycle policy rules.
+func ParseStrict(text string) (*Policy, error) {
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.DisallowUnknownFields()
+	var p Policy
+	if err := dec.Decode(&p); err != nil {
+		return nil, err
+	}
+	if err := Validate(&p); err != nil {
+		return nil, err
+	}
+	return Parse(text)
+}
+
+// Validate checks p as ECR would when the policy is put.
+func Validate(p *Policy) error {
+	var errs []string
+	if len(p.Rules) == 0 {
+		errs = append(errs, "policy must have at least one rule")
+	}
+	priorities := make(map[int]bool)
+	maxPriority := 0
+	anyPriority := 0
+	for _, r := range p.Rules {
+		for _, e := range validateRule(r) {
+			errs = append(errs, fmt.Sprintf("rule %d: %s", r.RulePriority, e))
+		}
+		if priorities[r.RulePriority] {
+			errs = append(errs, fmt.Sprintf("rule %d: rulePriority must be unique", r.RulePriority))
+		}
+		priorities[r.RulePriority] = true
+		if r.RulePriority > maxPriority {
+			maxPriority = r.RulePriority
+		}
+		if r.Selection.TagStatus == TagStatusAny {
+			if anyPriority != 0 {
+				errs = append(errs, fmt.Sprintf("rule %d: only one rule can have tagStatus any", r.RulePriority))
+			}
+			anyPriority = r.RulePriority
+		}
+	}
+	if anyPriority != 0 && anyPriority != maxPriority {
+		errs = append(errs, fmt.Sprintf("rule %d: rule with tagStatus any must have the highest rulePriority", anyPriority))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func validateRule(r *Rule) []string {
+	var errs []string
+	if r.RulePriority < minRulePriority || r.RulePriority > maxRulePriority {
+		errs = append(errs, fmt.Sprintf("rulePriority must be between %d and %d", minRulePriority, maxRulePriority))
+	}
+	s := r.Selection
+	switch s.TagStatus {
+	case TagStatusTagged:
+		if len(s.TagPrefixList) == 0 && len(s.TagPatternList) == 0 {
+			errs = append(errs, "tagPrefixList or tagPatternList is required when tagStatus is tagged")
+		}
+		if len(s.TagPrefixList) > 0 && len(s.TagPatternList) > 0 {
+			errs = append(errs, "tagPrefixList and tagPatternList cannot be used together")
+		}
+	case TagStatusUntagged, TagStatusAny:
+		if len(s.TagPrefixList) > 0 || len(s.TagPatternList) > 0 {
+			errs = append(errs, "tagPrefixList and tagPatternList are only allowed when tagStatus is tagged")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("tagStatus must be one of %s", strings.Join(TagStatuses, ", ")))
+	}
+	for _, p := range s.TagPrefixList {
+		if p == "" {
+			errs = append(errs, "tagPrefixList must not contain an empty prefix")
+		}
+	}
+	for _, p := range s.TagPatternList {
+		if p == "" {
+			errs = append(errs, "tagPatternList must not contain an empty pattern")
+		} else if strings.Count(p, "*") > maxTagPatternWildcards {
+			errs = append(errs, fmt.Sprintf("tagPatternList allows up to %d wildcards in a pattern: %s", maxTagPatternWildcards, p))
+		}
+	}
+	switch s.CountType {
+	case CountTypeImageCountMoreThan:
+		if s.CountUnit != "" {
+			errs = append(errs, "countUnit is not allowed when countType is imageCountMoreThan")
+		}
+	case CountTypeSinceImagePushed:
+		if s.CountUnit != CountUnitDays {
+			errs = append(errs, "countUnit must be days when countType is sinceImagePushed")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("countType must be one of %s", strings.Join(CountTypes, ", ")))
+	}
+	if s.CountNumber < 1 {
+		errs = append(errs, "countNumber must be a positive integer")
+	}
+	if r.Action.Type != ActionTypeExpire {
+		errs = append(errs, "action type must be expire")
+	}
+	return errs
+}
+
+// Format returns p as indented JSON, the form shown in diffs and editors.
+func Format(p *Policy) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.Encode(p)
+	return strings.TrimRight(buf.String(), "\n")
+}
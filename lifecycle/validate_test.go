@@ -0,0 +1,45 @@
+package lifecycle
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStrict(t *testing.T) {
+	tests := []struct {
+		policy string
+		err    string
+	}{
+		{`{"rules":[{"rulePriority":1,"selection":{"tagStatus":"untagged","countType":"sinceImagePushed","countUnit":"days","countNumber":14},"action":{"type":"expire"}}]}`, ""},
+		{`{"rules":[]}`, "at least one rule"},
+		{`{"rules":[{"rulePriority":1,"selection":{"tagStatus":"tagged","countType":"imageCountMoreThan","countNumber":1},"action":{"type":"expire"}}]}`, "tagPrefixList or tagPatternList is required"},
+		{`{"rules":[{"rulePriority":1,"selection":{"tagStatus":"tagged","tagPatternList":["prod-*"],"countType":"imageCountMoreThan","countNumber":1},"action":{"type":"expire"}}]}`, ""},
+		{`{"rules":[{"rulePriority":1,"selection":{"tagStatus":"tagged","tagPrefixList":["prod"],"tagPatternList":["prod-*"],"countType":"imageCountMoreThan","countNumber":1},"action":{"type":"expire"}}]}`, "cannot be used together"},
+		{`{"rules":[{"rulePriority":1,"selection":{"tagStatus":"untagged","tagPatternList":["*"],"countType":"imageCountMoreThan","countNumber":1},"action":{"type":"expire"}}]}`, "only allowed when tagStatus is tagged"},
+		{`{"rules":[{"rulePriority":1,"selection":{"tagStatus":"tagged","tagPatternList":["*a*b*c*d*"],"countType":"imageCountMoreThan","countNumber":1},"action":{"type":"expire"}}]}`, "up to 4 wildcards"},
+		{`{"rules":[{"rulePriority":1,"selection":{"tagStatus":"untagged","countType":"sinceImagePushed","countNumber":1},"action":{"type":"expire"}}]}`, "countUnit must be days"},
+		{`{"rules":[{"rulePriority":1,"selection":{"tagStatus":"untagged","countType":"imageCountMoreThan","countUnit":"days","countNumber":1},"action":{"type":"expire"}}]}`, "countUnit is not allowed"},
+		{`{"rules":[{"rulePriority":1,"selection":{"tagStatus":"untagged","countType":"imageCountMoreThan","countNumber":0},"action":{"type":"expire"}}]}`, "countNumber must be a positive integer"},
+		{`{"rules":[
+			{"rulePriority":2,"selection":{"tagStatus":"any","countType":"imageCountMoreThan","countNumber":1},"action":{"type":"expire"}},
+			{"rulePriority":3,"selection":{"tagStatus":"untagged","countType":"imageCountMoreThan","countNumber":1},"action":{"type":"expire"}}
+		]}`, "must have the highest rulePriority"},
+		{`{"rules":[
+			{"rulePriority":1,"selection":{"tagStatus":"untagged","countType":"imageCountMoreThan","countNumber":1},"action":{"type":"expire"}},
+			{"rulePriority":1,"selection":{"tagStatus":"untagged","countType":"imageCountMoreThan","countNumber":2},"action":{"type":"expire"}}
+		]}`, "must be unique"},
+		{`{"rules":[{"rulePriority":1,"selection":{"tagStatus":"untagged","countType":"imageCountMoreThan","countNumber":1},"action":{"type":"expire"},"extra":1}]}`, "unknown field"},
+	}
+	for _, test := range tests {
+		_, err := ParseStrict(test.policy)
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("ParseStrict(%s) error = %v; want = nil", test.policy, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("ParseStrict(%s) error = %v; want containing %q", test.policy, err, test.err)
+		}
+	}
+}
@@ -3,32 +3,79 @@ package main
 import (
 	"flag"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/lusingander/ecr-browser/aws"
+	"github.com/lusingander/ecr-browser/cost"
 	"github.com/lusingander/ecr-browser/domain"
 	"github.com/lusingander/ecr-browser/mock"
+	"github.com/lusingander/ecr-browser/ocilayout"
+	"github.com/lusingander/ecr-browser/registry"
 	"github.com/lusingander/ecr-browser/ui"
 )
 
 var (
 	useMock *bool
+	public  *bool
+	region  *string
+	profile *string
+	tz      *string
+	prices  *string
+
+	local        *string
+	registryURL  *string
+	registryUser *string
 )
 
+const registryPasswordEnv = "REGISTRY_PASSWORD"
+
 func parseFlags() {
 	useMock = flag.Bool("mock", false, "Use mock data")
+	public = flag.Bool("public", false, "Browse ECR Public repositories (us-east-1) instead of ECR")
+	region = flag.String("region", domain.TargetRegion, "AWS region")
+	profile = flag.String("profile", "", "AWS shared config profile")
+	tz = flag.String("tz", domain.LocalTimeZone, "Time zone to display times in (local, UTC or IANA name)")
+	prices = flag.String("prices", "", "JSON file of storage prices per GB-month by region")
+	local = flag.String("local", "", "Browse OCI image layout directories or docker save archives (comma separated) instead of ECR")
+	registryURL = flag.String("registry", "", "Browse a Docker Registry HTTP API v2 (e.g. localhost:5000) instead of ECR")
+	registryUser = flag.String("registry-user", "", "User name for -registry (password from $"+registryPasswordEnv+")")
 	flag.Parse()
 }
 
-func newClient() domain.ContainerClient {
+func newClient() (domain.ContainerClient, error) {
+	if *useMock && *public {
+		return mock.NewMockPublicClient(), nil
+	}
 	if *useMock {
-		return mock.NewMockClient()
+		return mock.NewMockClient(), nil
+	}
+	if *local != "" {
+		return ocilayout.NewLayoutClient(strings.Split(*local, ","))
+	}
+	if *registryURL != "" {
+		return registry.NewRegistryClient(*registryURL, *registryUser, os.Getenv(registryPasswordEnv))
+	}
+	if *public {
+		return aws.NewAwsEcrPublicClient(*profile)
 	}
-	return aws.NewAwsEcrClient()
+	return aws.NewAwsEcrClient(*region, *profile)
 }
 
 func main() {
 	parseFlags()
-	cli := newClient()
+	if err := domain.SetDisplayTimeZone(*tz); err != nil {
+		log.Fatal(err)
+	}
+	if *prices != "" {
+		if err := cost.LoadPriceFile(*prices); err != nil {
+			log.Fatal(err)
+		}
+	}
+	cli, err := newClient()
+	if err != nil {
+		log.Fatal(err)
+	}
 	if err := ui.Start(cli); err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,85 @@
+package mock
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+// mockCachedRepositories are the upstream repositories pulled through the initial rules, by prefix.
+var mockCachedRepositories = map[string][]string{
+	"docker-hub": {"library/nginx", "library/redis", "library/golang"},
+	"quay":       {"prometheus/node-exporter", "coreos/etcd"},
+}
+
+func mockCacheRules() []*domain.PullThroughCacheRule {
+	createdAt := time.Now().AddDate(0, -2, 0)
+	return []*domain.PullThroughCacheRule{
+		{
+			Prefix:        "docker-hub",
+			UpstreamUrl:   "registry-1.docker.io",
+			CredentialArn: "arn:aws:secretsmanager:ap-northeast-1:xxx:secret:ecr-pullthroughcache/docker-hub-AbCdEf",
+			RegistryId:    "xxx",
+			CreatedAt:     createdAt,
+		},
+		{
+			Prefix:      "quay",
+			UpstreamUrl: "quay.io",
+			RegistryId:  "xxx",
+			CreatedAt:   createdAt.AddDate(0, 0, 7),
+		},
+	}
+}
+
+// cachedRepositories returns the repositories ECR created for the images pulled through the rules.
+func (c *mockClinet) cachedRepositories() []*domain.Repository {
+	var ret []*domain.Repository
+	for _, rule := range c.cacheRules {
+		for i, name := range mockCachedRepositories[rule.Prefix] {
+			name = rule.Prefix + "/" + name
+			uri := fmt.Sprintf("xxx.dkr.ecr.ap-northeast-1.amazonaws.com/%s", name)
+			arn := fmt.Sprintf("arn:aws:ecr:ap-northeast-1:xxx:repository/%s", name)
+			createdAt := rule.CreatedAt.AddDate(0, 0, i+1)
+			ret = append(ret, domain.NewRepository(name, uri, arn, "xxx", domain.TagMutabilityMutable, false, domain.EncryptionTypeAES256, "", createdAt))
+		}
+	}
+	return ret
+}
+
+func (c *mockClinet) FetchPullThroughCacheRules() ([]*domain.PullThroughCacheRule, error) {
+	time.Sleep(c.delay / 5)
+	return c.cacheRules, nil
+}
+
+func (c *mockClinet) CreatePullThroughCacheRule(in *domain.CreatePullThroughCacheRuleInput) (*domain.PullThroughCacheRule, error) {
+	for _, r := range c.cacheRules {
+		if r.Prefix == in.Prefix {
+			return nil, fmt.Errorf("PullThroughCacheRuleAlreadyExistsException: A pull through cache rule with these settings already exists for the private registry with id 'xxx'")
+		}
+	}
+	rule := &domain.PullThroughCacheRule{
+		Prefix:        in.Prefix,
+		UpstreamUrl:   in.UpstreamUrl,
+		CredentialArn: in.CredentialArn,
+		RegistryId:    "xxx",
+		CreatedAt:     time.Now(),
+	}
+	c.cacheRules = append(c.cacheRules, rule)
+	return rule, nil
+}
+
+// DeletePullThroughCacheRule keeps the repositories the rule created, as ECR does.
+func (c *mockClinet) DeletePullThroughCacheRule(prefix string) error {
+	ret := make([]*domain.PullThroughCacheRule, 0, len(c.cacheRules))
+	for _, r := range c.cacheRules {
+		if r.Prefix != prefix {
+			ret = append(ret, r)
+		}
+	}
+	if len(ret) == len(c.cacheRules) {
+		return fmt.Errorf("PullThroughCacheRuleNotFoundException: The pull through cache rule with prefix '%s' does not exist", prefix)
+	}
+	c.cacheRules = ret
+	return nil
+}
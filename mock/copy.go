@@ -0,0 +1,131 @@
+package mock
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+const (
+	mockUploadParts = 10
+)
+
+func (c *mockClinet) PlanImageCopy(repo string, img *domain.Image, dst *domain.CopyDestination) (*domain.CopyPlan, error) {
+	if err := domain.ValidateRepositoryName(dst.Repository); err != nil {
+		return nil, err
+	}
+	if err := domain.ValidateImageTag(dst.Tag); err != nil {
+		return nil, err
+	}
+	m, err := c.FetchImageManifest(repo, img)
+	if err != nil {
+		return nil, err
+	}
+	plan := &domain.CopyPlan{
+		Repository:  repo,
+		Image:       img,
+		Destination: dst,
+		Manifests:   []*domain.CopyManifest{{Digest: img.Digest, MediaType: img.ManifestMediaType}},
+		Blobs:       []*domain.CopyBlob{{Digest: m.ConfigDigest, Kind: domain.CopyBlobConfig, SizeByte: 2 * 1024}},
+	}
+	for _, l := range m.Layers {
+		plan.Blobs = append(plan.Blobs, &domain.CopyBlob{Digest: l.Digest, MediaType: l.MediaType, Kind: domain.CopyBlobLayer, SizeByte: l.SizeByte})
+	}
+	existing, err := c.destinationBlobs(dst)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range plan.Blobs {
+		b.Exists = existing[b.Digest]
+	}
+	return plan, nil
+}
+
+// destinationBlobs returns the digests of the blobs in the destination repository.
+// Mock repositories of other regions and registries are always empty.
+func (c *mockClinet) destinationBlobs(dst *domain.CopyDestination) (map[string]bool, error) {
+	ret := make(map[string]bool)
+	if dst.Region != "" || dst.RegistryId != "" {
+		return ret, nil
+	}
+	repos, err := c.FetchAllRepositories()
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, r := range repos {
+		found = found || r.Name == dst.Repository
+	}
+	if !found {
+		return nil, fmt.Errorf("RepositoryNotFoundException: The repository with name '%s' does not exist", dst.Repository)
+	}
+	imgs, err := c.FetchAllImages(dst.Repository)
+	if err != nil {
+		return nil, err
+	}
+	ms, err := c.FetchImageManifests(dst.Repository, imgs)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range ms {
+		if m == nil {
+			continue
+		}
+		ret[m.ConfigDigest] = true
+		for _, l := range m.Layers {
+			ret[l.Digest] = true
+		}
+	}
+	return ret, nil
+}
+
+// CopyImage uploads the missing blobs in parts, and adds the image to the destination
+// with the tag moved from any other image there.
+func (c *mockClinet) CopyImage(plan *domain.CopyPlan, progress func(i int, uploaded int64)) error {
+	for i, b := range plan.Blobs {
+		if b.Exists {
+			continue
+		}
+		for p := int64(1); p <= mockUploadParts; p++ {
+			time.Sleep(c.delay / mockUploadParts)
+			progress(i, b.SizeByte*p/mockUploadParts)
+		}
+	}
+	dst := plan.Destination
+	if dst.Region != "" || dst.RegistryId != "" {
+		return nil
+	}
+	imgs, err := c.FetchAllImages(dst.Repository)
+	if err != nil {
+		return err
+	}
+	m, err := c.FetchImageManifest(plan.Repository, plan.Image)
+	if err != nil {
+		return err
+	}
+	ret := make([]*domain.Image, 0, len(imgs)+1)
+	var copied *domain.Image
+	for _, img := range imgs {
+		tags := make([]string, 0, len(img.Tags))
+		for _, t := range img.Tags {
+			if t != dst.Tag {
+				tags = append(tags, t)
+			}
+		}
+		img.Tags = tags
+		if img.Digest == plan.Image.Digest {
+			copied = img
+			continue
+		}
+		ret = append(ret, img)
+	}
+	if copied == nil {
+		src := plan.Image
+		copied = domain.NewImage(nil, time.Now(), src.Digest, src.SizeByte, "", time.Time{}, src.ManifestMediaType, src.ArtifactMediaType)
+	}
+	copied.Tags = append(copied.Tags, dst.Tag)
+	c.copies[dst.Repository+"@"+copied.Digest] = m
+	c.imageCache.set(dst.Repository, append([]*domain.Image{copied}, ret...))
+	return nil
+}
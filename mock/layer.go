@@ -0,0 +1,122 @@
+package mock
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+// mockBlobs holds the layer tarballs of the manifests built so far, by digest.
+var mockBlobs sync.Map
+
+type mockFile struct {
+	name     string
+	typeflag byte
+	body     string
+	linkname string
+}
+
+func dir(name string) mockFile {
+	return mockFile{name: name, typeflag: tar.TypeDir}
+}
+
+func file(name, body string) mockFile {
+	return mockFile{name: name, typeflag: tar.TypeReg, body: body}
+}
+
+func symlink(name, target string) mockFile {
+	return mockFile{name: name, typeflag: tar.TypeSymlink, linkname: target}
+}
+
+func (c *mockClinet) FetchLayerBlob(repo, digest string) (io.ReadCloser, error) {
+	time.Sleep(c.delay)
+	if bs, ok := mockBlobs.Load(digest); ok {
+		return ioutil.NopCloser(bytes.NewReader(bs.([]byte))), nil
+	}
+	return nil, fmt.Errorf("LayersNotFoundException: layer %s not found", digest)
+}
+
+// mockLayer builds the tarball of files, whose digest identifies the layer.
+// The size is what the manifest claims, not the size of the tarball.
+func mockLayer(files []mockFile, size int64) *domain.Layer {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, f := range files {
+		h := &tar.Header{Name: f.name, Typeflag: f.typeflag, Linkname: f.linkname, Mode: 0644}
+		switch f.typeflag {
+		case tar.TypeDir:
+			h.Mode = 0755
+		case tar.TypeReg:
+			h.Size = int64(len(f.body))
+		}
+		tw.WriteHeader(h)
+		tw.Write([]byte(f.body))
+	}
+	tw.Close()
+	gw.Close()
+	bs := buf.Bytes()
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(bs))
+	mockBlobs.Store(digest, bs)
+	return &domain.Layer{Digest: digest, SizeByte: size, MediaType: mockLayerMediaType}
+}
+
+func baseFiles() []mockFile {
+	return []mockFile{
+		dir("bin/"),
+		file("bin/bash", "ELF bash"),
+		file("bin/dash", "ELF dash"),
+		symlink("bin/sh", "dash"),
+		dir("etc/"),
+		file("etc/os-release", "PRETTY_NAME=\"Debian GNU/Linux 10 (buster)\"\n"),
+		file("etc/passwd", "root:x:0:0:root:/root:/bin/bash\n"),
+		dir("tmp/"),
+		file("tmp/apt-install.log", "Setting up ca-certificates\n"),
+		dir("usr/lib/x86_64-linux-gnu/"),
+		file("usr/lib/x86_64-linux-gnu/libc.so.6", "ELF libc"),
+		dir("var/cache/apt/"),
+		file("var/cache/apt/pkgcache.bin", "apt cache"),
+	}
+}
+
+func runtimeFiles(version string) []mockFile {
+	return []mockFile{
+		file("tmp/.wh.apt-install.log", ""),
+		file("var/cache/apt/.wh..wh..opq", ""),
+		dir("usr/local/go/"),
+		file("usr/local/go/VERSION", "go"+version),
+		dir("usr/local/go/bin/"),
+		file("usr/local/go/bin/go", "ELF go "+version),
+		file("usr/local/go/bin/gofmt", "ELF gofmt "+version),
+	}
+}
+
+func depsFiles(repo string, n int) []mockFile {
+	v := "v1." + strconv.Itoa(n) + ".0"
+	return []mockFile{
+		dir("app/"),
+		file("app/go.mod", "module github.com/example/"+repo+"\n\nrequire github.com/example/lib "+v+"\n"),
+		file("app/go.sum", "github.com/example/lib "+v+" h1:...\n"),
+		dir("go/pkg/mod/github.com/example/lib@" + v + "/"),
+		file("go/pkg/mod/github.com/example/lib@"+v+"/lib.go", "package lib\n"),
+		dir("tmp/go-build/"),
+		file("tmp/go-build/cache", "build cache"),
+	}
+}
+
+func appFiles(repo, version string) []mockFile {
+	return []mockFile{
+		file("app/server", "ELF "+repo+" "+version),
+		file("app/config.yaml", "port: 8080\nversion: "+version+"\n"),
+		file("tmp/.wh.go-build", ""),
+	}
+}
@@ -0,0 +1,123 @@
+package mock
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+const (
+	mockLayerMediaType = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+	mockBaseCreatedBy  = "/bin/sh -c #(nop) ADD file:%s in / "
+)
+
+func (c *mockClinet) FetchImageManifest(repo string, img *domain.Image) (*domain.Manifest, error) {
+	time.Sleep(c.delay / 5)
+	if m := c.manifest(repo, img); m != nil {
+		return m, nil
+	}
+	return nil, fmt.Errorf("ImageNotFoundException: manifest of %s not found", img.Digest)
+}
+
+func (c *mockClinet) FetchImageManifests(repo string, imgs []*domain.Image) ([]*domain.Manifest, error) {
+	time.Sleep(c.delay)
+	ret := make([]*domain.Manifest, len(imgs))
+	for i, img := range imgs {
+		ret[i] = c.manifest(repo, img)
+	}
+	return ret, nil
+}
+
+func (c *mockClinet) manifest(repo string, img *domain.Image) *domain.Manifest {
+	if m, ok := c.copies[repo+"@"+img.Digest]; ok {
+		return m
+	}
+	for i := 1; i <= c.imageCount; i++ {
+		if fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(repo+strconv.Itoa(i)+repo))) == img.Digest {
+			return manifest(i, repo, img)
+		}
+	}
+	return nil
+}
+
+func mockDigest(s string) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(s)))
+}
+
+// manifest builds the i-th image of repo from four layers: an OS layer shared by all images,
+// a runtime layer upgraded 20 images ago, a dependency layer shared by 5 images and the app itself.
+// Every 15th image is the previous one pushed again under another manifest digest.
+func manifest(i int, repo string, img *domain.Image) *domain.Manifest {
+	if i%15 == 0 {
+		m := manifest(i-1, repo, image(i-1, repo))
+		m.Digest = img.Digest
+		return m
+	}
+	runtime, goVersion := "1.14.6", "go1.14.6"
+	if i > 20 {
+		runtime, goVersion = "1.13.14", "go1.13.14"
+	}
+	deps := (i - 1) / 5
+	base := img.PushedAt.AddDate(0, -3, 0)
+	version := fmt.Sprintf("%x", sha256.Sum256([]byte(repo+strconv.Itoa(i))))[:7]
+	layers := []*domain.Layer{
+		mockLayer(baseFiles(), 200*1024),
+		mockLayer(runtimeFiles(runtime), 150*1024),
+		mockLayer(depsFiles(repo, deps), int64(50+deps)*1024),
+	}
+	app := img.SizeByte
+	for _, l := range layers {
+		app -= l.SizeByte
+	}
+	if app < 1024 {
+		app = 1024
+	}
+	layers = append(layers, mockLayer(appFiles(repo, version), app))
+	cmd := []string{"--port=8080"}
+	if i%9 == 0 {
+		cmd = append(cmd, "--debug")
+	}
+	labels := map[string]string{
+		"org.opencontainers.image.source":   "https://github.com/example/" + repo,
+		"org.opencontainers.image.revision": version,
+	}
+	if i <= 20 {
+		labels["org.opencontainers.image.vendor"] = "example"
+	}
+	config := &domain.ImageConfig{
+		Architecture: "amd64",
+		OS:           "linux",
+		CreatedAt:    img.PushedAt,
+		Env: []string{
+			"PATH=/go/bin:/usr/local/go/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+			"GOLANG_VERSION=" + runtime,
+			"APP_VERSION=" + version,
+		},
+		Labels:       labels,
+		Entrypoint:   []string{"/app/server"},
+		Cmd:          cmd,
+		WorkingDir:   "/app",
+		User:         "app",
+		ExposedPorts: []string{"8080/tcp"},
+		History: []*domain.HistoryEntry{
+			{CreatedAt: base, CreatedBy: fmt.Sprintf(mockBaseCreatedBy, mockDigest("debian-buster-slim")[7:19])},
+			{CreatedAt: base, CreatedBy: `/bin/sh -c #(nop)  CMD ["bash"]`, EmptyLayer: true},
+			{CreatedAt: base.AddDate(0, 1, 0), CreatedBy: "/bin/sh -c set -eux; url=\"https://golang.org/dl/" + goVersion + ".linux-amd64.tar.gz\"; wget -O go.tgz \"$url\"; tar -C /usr/local -xzf go.tgz; rm go.tgz"},
+			{CreatedAt: img.PushedAt, CreatedBy: "WORKDIR /app", EmptyLayer: true},
+			{CreatedAt: img.PushedAt, CreatedBy: "COPY go.mod go.sum ./ # buildkit", Comment: "buildkit.dockerfile.v0"},
+			{CreatedAt: img.PushedAt, CreatedBy: "COPY . . # buildkit", Comment: "buildkit.dockerfile.v0"},
+			{CreatedAt: img.PushedAt, CreatedBy: "USER app", EmptyLayer: true},
+			{CreatedAt: img.PushedAt, CreatedBy: `ENTRYPOINT ["/app/server"]`, EmptyLayer: true},
+		},
+	}
+	return &domain.Manifest{
+		Digest:       img.Digest,
+		MediaType:    img.ManifestMediaType,
+		ConfigDigest: mockDigest(img.Digest + "-config"),
+		Layers:       layers,
+		Config:       config,
+	}
+}
@@ -4,24 +4,98 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/lusingander/ecr-browser/domain"
 )
 
+const (
+	defaultRepositoryCount = 20
+	defaultImageCount      = 50
+	defaultDelay           = time.Millisecond * 500
+)
+
 type mockClinet struct {
+	repositoryCount int
+	imageCount      int
+	delay           time.Duration
 	repositoryCache
-	imageCacheMap
+	*imageCache
+	lifecyclePolicies  map[string]string
+	repositoryPolicies map[string]string
+	repositoryTags     map[string]map[string]string
+	copies             map[string]*domain.Manifest // manifests of copied images, by repository@digest
+	cacheRules         []*domain.PullThroughCacheRule
 }
 
 type repositoryCache []*domain.Repository
 
-type imageCacheMap map[string][]*domain.Image
+// imageCache is safe for concurrent use, so images of several repositories can be fetched at once.
+type imageCache struct {
+	mu sync.Mutex
+	m  map[string][]*domain.Image
+}
+
+func newImageCache() *imageCache {
+	return &imageCache{m: make(map[string][]*domain.Image)}
+}
+
+func (c *imageCache) get(repo string) ([]*domain.Image, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	imgs, ok := c.m[repo]
+	return imgs, ok
+}
+
+func (c *imageCache) set(repo string, imgs []*domain.Image) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.m[repo] = imgs
+}
+
+func (c *imageCache) remove(repo string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.m, repo)
+}
+
+// without drops deleted from the cached images of repo.
+func (c *imageCache) without(repo string, deleted map[*domain.Image]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cache, ok := c.m[repo]
+	if !ok {
+		return
+	}
+	ret := make([]*domain.Image, 0, len(cache))
+	for _, img := range cache {
+		if !deleted[img] {
+			ret = append(ret, img)
+		}
+	}
+	c.m[repo] = ret
+}
 
 func NewMockClient() domain.ContainerClient {
+	return NewMockClientWithSize(defaultRepositoryCount, defaultImageCount, defaultDelay)
+}
+
+// NewMockClientWithSize returns a mock client that serves repos repositories
+// with imgs images each, waiting delay before every uncached fetch.
+func NewMockClientWithSize(repos, imgs int, delay time.Duration) domain.ContainerClient {
 	return &mockClinet{
+		repositoryCount: repos,
+		imageCount:      imgs,
+		delay:           delay,
 		repositoryCache: make(repositoryCache, 0),
-		imageCacheMap:   make(imageCacheMap),
+		imageCache:      newImageCache(),
+
+		lifecyclePolicies:  make(map[string]string),
+		repositoryPolicies: make(map[string]string),
+		repositoryTags:     make(map[string]map[string]string),
+		copies:             make(map[string]*domain.Manifest),
+		cacheRules:         mockCacheRules(),
 	}
 }
 
@@ -30,13 +104,14 @@ func (c *mockClinet) FetchAllRepositories() ([]*domain.Repository, error) {
 		return c.repositoryCache, nil
 	}
 
-	time.Sleep(time.Millisecond * 500)
+	time.Sleep(c.delay)
 
-	n := 20
+	n := c.repositoryCount
 	repos := make([]*domain.Repository, 0, n)
 	for i := 1; i <= n; i++ {
 		repos = append(repos, repo(i))
 	}
+	repos = append(repos, c.cachedRepositories()...)
 
 	c.repositoryCache = repos
 
@@ -44,30 +119,208 @@ func (c *mockClinet) FetchAllRepositories() ([]*domain.Repository, error) {
 }
 
 func (c *mockClinet) FetchAllImages(repo string) ([]*domain.Image, error) {
-	if cache, ok := c.imageCacheMap[repo]; ok {
+	if cache, ok := c.imageCache.get(repo); ok {
 		return cache, nil
 	}
 
-	time.Sleep(time.Millisecond * 500)
+	time.Sleep(c.delay)
 
-	n := 50
+	n := c.imageCount
 	images := make([]*domain.Image, 0, n)
 	for i := 1; i <= n; i++ {
 		images = append(images, image(i, repo))
 	}
 
-	c.imageCacheMap[repo] = images
+	c.imageCache.set(repo, images)
 
 	return images, nil
 }
 
+func (c *mockClinet) CreateRepository(in *domain.CreateRepositoryInput) (*domain.Repository, error) {
+	repos, err := c.FetchAllRepositories()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range repos {
+		if r.Name == in.Name {
+			return nil, fmt.Errorf("RepositoryAlreadyExistsException: The repository with name '%s' already exists", in.Name)
+		}
+	}
+	uri := fmt.Sprintf("xxx.dkr.ecr.ap-northeast-1.amazonaws.com/%s", in.Name)
+	arn := fmt.Sprintf("arn:aws:ecr:ap-northeast-1:xxx:repository/%s", in.Name)
+	repo := domain.NewRepository(in.Name, uri, arn, "xxx", in.TagMutability, in.ScanOnPush, in.EncryptionType, in.KmsKey, time.Now())
+	c.repositoryCache = append(c.repositoryCache, repo)
+	c.imageCache.set(in.Name, []*domain.Image{})
+	c.repositoryPolicies[in.Name] = ""
+	c.repositoryTags[in.Name] = in.Tags
+	c.lifecyclePolicies[in.Name] = ""
+	return repo, nil
+}
+
+func (c *mockClinet) DeleteRepository(repo string, force bool) error {
+	imgs, err := c.FetchAllImages(repo)
+	if err != nil {
+		return err
+	}
+	if len(imgs) > 0 && !force {
+		return fmt.Errorf("RepositoryNotEmptyException: The repository with name '%s' cannot be deleted because it still contains images", repo)
+	}
+	c.repositoryCache = c.repositoryCache.without(repo)
+	c.imageCache.remove(repo)
+	return nil
+}
+
+// without returns a new cache, so that lists built from the old one are not affected.
+func (c repositoryCache) without(repo string) repositoryCache {
+	ret := make(repositoryCache, 0, len(c))
+	for _, r := range c {
+		if r.Name != repo {
+			ret = append(ret, r)
+		}
+	}
+	return ret
+}
+
+var (
+	mockTeams = []string{"payments", "search", "platform"}
+)
+
+func (c *mockClinet) FetchRepositoryTags(repo *domain.Repository) (map[string]string, error) {
+	if tags, ok := c.repositoryTags[repo.Name]; ok {
+		return tags, nil
+	}
+	time.Sleep(c.delay / 5)
+	var i int
+	fmt.Sscanf(repo.Name, "sample-repo-%d", &i)
+	return map[string]string{
+		"team": mockTeams[i%len(mockTeams)],
+		"env":  "production",
+	}, nil
+}
+
+func (c *mockClinet) SetTagMutability(repo *domain.Repository, mutability string) error {
+	repo.TagMutability = mutability
+	return nil
+}
+
+func (c *mockClinet) SetScanOnPush(repo *domain.Repository, scanOnPush bool) error {
+	repo.ScanOnPush = scanOnPush
+	return nil
+}
+
+func (c *mockClinet) DeleteImages(repo string, imgs []*domain.Image) ([]*domain.ImageDeleteFailure, error) {
+	time.Sleep(c.delay)
+	deleted := make(map[*domain.Image]bool, len(imgs))
+	for _, img := range imgs {
+		deleted[img] = true
+	}
+	c.imageCache.without(repo, deleted)
+	return nil, nil
+}
+
+func (c *mockClinet) FetchLifecyclePolicy(repo string) (string, error) {
+	if text, ok := c.lifecyclePolicies[repo]; ok {
+		return text, nil
+	}
+	return lifecyclePolicy, nil
+}
+
+func (c *mockClinet) PutLifecyclePolicy(repo, text string) error {
+	c.lifecyclePolicies[repo] = text
+	return nil
+}
+
+func (c *mockClinet) DeleteLifecyclePolicy(repo string) error {
+	c.lifecyclePolicies[repo] = ""
+	return nil
+}
+
+const lifecyclePolicy = `{
+  "rules": [
+    {
+      "rulePriority": 1,
+      "description": "Expire untagged images older than 14 days",
+      "selection": {
+        "tagStatus": "untagged",
+        "countType": "sinceImagePushed",
+        "countUnit": "days",
+        "countNumber": 14
+      },
+      "action": {
+        "type": "expire"
+      }
+    },
+    {
+      "rulePriority": 2,
+      "description": "Keep last 30 images",
+      "selection": {
+        "tagStatus": "any",
+        "countType": "imageCountMoreThan",
+        "countNumber": 30
+      },
+      "action": {
+        "type": "expire"
+      }
+    }
+  ]
+}`
+
+func (c *mockClinet) FetchRepositoryPolicy(repo string) (string, error) {
+	if text, ok := c.repositoryPolicies[repo]; ok {
+		return text, nil
+	}
+	return repositoryPolicy, nil
+}
+
+func (c *mockClinet) PutRepositoryPolicy(repo, text string) error {
+	c.repositoryPolicies[repo] = text
+	return nil
+}
+
+func (c *mockClinet) DeleteRepositoryPolicy(repo string) error {
+	c.repositoryPolicies[repo] = ""
+	return nil
+}
+
+const repositoryPolicy = `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Sid": "CrossAccountPull",
+      "Effect": "Allow",
+      "Principal": {
+        "AWS": "arn:aws:iam::210987654321:root"
+      },
+      "Action": [
+        "ecr:BatchGetImage",
+        "ecr:GetDownloadUrlForLayer",
+        "ecr:BatchCheckLayerAvailability"
+      ]
+    },
+    {
+      "Sid": "CIPush",
+      "Effect": "Allow",
+      "Principal": {
+        "AWS": "arn:aws:iam::xxx:role/ci"
+      },
+      "Action": [
+        "ecr:PutImage",
+        "ecr:InitiateLayerUpload",
+        "ecr:UploadLayerPart",
+        "ecr:CompleteLayerUpload"
+      ]
+    }
+  ]
+}`
+
 func repo(i int) *domain.Repository {
 	name := fmt.Sprintf("sample-repo-%02d", i)
 	uri := fmt.Sprintf("xxx.dkr.ecr.ap-northeast-1.amazonaws.com/%s", name)
 	arn := fmt.Sprintf("arn:aws:ecr:ap-northeast-1:xxx:repository/%s", name)
 	tagMutability := "MUTABLE"
 	createdAt := time.Now().AddDate(0, 0, i)
-	return domain.NewRepository(name, uri, arn, tagMutability, createdAt)
+	scanOnPush := i%3 == 0
+	return domain.NewRepository(name, uri, arn, "xxx", tagMutability, scanOnPush, domain.EncryptionTypeAES256, "", createdAt)
 }
 
 func image(i int, repo string) *domain.Image {
@@ -84,5 +337,19 @@ func image(i int, repo string) *domain.Image {
 	pushedAt := time.Now().AddDate(0, 0, -i)
 	digest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(repo+is+repo)))
 	sizeByte := 1024 * 1024 * i / 2
-	return domain.NewImage(tags, pushedAt, digest, int64(sizeByte))
+	scanStatus := "COMPLETE"
+	if i%7 == 0 {
+		scanStatus = ""
+	}
+	var lastPulledAt time.Time
+	if i%4 != 0 {
+		lastPulledAt = pushedAt.AddDate(0, 0, i/2)
+	}
+	manifestMediaType := "application/vnd.docker.distribution.manifest.v2+json"
+	artifactMediaType := "application/vnd.docker.container.image.v1+json"
+	if i%9 == 0 {
+		manifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+		artifactMediaType = "application/vnd.oci.image.config.v1+json"
+	}
+	return domain.NewImage(tags, pushedAt, digest, int64(sizeByte), scanStatus, lastPulledAt, manifestMediaType, artifactMediaType)
 }
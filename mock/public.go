@@ -0,0 +1,84 @@
+package mock
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+const (
+	mockPublicAlias = "x1y2z3"
+)
+
+// mockPublicClient serves the mock repositories as ECR Public ones,
+// which have gallery pages but none of the settings and policies of private repositories.
+type mockPublicClient struct {
+	private *mockClinet
+	repositoryCache
+}
+
+func NewMockPublicClient() domain.ContainerClient {
+	return &mockPublicClient{
+		private:         NewMockClient().(*mockClinet),
+		repositoryCache: make(repositoryCache, 0),
+	}
+}
+
+func (c *mockPublicClient) FetchAllRepositories() ([]*domain.Repository, error) {
+	if len(c.repositoryCache) > 0 {
+		return c.repositoryCache, nil
+	}
+	repos, err := c.private.FetchAllRepositories()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*domain.Repository, 0, len(repos))
+	for _, r := range repos {
+		// public registries have no pull-through cache
+		if domain.FindPullThroughCacheRule(c.private.cacheRules, r.Name) != nil {
+			continue
+		}
+		uri := fmt.Sprintf("public.ecr.aws/%s/%s", mockPublicAlias, r.Name)
+		arn := fmt.Sprintf("arn:aws:ecr-public::xxx:repository/%s", r.Name)
+		ret = append(ret, domain.NewRepository(r.Name, uri, arn, r.RegistryId, "", false, "", "", r.CreatedAt))
+	}
+	c.repositoryCache = ret
+	return ret, nil
+}
+
+func (c *mockPublicClient) FetchAllImages(repo string) ([]*domain.Image, error) {
+	return c.private.FetchAllImages(repo)
+}
+
+func (c *mockPublicClient) FetchRepositoryTags(repo *domain.Repository) (map[string]string, error) {
+	return c.private.FetchRepositoryTags(repo)
+}
+
+func (c *mockPublicClient) FetchRepositoryCatalogData(repo *domain.Repository) (*domain.RepositoryCatalogData, error) {
+	time.Sleep(c.private.delay / 5)
+	var i int
+	fmt.Sscanf(repo.Name, "sample-repo-%d", &i)
+	archs := []string{"x86-64"}
+	if i%2 == 0 {
+		archs = append(archs, "ARM 64")
+	}
+	return &domain.RepositoryCatalogData{
+		Description: fmt.Sprintf("Sample service %d, built from github.com/example/%s", i, repo.Name),
+		AboutText: strings.Join([]string{
+			"## " + repo.Name,
+			"A sample service image published for the examples.",
+		}, "\n"),
+		UsageText: strings.Join([]string{
+			"docker pull " + repo.Uri + ":latest",
+			"docker run -p 8080:8080 " + repo.Uri + ":latest",
+		}, "\n"),
+		Architectures:    archs,
+		OperatingSystems: []string{"Linux"},
+	}, nil
+}
+
+func (c *mockPublicClient) RepositoryConsoleURL(repo *domain.Repository) string {
+	return repo.GalleryURL()
+}
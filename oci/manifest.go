@@ -0,0 +1,184 @@
+// Package oci parses Docker and OCI image manifests, indexes and configs.
+package oci
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+
+	defaultOS           = "linux"
+	defaultArchitecture = "amd64"
+)
+
+// ManifestMediaTypes are the manifest media types that can be parsed, to accept when fetching.
+var ManifestMediaTypes = []string{
+	MediaTypeOCIManifest,
+	MediaTypeDockerManifest,
+	MediaTypeOCIIndex,
+	MediaTypeDockerManifestList,
+}
+
+type descriptor struct {
+	MediaType string    `json:"mediaType"`
+	Digest    string    `json:"digest"`
+	Size      int64     `json:"size"`
+	Platform  *platform `json:"platform"`
+}
+
+type platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+}
+
+type manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	Config        descriptor   `json:"config"`
+	Layers        []descriptor `json:"layers"`
+	Manifests     []descriptor `json:"manifests"`
+}
+
+// IsIndex reports whether mediaType is a list of manifests for several platforms.
+func IsIndex(mediaType string) bool {
+	return mediaType == MediaTypeOCIIndex || mediaType == MediaTypeDockerManifestList
+}
+
+// MediaType returns the media type declared in a manifest or an index.
+// OCI manifests may omit it, so an object with layers is an OCI manifest
+// and one with manifests is an OCI index.
+func MediaType(bs []byte) (string, error) {
+	var m manifest
+	if err := json.Unmarshal(bs, &m); err != nil {
+		return "", err
+	}
+	return mediaType(&m), nil
+}
+
+func mediaType(m *manifest) string {
+	switch {
+	case m.MediaType != "":
+		return m.MediaType
+	case m.Manifests != nil:
+		return MediaTypeOCIIndex
+	default:
+		return MediaTypeOCIManifest
+	}
+}
+
+// SelectPlatform returns the digest of the linux/amd64 manifest in an index, or else the first one.
+func SelectPlatform(bs []byte) (string, error) {
+	var m manifest
+	if err := json.Unmarshal(bs, &m); err != nil {
+		return "", err
+	}
+	if len(m.Manifests) == 0 {
+		return "", fmt.Errorf("index has no manifests")
+	}
+	for _, d := range m.Manifests {
+		if p := d.Platform; p != nil && p.OS == defaultOS && p.Architecture == defaultArchitecture {
+			return d.Digest, nil
+		}
+	}
+	return m.Manifests[0].Digest, nil
+}
+
+// IndexManifests returns the digests of all manifests in an index, in order.
+func IndexManifests(bs []byte) ([]string, error) {
+	var m manifest
+	if err := json.Unmarshal(bs, &m); err != nil {
+		return nil, err
+	}
+	ret := make([]string, len(m.Manifests))
+	for i, d := range m.Manifests {
+		ret[i] = d.Digest
+	}
+	return ret, nil
+}
+
+// ParseManifest parses an image manifest. The config is left nil.
+func ParseManifest(digest string, bs []byte) (*domain.Manifest, error) {
+	var m manifest
+	if err := json.Unmarshal(bs, &m); err != nil {
+		return nil, err
+	}
+	mt := mediaType(&m)
+	if IsIndex(mt) {
+		return nil, fmt.Errorf("%s is an index, not a manifest", digest)
+	}
+	if mt != MediaTypeDockerManifest && mt != MediaTypeOCIManifest {
+		return nil, fmt.Errorf("unsupported manifest media type: %s", mt)
+	}
+	ret := &domain.Manifest{
+		Digest:         digest,
+		MediaType:      mt,
+		ConfigDigest:   m.Config.Digest,
+		ConfigSizeByte: m.Config.Size,
+	}
+	for _, l := range m.Layers {
+		ret.Layers = append(ret.Layers, &domain.Layer{Digest: l.Digest, SizeByte: l.Size, MediaType: l.MediaType})
+	}
+	return ret, nil
+}
+
+type config struct {
+	Architecture string    `json:"architecture"`
+	OS           string    `json:"os"`
+	Created      time.Time `json:"created"`
+	Config       struct {
+		Env          []string            `json:"Env"`
+		Labels       map[string]string   `json:"Labels"`
+		Entrypoint   []string            `json:"Entrypoint"`
+		Cmd          []string            `json:"Cmd"`
+		WorkingDir   string              `json:"WorkingDir"`
+		User         string              `json:"User"`
+		ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+	} `json:"config"`
+	History []struct {
+		Created    time.Time `json:"created"`
+		CreatedBy  string    `json:"created_by"`
+		Comment    string    `json:"comment"`
+		EmptyLayer bool      `json:"empty_layer"`
+	} `json:"history"`
+}
+
+// ParseConfig parses an image config blob.
+func ParseConfig(bs []byte) (*domain.ImageConfig, error) {
+	var c config
+	if err := json.Unmarshal(bs, &c); err != nil {
+		return nil, err
+	}
+	ret := &domain.ImageConfig{
+		Architecture: c.Architecture,
+		OS:           c.OS,
+		CreatedAt:    c.Created,
+		Env:          c.Config.Env,
+		Labels:       c.Config.Labels,
+		Entrypoint:   c.Config.Entrypoint,
+		Cmd:          c.Config.Cmd,
+		WorkingDir:   c.Config.WorkingDir,
+		User:         c.Config.User,
+	}
+	for p := range c.Config.ExposedPorts {
+		ret.ExposedPorts = append(ret.ExposedPorts, p)
+	}
+	sort.Strings(ret.ExposedPorts)
+	for _, h := range c.History {
+		ret.History = append(ret.History, &domain.HistoryEntry{
+			CreatedAt:  h.Created,
+			CreatedBy:  h.CreatedBy,
+			Comment:    h.Comment,
+			EmptyLayer: h.EmptyLayer,
+		})
+	}
+	return ret, nil
+}
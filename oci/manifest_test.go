@@ -0,0 +1,95 @@
+package oci
+
+import (
+	"testing"
+)
+
+const (
+	testIndex = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.index.v1+json",
+  "manifests": [
+    {"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:arm", "size": 1, "platform": {"architecture": "arm64", "os": "linux"}},
+    {"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:amd", "size": 1, "platform": {"architecture": "amd64", "os": "linux"}}
+  ]
+}`
+	testManifest = `{
+  "schemaVersion": 2,
+  "config": {"mediaType": "application/vnd.oci.image.config.v1+json", "digest": "sha256:config", "size": 10},
+  "layers": [
+    {"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": "sha256:l1", "size": 100},
+    {"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": "sha256:l2", "size": 50}
+  ]
+}`
+	testConfig = `{
+  "architecture": "amd64",
+  "os": "linux",
+  "created": "2020-08-01T00:00:00Z",
+  "config": {
+    "Env": ["PATH=/usr/bin"],
+    "Labels": {"version": "1.0"},
+    "Entrypoint": ["/app"],
+    "ExposedPorts": {"8080/tcp": {}}
+  },
+  "history": [
+    {"created": "2020-07-01T00:00:00Z", "created_by": "/bin/sh -c #(nop) ADD file:abc in / "},
+    {"created": "2020-08-01T00:00:00Z", "created_by": "ENTRYPOINT [\"/app\"]", "empty_layer": true}
+  ]
+}`
+)
+
+func TestMediaType(t *testing.T) {
+	tests := []struct {
+		json string
+		want string
+	}{
+		{testIndex, MediaTypeOCIIndex},
+		{testManifest, MediaTypeOCIManifest},
+		{`{"manifests": []}`, MediaTypeOCIIndex},
+		{`{"mediaType": "application/vnd.docker.distribution.manifest.v2+json"}`, MediaTypeDockerManifest},
+	}
+	for _, test := range tests {
+		if got, err := MediaType([]byte(test.json)); err != nil || got != test.want {
+			t.Errorf("MediaType(%v) = %v, %v; want = %v", test.json, got, err, test.want)
+		}
+	}
+}
+
+func TestSelectPlatform(t *testing.T) {
+	if got, err := SelectPlatform([]byte(testIndex)); err != nil || got != "sha256:amd" {
+		t.Errorf("SelectPlatform() = %v, %v; want = %v", got, err, "sha256:amd")
+	}
+}
+
+func TestIndexManifests(t *testing.T) {
+	got, err := IndexManifests([]byte(testIndex))
+	if err != nil || len(got) != 2 || got[0] != "sha256:arm" || got[1] != "sha256:amd" {
+		t.Errorf("IndexManifests() = %v, %v; want = [sha256:arm sha256:amd]", got, err)
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	m, err := ParseManifest("sha256:m", []byte(testManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ConfigDigest != "sha256:config" || m.ConfigSizeByte != 10 || len(m.Layers) != 2 || m.TotalSize() != 150 {
+		t.Errorf("ParseManifest() = %+v", m)
+	}
+	if _, err := ParseManifest("sha256:i", []byte(testIndex)); err == nil {
+		t.Errorf("ParseManifest(index) error = nil; want error")
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	c, err := ParseConfig([]byte(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Labels["version"] != "1.0" || len(c.Entrypoint) != 1 || len(c.ExposedPorts) != 1 || c.ExposedPorts[0] != "8080/tcp" {
+		t.Errorf("ParseConfig() = %+v", c)
+	}
+	if len(c.History) != 2 || !c.History[1].EmptyLayer {
+		t.Errorf("ParseConfig().History = %+v", c.History)
+	}
+}
@@ -0,0 +1,304 @@
+// Package ocilayout browses images on disk: OCI image layout directories
+// (index.json and blobs/sha256/...) and archives written by `docker save`.
+// Each layout or archive is a repository, and its images are tagged with their ref names.
+package ocilayout
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/oci"
+)
+
+const (
+	indexFile          = "index.json"
+	dockerManifestFile = "manifest.json"
+
+	refNameAnnotation = "org.opencontainers.image.ref.name"
+
+	// layers in docker archives are uncompressed tars named by their diff IDs
+	dockerLayerMediaType = "application/vnd.docker.image.rootfs.diff.tar"
+)
+
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+type index struct {
+	Manifests []descriptor `json:"manifests"`
+}
+
+// dockerManifest is an entry of the manifest.json of `docker save`.
+type dockerManifest struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+type rootFS struct {
+	RootFS struct {
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+}
+
+type repository struct {
+	*domain.Repository
+	store
+
+	once      sync.Once
+	err       error
+	images    []*domain.Image
+	manifests map[string]*domain.Manifest // by image digest, with configs
+	blobs     map[string]string           // names of the blobs not in blobs/<alg>/<hex>, by digest
+}
+
+type layoutClient struct {
+	repos []*repository
+}
+
+// NewLayoutClient returns a client of the layout directories and archives at paths.
+func NewLayoutClient(paths []string) (domain.ContainerClient, error) {
+	c := &layoutClient{}
+	names := make(map[string]int)
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		fi, err := os.Stat(abs)
+		if err != nil {
+			return nil, err
+		}
+		var s store = &dirStore{abs}
+		if !fi.IsDir() {
+			if s, err = newTarStore(abs); err != nil {
+				return nil, err
+			}
+		}
+		if !s.exists(indexFile) && !s.exists(dockerManifestFile) {
+			return nil, fmt.Errorf("%s is neither an OCI image layout nor a docker archive", p)
+		}
+		name := strings.TrimSuffix(filepath.Base(abs), filepath.Ext(abs))
+		if names[name]++; names[name] > 1 {
+			name = fmt.Sprintf("%s-%d", name, names[name])
+		}
+		repo := domain.NewRepository(name, abs, "", "", "", false, "", "", fi.ModTime())
+		c.repos = append(c.repos, &repository{Repository: repo, store: s})
+	}
+	return c, nil
+}
+
+func (c *layoutClient) FetchAllRepositories() ([]*domain.Repository, error) {
+	ret := make([]*domain.Repository, len(c.repos))
+	for i, r := range c.repos {
+		ret[i] = r.Repository
+	}
+	return ret, nil
+}
+
+// RepositoryConsoleURL returns an empty URL, as local layouts have no web page.
+func (c *layoutClient) RepositoryConsoleURL(repo *domain.Repository) string {
+	return ""
+}
+
+func (c *layoutClient) repository(name string) (*repository, error) {
+	for _, r := range c.repos {
+		if r.Name == name {
+			r.once.Do(func() { r.err = r.load() })
+			return r, r.err
+		}
+	}
+	return nil, fmt.Errorf("repository %s not found", name)
+}
+
+func (c *layoutClient) FetchAllImages(repo string) ([]*domain.Image, error) {
+	r, err := c.repository(repo)
+	if err != nil {
+		return nil, err
+	}
+	return r.images, nil
+}
+
+func (c *layoutClient) FetchImageManifest(repo string, img *domain.Image) (*domain.Manifest, error) {
+	r, err := c.repository(repo)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := r.manifests[img.Digest]; ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("manifest of %s not found", img.Digest)
+}
+
+func (c *layoutClient) FetchImageManifests(repo string, imgs []*domain.Image) ([]*domain.Manifest, error) {
+	r, err := c.repository(repo)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*domain.Manifest, len(imgs))
+	for i, img := range imgs {
+		ret[i] = r.manifests[img.Digest]
+	}
+	return ret, nil
+}
+
+func (c *layoutClient) FetchLayerBlob(repo, digest string) (io.ReadCloser, error) {
+	r, err := c.repository(repo)
+	if err != nil {
+		return nil, err
+	}
+	return r.open(r.blobName(digest))
+}
+
+// blobName returns where a blob is stored, blobs/<alg>/<hex> unless recorded otherwise.
+func (r *repository) blobName(digest string) string {
+	if name, ok := r.blobs[digest]; ok {
+		return name
+	}
+	return "blobs/" + strings.Replace(digest, ":", "/", 1)
+}
+
+func (r *repository) readBlob(digest string) ([]byte, error) {
+	return readFile(r.store, r.blobName(digest))
+}
+
+// load reads all manifests and configs, preferring index.json to manifest.json
+// since `docker save` of recent versions writes both.
+func (r *repository) load() error {
+	r.manifests = make(map[string]*domain.Manifest)
+	r.blobs = make(map[string]string)
+	if r.exists(indexFile) {
+		return r.loadIndex()
+	}
+	return r.loadDockerArchive()
+}
+
+func (r *repository) loadIndex() error {
+	bs, err := readFile(r.store, indexFile)
+	if err != nil {
+		return err
+	}
+	var idx index
+	if err := json.Unmarshal(bs, &idx); err != nil {
+		return fmt.Errorf("%s: %v", indexFile, err)
+	}
+	byDigest := make(map[string]*domain.Image)
+	for _, d := range idx.Manifests {
+		ref := d.Annotations[refNameAnnotation]
+		if img, ok := byDigest[d.Digest]; ok {
+			if ref != "" {
+				img.Tags = append(img.Tags, ref)
+			}
+			continue
+		}
+		var tags []string
+		if ref != "" {
+			tags = []string{ref}
+		}
+		img := domain.NewImage(tags, r.CreatedAt, d.Digest, 0, "", time.Time{}, d.MediaType, "")
+		byDigest[d.Digest] = img
+		r.images = append(r.images, img)
+		m, err := r.readManifest(d.Digest)
+		if err != nil {
+			// an image whose blobs are missing is still listed, without layers
+			continue
+		}
+		r.manifests[d.Digest] = m
+		r.describe(img, m)
+	}
+	return nil
+}
+
+// readManifest reads a manifest and its config, resolving an index to a platform.
+func (r *repository) readManifest(digest string) (*domain.Manifest, error) {
+	bs, err := r.readBlob(digest)
+	if err != nil {
+		return nil, err
+	}
+	mt, err := oci.MediaType(bs)
+	if err != nil {
+		return nil, err
+	}
+	if oci.IsIndex(mt) {
+		if digest, err = oci.SelectPlatform(bs); err != nil {
+			return nil, err
+		}
+		return r.readManifest(digest)
+	}
+	m, err := oci.ParseManifest(digest, bs)
+	if err != nil {
+		return nil, err
+	}
+	if m.ConfigDigest != "" {
+		bs, err := r.readBlob(m.ConfigDigest)
+		if err != nil {
+			return nil, err
+		}
+		if m.Config, err = oci.ParseConfig(bs); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// loadDockerArchive reads the manifest.json of `docker save` from versions before OCI layouts,
+// which has no manifest digests: images are identified by their config digests (image IDs),
+// and layers by their diff IDs.
+func (r *repository) loadDockerArchive() error {
+	bs, err := readFile(r.store, dockerManifestFile)
+	if err != nil {
+		return err
+	}
+	var dms []dockerManifest
+	if err := json.Unmarshal(bs, &dms); err != nil {
+		return fmt.Errorf("%s: %v", dockerManifestFile, err)
+	}
+	for _, dm := range dms {
+		config, err := readFile(r.store, dm.Config)
+		if err != nil {
+			return err
+		}
+		digest := fmt.Sprintf("sha256:%x", sha256.Sum256(config))
+		r.blobs[digest] = dm.Config
+		m := &domain.Manifest{Digest: digest, ConfigDigest: digest}
+		if m.Config, err = oci.ParseConfig(config); err != nil {
+			return fmt.Errorf("%s: %v", dm.Config, err)
+		}
+		var fs rootFS
+		if err := json.Unmarshal(config, &fs); err != nil {
+			return fmt.Errorf("%s: %v", dm.Config, err)
+		}
+		if len(fs.RootFS.DiffIDs) != len(dm.Layers) {
+			return fmt.Errorf("%s: %d layers for %d diff IDs", dm.Config, len(dm.Layers), len(fs.RootFS.DiffIDs))
+		}
+		for i, name := range dm.Layers {
+			d := fs.RootFS.DiffIDs[i]
+			r.blobs[d] = name
+			m.Layers = append(m.Layers, &domain.Layer{Digest: d, SizeByte: r.size(name), MediaType: dockerLayerMediaType})
+		}
+		img := domain.NewImage(append([]string(nil), dm.RepoTags...), r.CreatedAt, digest, 0, "", time.Time{}, "", "")
+		r.describe(img, m)
+		r.manifests[digest] = m
+		r.images = append(r.images, img)
+	}
+	return nil
+}
+
+// describe sets the size and the time of img from its manifest.
+// Images were never pushed, so the creation time in the config stands in for it.
+func (r *repository) describe(img *domain.Image, m *domain.Manifest) {
+	img.SizeByte = m.TotalSize()
+	if m.Config != nil && !m.Config.CreatedAt.IsZero() {
+		img.PushedAt = m.Config.CreatedAt
+	}
+}
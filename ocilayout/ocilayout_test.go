@@ -0,0 +1,215 @@
+package ocilayout
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/oci"
+)
+
+var testCreated = time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC)
+
+func digestOf(bs []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(bs))
+}
+
+func testConfig(diffIDs ...string) []byte {
+	ids, _ := json.Marshal(diffIDs)
+	return []byte(fmt.Sprintf(`{"architecture":"amd64","os":"linux","created":%q,"rootfs":{"type":"layers","diff_ids":%s},"history":[{"created_by":"/bin/sh -c #(nop) ADD file:abc in / "}]}`,
+		testCreated.Format(time.RFC3339), ids))
+}
+
+// writeLayout writes an OCI layout with an image tagged v1 and latest, and an index tagged multi.
+func writeLayout(t *testing.T, dir string) (layer []byte) {
+	blobs := filepath.Join(dir, "blobs", "sha256")
+	if err := os.MkdirAll(blobs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	put := func(bs []byte) string {
+		d := digestOf(bs)
+		if err := ioutil.WriteFile(filepath.Join(blobs, d[len("sha256:"):]), bs, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	layer = []byte("layer content")
+	config := testConfig(digestOf(layer))
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":%q,"config":{"digest":%q,"size":%d},"layers":[{"mediaType":"application/vnd.oci.image.layer.v1.tar","digest":%q,"size":%d}]}`,
+		oci.MediaTypeOCIManifest, put(config), len(config), put(layer), len(layer)))
+	md := put(manifest)
+	idx := []byte(fmt.Sprintf(`{"schemaVersion":2,"manifests":[{"digest":%q,"platform":{"architecture":"amd64","os":"linux"}}]}`, md))
+	id := put(idx)
+	index := fmt.Sprintf(`{"schemaVersion":2,"manifests":[
+  {"mediaType":%q,"digest":%q,"annotations":{"org.opencontainers.image.ref.name":"v1"}},
+  {"mediaType":%q,"digest":%q,"annotations":{"org.opencontainers.image.ref.name":"latest"}},
+  {"mediaType":%q,"digest":%q,"annotations":{"org.opencontainers.image.ref.name":"multi"}}
+]}`, oci.MediaTypeOCIManifest, md, oci.MediaTypeOCIManifest, md, oci.MediaTypeOCIIndex, id)
+	if err := ioutil.WriteFile(filepath.Join(dir, "index.json"), []byte(index), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "oci-layout"), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return layer
+}
+
+// writeDockerArchive writes a `docker save` archive of the legacy format with one image of two layers.
+// writeDockerArchive writes an archive like the legacy `docker save` of app:1.0 and app:2.0,
+// where app:2.0 refers to the layers it shares with app:1.0 by a symlink and a hard link.
+func writeDockerArchive(t *testing.T, file string) (layers [][]byte) {
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	add := func(name string, bs []byte) {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(bs)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write(bs)
+	}
+	link := func(name, target string, typeflag byte) {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Linkname: target, Typeflag: typeflag}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	layers = [][]byte{[]byte("base layer"), []byte("app layer!"), []byte("worker layer")}
+	config1 := testConfig(digestOf(layers[0]), digestOf(layers[1]))
+	config1Name := digestOf(config1)[len("sha256:"):] + ".json"
+	config2 := testConfig(digestOf(layers[0]), digestOf(layers[1]), digestOf(layers[2]))
+	config2Name := digestOf(config2)[len("sha256:"):] + ".json"
+	add(config1Name, config1)
+	add(config2Name, config2)
+	add("./aaa/layer.tar", layers[0])
+	add("bbb/layer.tar", layers[1])
+	link("ccc/layer.tar", "../aaa/layer.tar", tar.TypeSymlink)
+	link("ddd/layer.tar", "bbb/layer.tar", tar.TypeLink)
+	add("eee/layer.tar", layers[2])
+	add("manifest.json", []byte(fmt.Sprintf(`[{"Config":%q,"RepoTags":["app:1.0","app:latest"],"Layers":["aaa/layer.tar","bbb/layer.tar"]},`+
+		`{"Config":%q,"RepoTags":["app:2.0"],"Layers":["ccc/layer.tar","ddd/layer.tar","eee/layer.tar"]}]`, config1Name, config2Name)))
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return layers
+}
+
+func readBlob(t *testing.T, c domain.ContainerClient, repo, digest string) string {
+	rc, err := c.(domain.LayerBlobClient).FetchLayerBlob(repo, digest)
+	if err != nil {
+		t.Fatalf("FetchLayerBlob(%v, %v) error: %v", repo, digest, err)
+	}
+	defer rc.Close()
+	bs, _ := ioutil.ReadAll(rc)
+	return string(bs)
+}
+
+func TestLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocilayout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	layout := filepath.Join(dir, "build")
+	layer := writeLayout(t, layout)
+
+	c, err := NewLayoutClient([]string{layout})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos, _ := c.FetchAllRepositories()
+	if len(repos) != 1 || repos[0].Name != "build" {
+		t.Fatalf("repositories = %v; want = [build]", repos)
+	}
+	imgs, err := c.FetchAllImages("build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imgs) != 2 {
+		t.Fatalf("len(images) = %v; want = %v", len(imgs), 2)
+	}
+	if want := []string{"v1", "latest"}; !reflect.DeepEqual(imgs[0].Tags, want) {
+		t.Errorf("tags = %v; want = %v", imgs[0].Tags, want)
+	}
+	if imgs[0].SizeByte != int64(len(layer)) || !imgs[0].PushedAt.Equal(testCreated) {
+		t.Errorf("image = %v bytes at %v; want = %v bytes at %v", imgs[0].SizeByte, imgs[0].PushedAt, len(layer), testCreated)
+	}
+	m, err := c.(domain.ImageManifestClient).FetchImageManifest("build", imgs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Layers) != 1 || m.Config == nil || len(m.Config.History) != 1 {
+		t.Fatalf("manifest of the index = %v layers, config %v", len(m.Layers), m.Config)
+	}
+	if got := readBlob(t, c, "build", m.Layers[0].Digest); got != string(layer) {
+		t.Errorf("layer = %q; want = %q", got, layer)
+	}
+}
+
+func TestDockerArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocilayout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "app.tar")
+	layers := writeDockerArchive(t, file)
+
+	c, err := NewLayoutClient([]string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	imgs, err := c.FetchAllImages("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imgs) != 2 {
+		t.Fatalf("len(images) = %v; want = %v", len(imgs), 2)
+	}
+	if want := []string{"app:1.0", "app:latest"}; !reflect.DeepEqual(imgs[0].Tags, want) {
+		t.Errorf("tags = %v; want = %v", imgs[0].Tags, want)
+	}
+	if want := int64(len(layers[0]) + len(layers[1])); imgs[0].SizeByte != want {
+		t.Errorf("SizeByte = %v; want = %v", imgs[0].SizeByte, want)
+	}
+	if want := int64(len(layers[0]) + len(layers[1]) + len(layers[2])); imgs[1].SizeByte != want {
+		t.Errorf("SizeByte of the image sharing layers = %v; want = %v", imgs[1].SizeByte, want)
+	}
+	for _, img := range imgs {
+		m, err := c.(domain.ImageManifestClient).FetchImageManifest("app", img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, l := range m.Layers {
+			if l.Digest != digestOf(layers[i]) {
+				t.Errorf("%v layer %d digest = %v; want = %v", img.Tags, i, l.Digest, digestOf(layers[i]))
+			}
+			if got := readBlob(t, c, "app", l.Digest); got != string(layers[i]) {
+				t.Errorf("%v layer %d = %q; want = %q", img.Tags, i, got, layers[i])
+			}
+		}
+	}
+}
+
+func TestNewLayoutClientInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ocilayout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if _, err := NewLayoutClient([]string{dir}); err == nil {
+		t.Errorf("NewLayoutClient(empty dir) succeeded")
+	}
+	if _, err := NewLayoutClient([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Errorf("NewLayoutClient(missing) succeeded")
+	}
+}
@@ -0,0 +1,162 @@
+package ocilayout
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// store reads the files of a layout directory or an archive by their slash separated names.
+type store interface {
+	exists(name string) bool
+	size(name string) int64
+	open(name string) (io.ReadCloser, error)
+}
+
+func readFile(s store, name string) ([]byte, error) {
+	rc, err := s.open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+type dirStore struct {
+	root string
+}
+
+func (s *dirStore) path(name string) string {
+	return filepath.Join(s.root, filepath.FromSlash(cleanName(name)))
+}
+
+func (s *dirStore) exists(name string) bool {
+	_, err := os.Stat(s.path(name))
+	return err == nil
+}
+
+func (s *dirStore) size(name string) int64 {
+	fi, err := os.Stat(s.path(name))
+	if err != nil {
+		return 0
+	}
+	return fi.Size()
+}
+
+func (s *dirStore) open(name string) (io.ReadCloser, error) {
+	return os.Open(s.path(name))
+}
+
+// maxLinkDepth bounds the links followed to a file, against link cycles.
+const maxLinkDepth = 8
+
+// tarStore reads an archive written by `docker save` (or a tarred layout).
+// Tar files cannot be read at random, so each open scans the archive up to the file.
+// The legacy format writes a layer shared with an earlier image as a symlink
+// (e.g. ../<id>/layer.tar), so links are followed to the file they point at.
+type tarStore struct {
+	file  string
+	sizes map[string]int64
+	links map[string]string // member name -> name of the member it links to
+}
+
+func newTarStore(file string) (*tarStore, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s := &tarStore{file: file, sizes: make(map[string]int64), links: make(map[string]string)}
+	tr := tar.NewReader(f)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return s, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		name := cleanName(h.Name)
+		switch h.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+			s.sizes[name] = h.Size
+		case tar.TypeSymlink:
+			// relative to the directory of the member, like on a filesystem
+			if path.IsAbs(h.Linkname) {
+				s.links[name] = cleanName(h.Linkname)
+			} else {
+				s.links[name] = cleanName(path.Join(path.Dir(name), h.Linkname))
+			}
+		case tar.TypeLink:
+			// hard links name the member from the root of the archive
+			s.links[name] = cleanName(h.Linkname)
+		}
+	}
+}
+
+// resolve follows the links from name to the regular file it stands for.
+func (s *tarStore) resolve(name string) string {
+	name = cleanName(name)
+	for i := 0; i < maxLinkDepth; i++ {
+		target, ok := s.links[name]
+		if !ok {
+			break
+		}
+		name = target
+	}
+	return name
+}
+
+func (s *tarStore) exists(name string) bool {
+	_, ok := s.sizes[s.resolve(name)]
+	return ok
+}
+
+func (s *tarStore) size(name string) int64 {
+	return s.sizes[s.resolve(name)]
+}
+
+func (s *tarStore) open(name string) (io.ReadCloser, error) {
+	name = s.resolve(name)
+	if !s.exists(name) {
+		return nil, fmt.Errorf("%s: %s not found", s.file, name)
+	}
+	f, err := os.Open(s.file)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(f)
+	for {
+		h, err := tr.Next()
+		if err != nil {
+			f.Close()
+			if err == io.EOF {
+				err = fmt.Errorf("%s: %s not found", s.file, name)
+			}
+			return nil, err
+		}
+		if cleanName(h.Name) == name && (h.Typeflag == tar.TypeReg || h.Typeflag == tar.TypeRegA) {
+			return &tarFile{tr, f}, nil
+		}
+	}
+}
+
+// tarFile reads a file in an archive and closes the archive.
+type tarFile struct {
+	io.Reader
+	f *os.File
+}
+
+func (f *tarFile) Close() error {
+	return f.f.Close()
+}
+
+// cleanName drops the "./" some tools write in front of archive members.
+func cleanName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
@@ -0,0 +1,164 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// authenticator answers the challenges of a registry with basic auth or bearer tokens.
+// Tokens are kept per scope, so a token for one repository is not sent for another.
+type authenticator struct {
+	client   *http.Client
+	username string
+	password string
+
+	mu     sync.Mutex
+	basic  bool
+	tokens map[string]string
+}
+
+func newAuthenticator(client *http.Client, username, password string) *authenticator {
+	return &authenticator{
+		client:   client,
+		username: username,
+		password: password,
+		tokens:   make(map[string]string),
+	}
+}
+
+// authorize sets the credentials a previous challenge asked for.
+func (a *authenticator) authorize(req *http.Request, scope string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if token, ok := a.tokens[scope]; ok {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if a.basic {
+		req.SetBasicAuth(a.username, a.password)
+	}
+}
+
+// challenge prepares the credentials asked for by the WWW-Authenticate header of a 401 response.
+func (a *authenticator) challenge(resp *http.Response, scope string) error {
+	scheme, params := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if a.username == "" {
+			return fmt.Errorf("registry requires a user name and password")
+		}
+		a.mu.Lock()
+		a.basic = true
+		a.mu.Unlock()
+		return nil
+	case "bearer":
+		// the token is asked for with the scope the registry wants, which may differ
+		// from ours, but kept under ours so that authorize finds it on the retry
+		requested := scope
+		if s, ok := params["scope"]; ok {
+			requested = s
+		}
+		token, err := a.fetchToken(params["realm"], params["service"], requested)
+		if err != nil {
+			return err
+		}
+		a.mu.Lock()
+		a.tokens[scope] = token
+		a.mu.Unlock()
+		return nil
+	default:
+		return fmt.Errorf("unsupported registry authentication: %q", resp.Header.Get("WWW-Authenticate"))
+	}
+}
+
+// fetchToken gets a bearer token from the token service, as the user if credentials are set.
+func (a *authenticator) fetchToken(realm, service, scope string) (string, error) {
+	if realm == "" {
+		return "", fmt.Errorf("bearer challenge without realm")
+	}
+	u, err := url.Parse(realm)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	if service != "" {
+		q.Set("service", service)
+	}
+	if scope != "" {
+		q.Set("scope", scope)
+	}
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if a.username != "" {
+		req.SetBasicAuth(a.username, a.password)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request to %s: %s", u.Host, resp.Status)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("token response: %v", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("token response without token")
+}
+
+// parseChallenge splits `Bearer realm="https://auth",service="registry"` into the scheme and its parameters.
+func parseChallenge(h string) (string, map[string]string) {
+	h = strings.TrimSpace(h)
+	i := strings.IndexByte(h, ' ')
+	if i < 0 {
+		return h, nil
+	}
+	scheme, rest := h[:i], h[i+1:]
+	params := make(map[string]string)
+	for {
+		rest = strings.TrimLeft(rest, " ,")
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			return scheme, params
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = rest[eq+1:]
+		var val strings.Builder
+		if strings.HasPrefix(rest, `"`) {
+			j := 1
+			for ; j < len(rest) && rest[j] != '"'; j++ {
+				if rest[j] == '\\' && j+1 < len(rest) {
+					j++
+				}
+				val.WriteByte(rest[j])
+			}
+			if j < len(rest) {
+				j++
+			}
+			rest = rest[j:]
+		} else {
+			j := strings.IndexByte(rest, ',')
+			if j < 0 {
+				j = len(rest)
+			}
+			val.WriteString(strings.TrimSpace(rest[:j]))
+			rest = rest[j:]
+		}
+		params[key] = val.String()
+	}
+}
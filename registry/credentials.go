@@ -0,0 +1,64 @@
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// dockerConfig is the part of ~/.docker/config.json written by `docker login`.
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth string `json:"auth"`
+	} `json:"auths"`
+}
+
+// DockerCredentials returns the user name and password `docker login` stored for host,
+// or empty strings if there are none. Credential helpers are not supported.
+func DockerCredentials(host string) (string, string) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", ""
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	bs, err := ioutil.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return "", ""
+	}
+	return parseDockerCredentials(bs, host)
+}
+
+func parseDockerCredentials(bs []byte, host string) (string, string) {
+	var cfg dockerConfig
+	if err := json.Unmarshal(bs, &cfg); err != nil {
+		return "", ""
+	}
+	for key, a := range cfg.Auths {
+		if registryHost(key) != host {
+			continue
+		}
+		bs, err := base64.StdEncoding.DecodeString(a.Auth)
+		if err != nil {
+			continue
+		}
+		if i := strings.IndexByte(string(bs), ':'); i >= 0 {
+			return string(bs[:i]), string(bs[i+1:])
+		}
+	}
+	return "", ""
+}
+
+// registryHost strips the scheme and path `docker login` may keep in the keys of auths.
+func registryHost(key string) string {
+	key = strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://")
+	if i := strings.IndexByte(key, '/'); i >= 0 {
+		key = key[:i]
+	}
+	return key
+}
@@ -0,0 +1,201 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/oci"
+)
+
+const (
+	digestHeader = "Docker-Content-Digest"
+	deleteScope  = "repository:%s:delete"
+)
+
+// headManifest resolves a tag to the digest and media type of its manifest.
+// Registries that omit the digest header are asked for the manifest itself.
+func (c *registryClient) headManifest(repo, ref string) (string, string, error) {
+	resp, err := c.do(http.MethodHead, fmt.Sprintf("/v2/%s/manifests/%s", repo, ref), pullScope(repo), oci.ManifestMediaTypes)
+	if err != nil {
+		return "", "", err
+	}
+	if err := checkStatus(resp); err != nil {
+		return "", "", err
+	}
+	resp.Body.Close()
+	if d := resp.Header.Get(digestHeader); d != "" {
+		return d, contentType(resp), nil
+	}
+	d, mt, _, err := c.getManifest(repo, ref)
+	return d, mt, err
+}
+
+// getManifest reads the manifest ref (a tag or digest) and returns its digest, media type and body.
+func (c *registryClient) getManifest(repo, ref string) (string, string, []byte, error) {
+	resp, err := c.do(http.MethodGet, fmt.Sprintf("/v2/%s/manifests/%s", repo, ref), pullScope(repo), oci.ManifestMediaTypes)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if err := checkStatus(resp); err != nil {
+		return "", "", nil, err
+	}
+	defer resp.Body.Close()
+	bs, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", nil, err
+	}
+	d := resp.Header.Get(digestHeader)
+	if d == "" {
+		d = fmt.Sprintf("sha256:%x", sha256.Sum256(bs))
+	}
+	mt := contentType(resp)
+	if mt == "" {
+		if mt, err = oci.MediaType(bs); err != nil {
+			return "", "", nil, err
+		}
+	}
+	return d, mt, bs, nil
+}
+
+func (c *registryClient) FetchIndexChildren(repo string, imgs []*domain.Image) ([]string, error) {
+	var ret []string
+	for _, img := range imgs {
+		if !oci.IsIndex(img.ManifestMediaType) {
+			continue
+		}
+		_, _, bs, err := c.getManifest(repo, img.Digest)
+		if err != nil {
+			return nil, err
+		}
+		children, err := oci.IndexManifests(bs)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, children...)
+	}
+	return ret, nil
+}
+
+func contentType(resp *http.Response) string {
+	mt, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mt
+}
+
+func (c *registryClient) FetchImageManifest(repo string, img *domain.Image) (*domain.Manifest, error) {
+	c.mu.Lock()
+	m, ok := c.manifests[img.Digest]
+	c.mu.Unlock()
+	if ok {
+		return m, nil
+	}
+	_, mt, bs, err := c.getManifest(repo, img.Digest)
+	if err != nil {
+		return nil, err
+	}
+	digest := img.Digest
+	if oci.IsIndex(mt) {
+		if digest, err = oci.SelectPlatform(bs); err != nil {
+			return nil, err
+		}
+		if _, _, bs, err = c.getManifest(repo, digest); err != nil {
+			return nil, err
+		}
+	}
+	if m, err = oci.ParseManifest(digest, bs); err != nil {
+		return nil, err
+	}
+	if m.ConfigDigest != "" {
+		bs, err := c.downloadBlob(repo, m.ConfigDigest)
+		if err != nil {
+			return nil, err
+		}
+		if m.Config, err = oci.ParseConfig(bs); err != nil {
+			return nil, err
+		}
+	}
+	c.mu.Lock()
+	c.manifests[img.Digest] = m
+	c.mu.Unlock()
+	return m, nil
+}
+
+// FetchImageManifests reads the manifests one by one, fetchWorkers at a time, since the API has no batch read.
+func (c *registryClient) FetchImageManifests(repo string, imgs []*domain.Image) ([]*domain.Manifest, error) {
+	ret := make([]*domain.Manifest, len(imgs))
+	parallel(len(imgs), func(i int) error {
+		ret[i], _ = c.FetchImageManifest(repo, imgs[i])
+		return nil
+	})
+	return ret, nil
+}
+
+func (c *registryClient) FetchLayerBlob(repo, digest string) (io.ReadCloser, error) {
+	return c.openBlob(repo, digest)
+}
+
+func (c *registryClient) downloadBlob(repo, digest string) ([]byte, error) {
+	rc, err := c.openBlob(repo, digest)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+// openBlob streams a blob, following the redirect to storage that some registries answer with.
+func (c *registryClient) openBlob(repo, digest string) (io.ReadCloser, error) {
+	resp, err := c.do(http.MethodGet, fmt.Sprintf("/v2/%s/blobs/%s", repo, digest), pullScope(repo), nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// DeleteImages deletes the manifests of imgs, which removes all their tags.
+// registry:2 only allows this with REGISTRY_STORAGE_DELETE_ENABLED.
+func (c *registryClient) DeleteImages(repo string, imgs []*domain.Image) ([]*domain.ImageDeleteFailure, error) {
+	var failures []*domain.ImageDeleteFailure
+	deleted := make(map[*domain.Image]bool, len(imgs))
+	for _, img := range imgs {
+		if err := c.deleteManifest(repo, img.Digest); err != nil {
+			failures = append(failures, &domain.ImageDeleteFailure{Image: img, Reason: err.Error()})
+			continue
+		}
+		deleted[img] = true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cache, ok := c.images[repo]; ok {
+		ret := make([]*domain.Image, 0, len(cache))
+		for _, img := range cache {
+			if !deleted[img] {
+				ret = append(ret, img)
+			}
+		}
+		c.images[repo] = ret
+	}
+	return failures, nil
+}
+
+func (c *registryClient) deleteManifest(repo, digest string) error {
+	resp, err := c.do(http.MethodDelete, fmt.Sprintf("/v2/%s/manifests/%s", repo, digest), fmt.Sprintf(deleteScope, repo), nil)
+	if err != nil {
+		return err
+	}
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,284 @@
+// Package registry browses registries implementing the Docker Registry HTTP API v2
+// (OCI Distribution), such as registry:2, Harbor and Artifactory.
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+const (
+	pageSize     = 100
+	fetchWorkers = 8
+	catalogScope = "registry:catalog:*"
+)
+
+var linkNextPattern = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+
+type registryClient struct {
+	base *url.URL
+	http *http.Client
+	auth *authenticator
+
+	mu        sync.Mutex
+	repos     []*domain.Repository
+	images    map[string][]*domain.Image
+	manifests map[string]*domain.Manifest // by digest, with configs
+}
+
+// NewRegistryClient returns a client of the registry at endpoint, such as "https://registry.example.com"
+// or "localhost:5000" (https unless the scheme is given).
+// Without a user name, the credentials of `docker login` for the host are used if any.
+func NewRegistryClient(endpoint, username, password string) (domain.ContainerClient, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if base.Host == "" {
+		return nil, fmt.Errorf("invalid registry: %s", endpoint)
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/")
+	if username == "" {
+		username, password = DockerCredentials(base.Host)
+	}
+	cli := &http.Client{}
+	return &registryClient{
+		base:      base,
+		http:      cli,
+		auth:      newAuthenticator(cli, username, password),
+		images:    make(map[string][]*domain.Image),
+		manifests: make(map[string]*domain.Manifest),
+	}, nil
+}
+
+func pullScope(repo string) string {
+	return fmt.Sprintf("repository:%s:pull", repo)
+}
+
+// do sends a request to path (under the registry endpoint, or an absolute URL),
+// authenticating and retrying once if the registry challenges it.
+func (c *registryClient) do(method, path, scope string, accept []string) (*http.Response, error) {
+	target := path
+	if !strings.Contains(path, "://") {
+		target = c.base.Scheme + "://" + c.base.Host + c.base.Path + path
+	}
+	for retried := false; ; retried = true {
+		req, err := http.NewRequest(method, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(accept) > 0 {
+			req.Header.Set("Accept", strings.Join(accept, ", "))
+		}
+		c.auth.authorize(req, scope)
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusUnauthorized || retried {
+			return resp, nil
+		}
+		resp.Body.Close()
+		if err := c.auth.challenge(resp, scope); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// checkStatus returns the error the registry reported if resp is not 2xx, closing its body.
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	bs, _ := ioutil.ReadAll(resp.Body)
+	if err := json.Unmarshal(bs, &body); err == nil && len(body.Errors) > 0 {
+		return fmt.Errorf("%s: %s", body.Errors[0].Code, body.Errors[0].Message)
+	}
+	return fmt.Errorf("%s %s: %s", resp.Request.Method, resp.Request.URL.Path, resp.Status)
+}
+
+// getJSON decodes the page at path into v and returns the URL of the next page, if any.
+func (c *registryClient) getJSON(path, scope string, v interface{}) (string, error) {
+	resp, err := c.do(http.MethodGet, path, scope, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := checkStatus(resp); err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return "", err
+	}
+	if m := linkNextPattern.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+		next, err := resp.Request.URL.Parse(m[1])
+		if err != nil {
+			return "", err
+		}
+		return next.String(), nil
+	}
+	return "", nil
+}
+
+func (c *registryClient) FetchAllRepositories() ([]*domain.Repository, error) {
+	c.mu.Lock()
+	cache := c.repos
+	c.mu.Unlock()
+	if cache != nil {
+		return cache, nil
+	}
+	var names []string
+	for next := fmt.Sprintf("/v2/_catalog?n=%d", pageSize); next != ""; {
+		var page struct {
+			Repositories []string `json:"repositories"`
+		}
+		var err error
+		if next, err = c.getJSON(next, catalogScope, &page); err != nil {
+			return nil, err
+		}
+		names = append(names, page.Repositories...)
+	}
+	sort.Strings(names)
+	ret := make([]*domain.Repository, len(names))
+	for i, n := range names {
+		ret[i] = domain.NewRepository(n, c.base.Host+c.base.Path+"/"+n, "", "", "", false, "", "", time.Time{})
+	}
+	c.mu.Lock()
+	c.repos = ret
+	c.mu.Unlock()
+	return ret, nil
+}
+
+// RepositoryConsoleURL returns the Docker Hub page of the repository.
+// Repositories of other registries have no web page known to the API.
+func (c *registryClient) RepositoryConsoleURL(repo *domain.Repository) string {
+	switch c.base.Host {
+	case "registry-1.docker.io", "index.docker.io", "docker.io":
+	default:
+		return ""
+	}
+	if name := strings.TrimPrefix(repo.Name, "library/"); name != repo.Name {
+		return "https://hub.docker.com/_/" + name
+	}
+	return "https://hub.docker.com/r/" + repo.Name
+}
+
+// FetchAllImages lists the tagged images of repo; the API cannot list untagged manifests.
+// Tags are resolved to digests with HEAD requests, and each distinct manifest and its config
+// are read once for the size and the creation time of the image.
+func (c *registryClient) FetchAllImages(repo string) ([]*domain.Image, error) {
+	c.mu.Lock()
+	cache, ok := c.images[repo]
+	c.mu.Unlock()
+	if ok {
+		return cache, nil
+	}
+	tags, err := c.fetchTags(repo)
+	if err != nil {
+		return nil, err
+	}
+	digests := make([]string, len(tags))
+	mediaTypes := make([]string, len(tags))
+	err = parallel(len(tags), func(i int) error {
+		var err error
+		digests[i], mediaTypes[i], err = c.headManifest(repo, tags[i])
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	var ret []*domain.Image
+	byDigest := make(map[string]*domain.Image)
+	for i, d := range digests {
+		if img, ok := byDigest[d]; ok {
+			img.Tags = append(img.Tags, tags[i])
+			continue
+		}
+		img := domain.NewImage([]string{tags[i]}, time.Time{}, d, 0, "", time.Time{}, mediaTypes[i], "")
+		byDigest[d] = img
+		ret = append(ret, img)
+	}
+	// an unreadable manifest leaves the image without size and time rather than hiding the repository
+	parallel(len(ret), func(i int) error {
+		m, err := c.FetchImageManifest(repo, ret[i])
+		if err != nil {
+			return nil
+		}
+		ret[i].SizeByte = m.TotalSize()
+		if m.Config != nil {
+			ret[i].PushedAt = m.Config.CreatedAt
+		}
+		return nil
+	})
+	c.mu.Lock()
+	c.images[repo] = ret
+	c.mu.Unlock()
+	return ret, nil
+}
+
+func (c *registryClient) fetchTags(repo string) ([]string, error) {
+	var tags []string
+	for next := fmt.Sprintf("/v2/%s/tags/list?n=%d", repo, pageSize); next != ""; {
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		var err error
+		if next, err = c.getJSON(next, pullScope(repo), &page); err != nil {
+			return nil, err
+		}
+		tags = append(tags, page.Tags...)
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// parallel calls f for 0 to n-1 with up to fetchWorkers at once and returns the first error.
+func parallel(n int, f func(i int) error) error {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		first error
+	)
+	indexes := make(chan int)
+	for w := 0; w < fetchWorkers && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := f(i); err != nil {
+					mu.Lock()
+					if first == nil {
+						first = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return first
+}
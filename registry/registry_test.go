@@ -0,0 +1,396 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/oci"
+)
+
+const (
+	testUser     = "user"
+	testPassword = "secret"
+)
+
+type blob struct {
+	mediaType string
+	body      []byte
+}
+
+// fakeRegistry serves the parts of the registry:2 API the client uses from memory.
+// With a realm, it requires bearer tokens issued by its /token endpoint; otherwise basic auth.
+type fakeRegistry struct {
+	*httptest.Server
+	token     bool
+	tags      map[string]map[string]string // repo -> tag -> digest
+	manifests map[string]*blob
+	blobs     map[string][]byte
+	deleted   []string
+}
+
+func digestOf(bs []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(bs))
+}
+
+func newFakeRegistry(token bool) *fakeRegistry {
+	r := &fakeRegistry{
+		token:     token,
+		tags:      make(map[string]map[string]string),
+		manifests: make(map[string]*blob),
+		blobs:     make(map[string][]byte),
+	}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serve))
+	return r
+}
+
+// push stores an image whose layers have the given sizes, created at created, under tags.
+func (r *fakeRegistry) push(repo string, created time.Time, sizes []int64, tags ...string) string {
+	config := []byte(fmt.Sprintf(`{"architecture":"amd64","os":"linux","created":%q}`, created.Format(time.RFC3339)))
+	r.blobs[digestOf(config)] = config
+	var layers []string
+	for i, s := range sizes {
+		layers = append(layers, fmt.Sprintf(`{"mediaType":"application/vnd.oci.image.layer.v1.tar+gzip","digest":"sha256:%s-%d","size":%d}`, repo, i, s))
+	}
+	m := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":%q,"config":{"digest":%q,"size":%d},"layers":[%s]}`,
+		oci.MediaTypeOCIManifest, digestOf(config), len(config), strings.Join(layers, ",")))
+	return r.pushManifest(repo, oci.MediaTypeOCIManifest, m, tags...)
+}
+
+// pushIndex stores an index of an arm64 and an amd64 manifest under tags.
+func (r *fakeRegistry) pushIndex(repo, arm, amd string, tags ...string) string {
+	m := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":%q,"manifests":[{"digest":%q,"platform":{"architecture":"arm64","os":"linux"}},{"digest":%q,"platform":{"architecture":"amd64","os":"linux"}}]}`,
+		oci.MediaTypeOCIIndex, arm, amd))
+	return r.pushManifest(repo, oci.MediaTypeOCIIndex, m, tags...)
+}
+
+func (r *fakeRegistry) pushManifest(repo, mediaType string, m []byte, tags ...string) string {
+	d := digestOf(m)
+	r.manifests[d] = &blob{mediaType, m}
+	if r.tags[repo] == nil {
+		r.tags[repo] = make(map[string]string)
+	}
+	for _, t := range tags {
+		r.tags[repo][t] = d
+	}
+	return d
+}
+
+func (r *fakeRegistry) serve(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		r.serveToken(w, req)
+		return
+	}
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	scope := "registry:catalog:*"
+	if path != "_catalog" {
+		repo := path[:strings.LastIndex(path[:strings.LastIndex(path, "/")], "/")]
+		scope = "repository:" + repo + ":pull"
+		if req.Method == http.MethodDelete {
+			// like distribution, challenge with more actions than the client asks for
+			scope = "repository:" + repo + ":delete,pull"
+		}
+	}
+	if !r.authorized(req, scope) {
+		if r.token {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake",scope=%q`, r.URL, scope))
+		} else {
+			w.Header().Set("WWW-Authenticate", `Basic realm="fake"`)
+		}
+		http.Error(w, `{"errors":[{"code":"UNAUTHORIZED","message":"authentication required"}]}`, http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case path == "_catalog":
+		r.serveCatalog(w, req)
+	case strings.HasSuffix(path, "/tags/list"):
+		r.serveTags(w, req, strings.TrimSuffix(path, "/tags/list"))
+	case strings.Contains(path, "/manifests/"):
+		i := strings.LastIndex(path, "/manifests/")
+		r.serveManifest(w, req, path[:i], path[i+len("/manifests/"):])
+	case strings.Contains(path, "/blobs/"):
+		bs, ok := r.blobs[path[strings.LastIndex(path, "/")+1:]]
+		if !ok {
+			http.Error(w, `{"errors":[{"code":"BLOB_UNKNOWN","message":"blob unknown to registry"}]}`, http.StatusNotFound)
+			return
+		}
+		w.Write(bs)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (r *fakeRegistry) serveToken(w http.ResponseWriter, req *http.Request) {
+	if u, p, ok := req.BasicAuth(); !ok || u != testUser || p != testPassword {
+		http.Error(w, "", http.StatusUnauthorized)
+		return
+	}
+	fmt.Fprintf(w, `{"token":%q}`, "token-"+req.URL.Query().Get("scope"))
+}
+
+func (r *fakeRegistry) authorized(req *http.Request, scope string) bool {
+	if r.token {
+		return req.Header.Get("Authorization") == "Bearer token-"+scope
+	}
+	u, p, ok := req.BasicAuth()
+	return ok && u == testUser && p == testPassword
+}
+
+// serveCatalog returns one repository per page, linking to the next.
+func (r *fakeRegistry) serveCatalog(w http.ResponseWriter, req *http.Request) {
+	var repos []string
+	for repo := range r.tags {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	last := req.URL.Query().Get("last")
+	i := sort.SearchStrings(repos, last)
+	if last != "" {
+		i++
+	}
+	if i+1 < len(repos) {
+		w.Header().Set("Link", fmt.Sprintf(`</v2/_catalog?last=%s&n=1>; rel="next"`, repos[i]))
+	}
+	fmt.Fprintf(w, `{"repositories":[%q]}`, repos[i])
+}
+
+func (r *fakeRegistry) serveTags(w http.ResponseWriter, req *http.Request, repo string) {
+	var tags []string
+	for t := range r.tags[repo] {
+		tags = append(tags, fmt.Sprintf("%q", t))
+	}
+	fmt.Fprintf(w, `{"name":%q,"tags":[%s]}`, repo, strings.Join(tags, ","))
+}
+
+func (r *fakeRegistry) serveManifest(w http.ResponseWriter, req *http.Request, repo, ref string) {
+	d := ref
+	if t, ok := r.tags[repo][ref]; ok {
+		d = t
+	}
+	m, ok := r.manifests[d]
+	if !ok {
+		http.Error(w, `{"errors":[{"code":"MANIFEST_UNKNOWN","message":"manifest unknown"}]}`, http.StatusNotFound)
+		return
+	}
+	if req.Method == http.MethodDelete {
+		r.deleted = append(r.deleted, d)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", m.mediaType)
+	w.Header().Set(digestHeader, d)
+	if req.Method == http.MethodGet {
+		w.Write(m.body)
+	}
+}
+
+func testRegistry(token bool) (*fakeRegistry, *registryClient) {
+	r := newFakeRegistry(token)
+	created := time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC)
+	r.push("app", created, []int64{100, 50}, "v1", "latest")
+	r.push("app", created.AddDate(0, 0, 1), []int64{100, 70}, "v2")
+	arm := r.push("tool", created, []int64{10}, "arm")
+	amd := r.push("tool", created.AddDate(0, 0, 2), []int64{20, 30})
+	r.pushIndex("tool", arm, amd, "multi")
+	r.push("web", created, []int64{1})
+	c, _ := NewRegistryClient(r.URL, testUser, testPassword)
+	return r, c.(*registryClient)
+}
+
+func TestFetch(t *testing.T) {
+	for _, token := range []bool{true, false} {
+		r, c := testRegistry(token)
+
+		repos, err := c.FetchAllRepositories()
+		if err != nil {
+			t.Fatalf("FetchAllRepositories() (token: %v) error: %v", token, err)
+		}
+		var names []string
+		for _, repo := range repos {
+			names = append(names, repo.Name)
+		}
+		if want := []string{"app", "tool", "web"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("repositories (token: %v) = %v; want = %v", token, names, want)
+		}
+		if want := strings.TrimPrefix(r.URL, "http://") + "/app"; repos[0].Uri != want {
+			t.Errorf("Uri = %v; want = %v", repos[0].Uri, want)
+		}
+
+		imgs, err := c.FetchAllImages("app")
+		if err != nil {
+			t.Fatalf("FetchAllImages() (token: %v) error: %v", token, err)
+		}
+		if len(imgs) != 2 {
+			t.Fatalf("len(images) = %v; want = %v", len(imgs), 2)
+		}
+		byTag := make(map[string]*domain.Image)
+		for _, img := range imgs {
+			for _, tag := range img.Tags {
+				byTag[tag] = img
+			}
+		}
+		if got := byTag["v1"].Tags; !reflect.DeepEqual(got, []string{"latest", "v1"}) {
+			t.Errorf("tags = %v; want = %v", got, []string{"latest", "v1"})
+		}
+		if got := byTag["v2"].SizeByte; got != 170 {
+			t.Errorf("SizeByte = %v; want = %v", got, 170)
+		}
+		if got, want := byTag["v2"].PushedAt, time.Date(2020, 8, 2, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+			t.Errorf("PushedAt = %v; want = %v", got, want)
+		}
+		r.Close()
+	}
+}
+
+func TestFetchIndex(t *testing.T) {
+	r, c := testRegistry(true)
+	defer r.Close()
+	imgs, err := c.FetchAllImages("tool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imgs) != 2 {
+		t.Fatalf("len(images) = %v; want = %v", len(imgs), 2)
+	}
+	for _, img := range imgs {
+		if img.Tags[0] != "multi" {
+			continue
+		}
+		if img.ManifestMediaType != oci.MediaTypeOCIIndex {
+			t.Errorf("ManifestMediaType = %v; want = %v", img.ManifestMediaType, oci.MediaTypeOCIIndex)
+		}
+		m, err := c.FetchImageManifest("tool", img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(m.Layers) != 2 || img.SizeByte != 50 {
+			t.Errorf("amd64 manifest: %v layers, size %v; want = 2 layers, size 50", len(m.Layers), img.SizeByte)
+		}
+	}
+}
+
+func TestFetchIndexChildren(t *testing.T) {
+	r, c := testRegistry(true)
+	defer r.Close()
+	imgs, err := c.FetchAllImages("tool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.FetchIndexChildren("tool", imgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var arm string
+	for _, img := range imgs {
+		if img.Tags[0] == "arm" {
+			arm = img.Digest
+		}
+	}
+	if len(got) != 2 || got[0] != arm {
+		t.Errorf("FetchIndexChildren() = %v; want = [%v <amd64>]", got, arm)
+	}
+}
+
+func TestFetchLayerBlob(t *testing.T) {
+	r, c := testRegistry(true)
+	defer r.Close()
+	r.blobs["sha256:layer"] = []byte("layer content")
+	rc, err := c.FetchLayerBlob("app", "sha256:layer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	if bs, _ := ioutil.ReadAll(rc); string(bs) != "layer content" {
+		t.Errorf("blob = %q; want = %q", bs, "layer content")
+	}
+	if _, err := c.FetchLayerBlob("app", "sha256:missing"); err == nil || !strings.Contains(err.Error(), "BLOB_UNKNOWN") {
+		t.Errorf("FetchLayerBlob(missing) error = %v; want = BLOB_UNKNOWN", err)
+	}
+}
+
+func TestDeleteImages(t *testing.T) {
+	r, c := testRegistry(true)
+	defer r.Close()
+	imgs, err := c.FetchAllImages("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	failures, err := c.DeleteImages("app", imgs[:1])
+	if err != nil || len(failures) > 0 {
+		t.Fatalf("DeleteImages() = %v, %v", failures, err)
+	}
+	if !reflect.DeepEqual(r.deleted, []string{imgs[0].Digest}) {
+		t.Errorf("deleted = %v; want = %v", r.deleted, []string{imgs[0].Digest})
+	}
+	if cached, _ := c.FetchAllImages("app"); len(cached) != 1 {
+		t.Errorf("len(cached images) = %v; want = %v", len(cached), 1)
+	}
+}
+
+func TestUnauthorized(t *testing.T) {
+	r := newFakeRegistry(true)
+	defer r.Close()
+	r.push("app", time.Now(), []int64{1}, "v1")
+	c, _ := NewRegistryClient(r.URL, testUser, "wrong")
+	if _, err := c.FetchAllRepositories(); err == nil {
+		t.Errorf("FetchAllRepositories() with a wrong password succeeded")
+	}
+}
+
+func TestRepositoryConsoleURL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		repo     string
+		want     string
+	}{
+		{"registry-1.docker.io", "library/nginx", "https://hub.docker.com/_/nginx"},
+		{"https://index.docker.io", "grafana/grafana", "https://hub.docker.com/r/grafana/grafana"},
+		{"localhost:5000", "app", ""},
+	}
+	for _, test := range tests {
+		c, err := NewRegistryClient(test.endpoint, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		repo := domain.NewRepository(test.repo, "", "", "", "", false, "", "", time.Time{})
+		got := c.(domain.ConsoleClient).RepositoryConsoleURL(repo)
+		if got != test.want {
+			t.Errorf("RepositoryConsoleURL(%s) on %s = %v; want = %v", test.repo, test.endpoint, got, test.want)
+		}
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:a/b:pull,push"`)
+	if scheme != "Bearer" {
+		t.Errorf("scheme = %v; want = %v", scheme, "Bearer")
+	}
+	want := map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry.example.com",
+		"scope":   "repository:a/b:pull,push",
+	}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("params = %v; want = %v", params, want)
+	}
+}
+
+func TestParseDockerCredentials(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("alice:pa:ss"))
+	bs := []byte(`{"auths": {"https://registry.example.com/v1/": {"auth": "` + auth + `"}}}`)
+	if u, p := parseDockerCredentials(bs, "registry.example.com"); u != "alice" || p != "pa:ss" {
+		t.Errorf("parseDockerCredentials() = %v, %v; want = alice, pa:ss", u, p)
+	}
+	if u, p := parseDockerCredentials(bs, "other.example.com"); u != "" || p != "" {
+		t.Errorf("parseDockerCredentials(other) = %v, %v; want = empty", u, p)
+	}
+}
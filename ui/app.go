@@ -2,10 +2,10 @@ package ui
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/eihigh/goban"
-	"github.com/gdamore/tcell"
 	"github.com/lusingander/ecr-browser/domain"
 	"github.com/mattn/go-runewidth"
 )
@@ -17,6 +17,14 @@ const (
 
 var (
 	client domain.ContainerClient
+
+	// current is kept across suspends so the app resumes where it left off.
+	current *ui
+)
+
+var (
+	// errSuspend is returned by app to leave the screen while an external command runs.
+	errSuspend = errors.New("suspend")
 )
 
 var (
@@ -27,18 +35,25 @@ var (
 )
 
 func app(_ context.Context, es goban.Events) error {
-	ui, err := newUI(es)
-	if err != nil {
-		return err
+	if current == nil {
+		ui, err := newUI(es)
+		if err != nil {
+			return err
+		}
+		current = ui
+	} else {
+		current.resume(es)
 	}
 
 	for {
+		if current.external != nil {
+			return errSuspend
+		}
 		goban.Show()
-		key := es.ReadKey()
-		if key.Rune() == 'q' || key.Key() == tcell.KeyCtrlC {
+		current.operate(es.ReadKey())
+		if current.quit {
 			return nil
 		}
-		ui.operate(key)
 	}
 }
 
@@ -49,5 +64,11 @@ func setting() {
 func Start(cli domain.ContainerClient) error {
 	client = cli
 	setting()
-	return goban.Main(app)
+	for {
+		err := goban.Main(app)
+		if err != errSuspend {
+			return err
+		}
+		current.runExternal()
+	}
 }
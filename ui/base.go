@@ -3,6 +3,7 @@ package ui
 import (
 	"github.com/eihigh/goban"
 	"github.com/gdamore/tcell"
+	"github.com/lusingander/ecr-browser/domain"
 	"github.com/lusingander/ecr-browser/layout"
 	"github.com/lusingander/ecr-browser/util"
 )
@@ -17,6 +18,8 @@ var (
 
 type operator interface {
 	operate(*tcell.EventKey)
+	keyBindings() []*keyBindingGroup
+	setFocus(bool)
 }
 
 type viewStack struct {
@@ -46,7 +49,11 @@ func (s *viewStack) pop(vs ...goban.View) []goban.View {
 type ui struct {
 	*baseView
 	*viewStack
-	focused operator
+	focused        operator
+	panes          []operator
+	commandHistory *layout.History
+	external       *externalCommand
+	quit           bool
 }
 
 func newUI(es goban.Events) (*ui, error) {
@@ -57,6 +64,7 @@ func newUI(es goban.Events) (*ui, error) {
 	}
 	ui.baseView = baseView
 	ui.viewStack = newViewStack()
+	ui.commandHistory = layout.NewHistory()
 	ui.loadRepositoryView(true)
 	return ui, nil
 }
@@ -74,11 +82,78 @@ func (u *ui) popViews() {
 }
 
 func (u *ui) operate(key *tcell.EventKey) {
+	u.baseView.HintBar.ClearMessage()
+	if dispatch([]*keyBindingGroup{u.globalKeyBindings()}, key) {
+		return
+	}
 	if u.focused != nil {
 		u.focused.operate(key)
 	}
 }
 
+func (u *ui) globalKeyBindings() *keyBindingGroup {
+	return &keyBindingGroup{
+		title: "GLOBAL",
+		bindings: []*keyBinding{
+			{runes: []rune{'?'}, desc: "show help", action: u.showHelp},
+			{runes: []rune{':'}, desc: "command line", action: u.commandLine},
+			{keys: []tcell.Key{tcell.KeyTab}, desc: "switch pane", action: u.focusNext},
+			{runes: []rune{'t'}, desc: "toggle relative time", action: u.toggleRelativeTime},
+			{runes: []rune{'q'}, keys: []tcell.Key{tcell.KeyCtrlC}, desc: "quit", action: func() { u.quit = true }},
+		},
+	}
+}
+
+func (u *ui) keyBindings() []*keyBindingGroup {
+	var groups []*keyBindingGroup
+	if u.focused != nil {
+		groups = append(groups, u.focused.keyBindings()...)
+	}
+	return append(groups, u.globalKeyBindings())
+}
+
+func (u *ui) showHelp() {
+	layout.NewHelpDialog(u.baseView.base, u.baseView.es, helpGroups(u.keyBindings())).Display()
+}
+
+func (u *ui) focus(o operator) {
+	if u.focused != nil {
+		u.focused.setFocus(false)
+	}
+	u.focused = o
+	o.setFocus(true)
+	u.baseView.setHint(hintStr(o.keyBindings()[0]) + "  ?: help")
+}
+
+// setPanes registers the operators that Tab cycles through and focuses the first one.
+func (u *ui) setPanes(os ...operator) {
+	u.panes = os
+	u.focus(os[0])
+}
+
+// refreshDetail rebuilds the detail pane from the current list selection.
+func (u *ui) refreshDetail() {
+	for _, o := range u.panes {
+		if l, ok := o.(listOperator); ok {
+			l.list().notify()
+		}
+	}
+}
+
+func (u *ui) toggleRelativeTime() {
+	domain.ToggleRelativeTime()
+	u.refreshDetail()
+}
+
+func (u *ui) focusNext() {
+	for i, o := range u.panes {
+		if o == u.focused {
+			u.focus(u.panes[(i+1)%len(u.panes)])
+			return
+		}
+	}
+}
+
 func (u *ui) loadRepositoryView(init bool) error {
 	loading := layout.NewLoadingDialog(u.baseView.base, u.baseView.es)
 	go loading.Display()
@@ -89,11 +164,12 @@ func (u *ui) loadRepositoryView(init bool) error {
 		return err
 	}
 	lv.setBaseUI(u)
-	u.pushViews(lv, dv)
-	u.focused = lv
 	if !init {
-		u.baseView.popBreadcrumb()
+		u.popViews()
+		u.baseView.resetBreadcrumb()
 	}
+	u.pushViews(lv, dv)
+	u.setPanes(lv, dv)
 	return nil
 }
 
@@ -107,9 +183,10 @@ func (u *ui) loadImageViews(repo string) error {
 		return err
 	}
 	lv.setBaseUI(u)
+	u.baseView.resetBreadcrumb()
 	u.popViews()
 	u.pushViews(lv, dv)
-	u.focused = lv
+	u.setPanes(lv, dv)
 	u.baseView.pushBreadcrumb(repo)
 	return nil
 }
@@ -117,6 +194,7 @@ func (u *ui) loadImageViews(repo string) error {
 type baseView struct {
 	base *goban.Box
 	*layout.Breadcrumb
+	*layout.HintBar
 	*gridLayout
 	es goban.Events
 }
@@ -127,7 +205,8 @@ func newBaseView(es goban.Events) (*baseView, error) {
 	bv.base = b
 	bv.createGrid(util.InsideSides(b, 1, 2, 1, 1))
 	bv.newECRBreadcrumb(b.Pos.X+2, b.Pos.Y+1, b.Size.X-3)
-	util.PushViews(bv, bv.Breadcrumb)
+	bv.HintBar = layout.NewHintBar(b.Pos.X+2, b.Pos.Y+b.Size.Y-1, b.Size.X-4)
+	util.PushViews(bv, bv.Breadcrumb, bv.HintBar)
 	return bv, nil
 }
 
@@ -143,6 +222,20 @@ func (v *baseView) newECRBreadcrumb(x, y, w int) {
 	v.Breadcrumb = b
 }
 
+func (v *baseView) setHint(s string) {
+	v.HintBar.SetHint(" " + s + " ")
+}
+
+func (u *ui) showError(err error) {
+	if err != nil {
+		u.baseView.showMessage(err.Error())
+	}
+}
+
+func (v *baseView) showMessage(s string) {
+	v.HintBar.SetMessage(" " + s + " ")
+}
+
 func (v *baseView) pushBreadcrumb(s string) {
 	v.Breadcrumb.Push(s)
 }
@@ -151,6 +244,12 @@ func (v *baseView) popBreadcrumb() string {
 	return v.Breadcrumb.Pop()
 }
 
+func (v *baseView) resetBreadcrumb() {
+	for v.Breadcrumb.Len() > len(breadcrumbBases) {
+		v.Breadcrumb.Pop()
+	}
+}
+
 func (v *baseView) newRepositoryView() (*repositoryListView, *repositoryDetailView, error) {
 	lv, err := newRepositoryListView(v.gridLayout.list)
 	if err != nil {
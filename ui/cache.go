@@ -0,0 +1,331 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/eihigh/goban"
+	"github.com/gdamore/tcell"
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/layout"
+)
+
+const (
+	cacheRuleListViewTitle = "PULL-THROUGH CACHE RULES"
+	cacheRuleBreadcrumb    = "CACHE RULES"
+	createCacheRuleTitle   = "NEW PULL-THROUGH CACHE RULE"
+	deleteCacheRuleTitle   = "DELETE PULL-THROUGH CACHE RULE"
+)
+
+const (
+	cacheRuleFieldPrefix = iota
+	cacheRuleFieldUpstream
+	cacheRuleFieldCredential
+)
+
+var (
+	// cacheUpstreams are the upstream registries ECR supports, offered in the form.
+	cacheUpstreams = []string{"registry-1.docker.io", "public.ecr.aws", "quay.io", "ghcr.io", "registry.k8s.io", "registry.gitlab.com"}
+)
+
+// pullThroughCacheRules holds the rules fetched with the repository list,
+// so repositories created by a rule can be marked.
+var pullThroughCacheRules []*domain.PullThroughCacheRule
+
+func pullThroughCacheClient() (domain.PullThroughCacheClient, error) {
+	if c, ok := client.(domain.PullThroughCacheClient); ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("current client does not support pull-through cache rules")
+}
+
+// refreshPullThroughCacheRules fetches the rules for marking repositories.
+// Without them (e.g. no permission to describe rules) repositories are just not marked.
+func refreshPullThroughCacheRules() {
+	pullThroughCacheRules = nil
+	c, err := pullThroughCacheClient()
+	if err != nil {
+		return
+	}
+	if rules, err := c.FetchPullThroughCacheRules(); err == nil {
+		pullThroughCacheRules = rules
+	}
+}
+
+// hasCachedRepositories reports whether any of repos was created by a pull-through cache rule.
+func hasCachedRepositories(repos []*domain.Repository) bool {
+	for _, r := range repos {
+		if domain.FindPullThroughCacheRule(pullThroughCacheRules, r.Name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// cachedRepositories returns the names of the repositories created by rule.
+func cachedRepositories(rule *domain.PullThroughCacheRule) []string {
+	repos, err := client.FetchAllRepositories()
+	if err != nil {
+		return nil
+	}
+	var ret []string
+	for _, r := range repos {
+		if rule.Caches(r.Name) {
+			ret = append(ret, r.Name)
+		}
+	}
+	return ret
+}
+
+var (
+	cacheRuleSorters = []*listSorter{
+		{"prefix", func(a, b listViewElement) bool {
+			return a.(*domain.PullThroughCacheRule).Prefix < b.(*domain.PullThroughCacheRule).Prefix
+		}, false},
+		{"created", func(a, b listViewElement) bool {
+			return a.(*domain.PullThroughCacheRule).CreatedAt.Before(b.(*domain.PullThroughCacheRule).CreatedAt)
+		}, true},
+	}
+
+	cacheRuleColumns = &columnSet{[]*listColumn{
+		{"prefix", func(e listViewElement) listCell { return textCell(e.(*domain.PullThroughCacheRule).Prefix) }},
+		{"upstream", func(e listViewElement) listCell { return textCell(e.(*domain.PullThroughCacheRule).UpstreamUrl) }},
+		{"credential", func(e listViewElement) listCell {
+			return textCell(valueOrNone(e.(*domain.PullThroughCacheRule).CredentialArn))
+		}},
+		{"repos", func(e listViewElement) listCell {
+			return numberCell(strconv.Itoa(len(cachedRepositories(e.(*domain.PullThroughCacheRule)))))
+		}},
+		{"created", func(e listViewElement) listCell {
+			return textCell(e.(*domain.PullThroughCacheRule).CreatedAtShortStr())
+		}},
+	}, []string{"prefix", "upstream", "repos", "credential"}}
+)
+
+type cacheRuleListView struct {
+	*listViewBase
+}
+
+func newCacheRuleListView(b *goban.Box, rules []*domain.PullThroughCacheRule) *cacheRuleListView {
+	elems := make([]listViewElement, len(rules))
+	for i, r := range rules {
+		elems[i] = r
+	}
+	return &cacheRuleListView{
+		listViewBase: &listViewBase{
+			box:       b,
+			model:     newListModel(elems, cacheRuleSorters...),
+			columnSet: cacheRuleColumns,
+			columns:   cacheRuleColumns.defaultColumns(),
+			title:     cacheRuleListViewTitle,
+		},
+	}
+}
+
+func (v *cacheRuleListView) operate(key *tcell.EventKey) {
+	dispatch(v.keyBindings(), key)
+}
+
+func (v *cacheRuleListView) keyBindings() []*keyBindingGroup {
+	return append([]*keyBindingGroup{
+		{
+			title: cacheRuleListViewTitle,
+			bindings: []*keyBinding{
+				{runes: []rune{'n'}, desc: "create rule", action: v.createRule},
+				{runes: []rune{'D'}, desc: "delete rule", action: v.deleteRule},
+				{runes: []rune{'l'}, desc: "show repositories of the rule", action: v.showRepositories},
+				{runes: []rune{'h'}, desc: "move to repository list", action: func() { v.ui.loadRepositoryView(false) }},
+			},
+		},
+	}, v.listViewBase.keyBindings()...)
+}
+
+func (v *cacheRuleListView) currentRule() *domain.PullThroughCacheRule {
+	r, _ := v.current().(*domain.PullThroughCacheRule)
+	return r
+}
+
+func cacheRuleFields() []*layout.FormField {
+	return []*layout.FormField{
+		cacheRuleFieldPrefix:     {Label: "repository prefix"},
+		cacheRuleFieldUpstream:   {Label: "upstream registry", Value: cacheUpstreams[0], Options: cacheUpstreams},
+		cacheRuleFieldCredential: {Label: "credential arn"},
+	}
+}
+
+func createCacheRuleInputFromFields(fs []*layout.FormField) (*domain.CreatePullThroughCacheRuleInput, error) {
+	in := &domain.CreatePullThroughCacheRuleInput{
+		Prefix:        strings.TrimSpace(fs[cacheRuleFieldPrefix].Value),
+		UpstreamUrl:   strings.TrimSpace(fs[cacheRuleFieldUpstream].Value),
+		CredentialArn: strings.TrimSpace(fs[cacheRuleFieldCredential].Value),
+	}
+	if err := in.Validate(); err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+func (v *cacheRuleListView) createRule() {
+	c, err := pullThroughCacheClient()
+	if err != nil {
+		v.ui.showError(err)
+		return
+	}
+	fields := cacheRuleFields()
+	for {
+		if !layout.NewFormDialog(v.ui.baseView.base, v.ui.baseView.es, createCacheRuleTitle, fields).Display() {
+			return
+		}
+		input, err := createCacheRuleInputFromFields(fields)
+		var rule *domain.PullThroughCacheRule
+		if err == nil {
+			rule, err = c.CreatePullThroughCacheRule(input)
+		}
+		if err == nil {
+			if err := v.ui.loadCacheRuleViews(); err != nil {
+				v.ui.showError(err)
+				return
+			}
+			if l, err := v.ui.focusedList(); err == nil {
+				l.selectWhere(func(e listViewElement) bool { return e.(*domain.PullThroughCacheRule).Prefix == rule.Prefix })
+			}
+			v.ui.showMessage(fmt.Sprintf("created %s, pull through %s/%s/<image>", rule.Display(), currentRegistryHost(), rule.Prefix))
+			return
+		}
+		if !v.ui.confirmRetry(err) {
+			return
+		}
+	}
+}
+
+func (v *cacheRuleListView) deleteRule() {
+	rule := v.currentRule()
+	if rule == nil {
+		return
+	}
+	c, err := pullThroughCacheClient()
+	if err != nil {
+		v.ui.showError(err)
+		return
+	}
+	lines := []string{
+		fmt.Sprintf("Delete the rule %s?", rule.Display()),
+		"",
+	}
+	if repos := cachedRepositories(rule); len(repos) > 0 {
+		lines = append(lines, fmt.Sprintf("The %d repositories it created are kept, but no longer updated from %s.", len(repos), rule.UpstreamUrl))
+	} else {
+		lines = append(lines, "It has not created any repositories.")
+	}
+	if !layout.NewConfirmDialog(v.ui.baseView.base, v.ui.baseView.es, deleteCacheRuleTitle, lines).Display() {
+		return
+	}
+	if err := c.DeletePullThroughCacheRule(rule.Prefix); err != nil {
+		v.ui.showError(err)
+		return
+	}
+	cursor := v.cursor()
+	if err := v.ui.loadCacheRuleViews(); err != nil {
+		v.ui.showError(err)
+		return
+	}
+	if l, err := v.ui.focusedList(); err == nil {
+		l.selectIndex(cursor)
+	}
+	v.ui.showMessage(fmt.Sprintf("deleted %s", rule.Display()))
+}
+
+// showRepositories shows the repository list filtered to the repositories the rule created.
+func (v *cacheRuleListView) showRepositories() {
+	rule := v.currentRule()
+	if rule == nil {
+		return
+	}
+	if err := v.ui.loadRepositoryView(false); err != nil {
+		v.ui.showError(err)
+		return
+	}
+	v.ui.showError(v.ui.runFilterCommand([]string{"cache=" + rule.Prefix}))
+}
+
+// currentRegistryHost is the host images are pulled through, e.g. xxx.dkr.ecr.ap-northeast-1.amazonaws.com.
+func currentRegistryHost() string {
+	repos, err := client.FetchAllRepositories()
+	if err == nil && len(repos) > 0 {
+		if i := strings.Index(repos[0].Uri, "/"); i > 0 {
+			return repos[0].Uri[:i]
+		}
+	}
+	return fmt.Sprintf("<account>.dkr.ecr.%s.amazonaws.com", currentRegion())
+}
+
+type cacheRuleDetailView struct {
+	*detailViewBase
+	selected *domain.PullThroughCacheRule
+}
+
+func newCacheRuleDetailView(b *goban.Box) *cacheRuleDetailView {
+	return &cacheRuleDetailView{newDetailViewBase(b), nil}
+}
+
+func (v *cacheRuleDetailView) update(e listViewElement) {
+	v.selected, _ = e.(*domain.PullThroughCacheRule)
+	v.SetLines(v.lines())
+}
+
+func (v *cacheRuleDetailView) lines() []string {
+	if v.selected == nil {
+		return nil
+	}
+	ls := []string{
+		"PREFIX:",
+		"  " + v.selected.Prefix,
+		"UPSTREAM REGISTRY:",
+		"  " + v.selected.UpstreamUrl,
+		"CREDENTIAL ARN:",
+		"  " + valueOrNone(v.selected.CredentialArn),
+		"REGISTRY ID:",
+		"  " + valueOrNone(v.selected.RegistryId),
+		"CREATED AT:",
+		"  " + v.selected.CreatedAtStr(),
+		"PULL:",
+		fmt.Sprintf("  docker pull %s/%s/<image>", currentRegistryHost(), v.selected.Prefix),
+		"REPOSITORIES:",
+	}
+	repos := cachedRepositories(v.selected)
+	if len(repos) == 0 {
+		return append(ls, "  "+noValue)
+	}
+	for _, r := range repos {
+		ls = append(ls, "  "+r)
+	}
+	return ls
+}
+
+func (u *ui) loadCacheRuleViews() error {
+	c, err := pullThroughCacheClient()
+	if err != nil {
+		return err
+	}
+	loading := layout.NewLoadingDialog(u.baseView.base, u.baseView.es)
+	go loading.Display()
+	defer loading.Close()
+
+	rules, err := c.FetchPullThroughCacheRules()
+	if err != nil {
+		return err
+	}
+	pullThroughCacheRules = rules
+	lv := newCacheRuleListView(u.baseView.gridLayout.list, rules)
+	dv := newCacheRuleDetailView(u.baseView.gridLayout.detail)
+	lv.addObserver(dv)
+	lv.setBaseUI(u)
+	u.baseView.resetBreadcrumb()
+	u.popViews()
+	u.pushViews(lv, dv)
+	u.setPanes(lv, dv)
+	u.baseView.pushBreadcrumb(cacheRuleBreadcrumb)
+	u.baseView.showMessage(fmt.Sprintf("%d pull-through cache rules", len(rules)))
+	return nil
+}
@@ -0,0 +1,349 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/eihigh/goban"
+	"github.com/gdamore/tcell"
+	"github.com/lusingander/ecr-browser/cleanup"
+	"github.com/lusingander/ecr-browser/cost"
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/layout"
+)
+
+const (
+	cleanupListViewTitle = "CLEANUP"
+	cleanupBreadcrumb    = "CLEANUP"
+	cleanupRulesTitle    = "CLEANUP RULES"
+	deleteImagesTitle    = "DELETE IMAGES"
+	cleanupReportPrompt  = "report file: "
+)
+
+const (
+	cleanupFieldUntagged = iota
+	cleanupFieldNotPulledDays
+	cleanupFieldTagPattern
+	cleanupFieldKeepNewest
+	cleanupFieldMinSize
+	cleanupFieldKeep
+)
+
+// cleanupRules are kept for the session, so the form starts from the last rules used.
+var cleanupRules = cleanup.DefaultRules()
+
+func imageDeleteClient() (domain.ImageDeleteClient, error) {
+	if c, ok := client.(domain.ImageDeleteClient); ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("current client does not support deleting images")
+}
+
+// indexChildren returns the digests of the manifests the indexes in imgs list,
+// none if the client cannot read indexes.
+func indexChildren(repo string, imgs []*domain.Image) ([]string, error) {
+	c, ok := client.(domain.ImageIndexClient)
+	if !ok {
+		return nil, nil
+	}
+	return c.FetchIndexChildren(repo, imgs)
+}
+
+func cleanupFields(r *cleanup.Rules) []*layout.FormField {
+	minSize := ""
+	if r.MinSizeByte > 0 {
+		minSize = humanize.Bytes(uint64(r.MinSizeByte))
+	}
+	return []*layout.FormField{
+		cleanupFieldUntagged:      {Label: "untagged", Value: strconv.FormatBool(r.Untagged), Options: boolOptions},
+		cleanupFieldNotPulledDays: {Label: "not pulled in days", Value: intFieldStr(r.NotPulledDays)},
+		cleanupFieldTagPattern:    {Label: "tag pattern", Value: r.TagPattern},
+		cleanupFieldKeepNewest:    {Label: "  keep newest", Value: intFieldStr(r.KeepNewest)},
+		cleanupFieldMinSize:       {Label: "larger than", Value: minSize},
+		cleanupFieldKeep:          {Label: "never delete tags", Value: strings.Join(r.Keep, ",")},
+	}
+}
+
+func intFieldStr(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+func cleanupRulesFromFields(fs []*layout.FormField) (*cleanup.Rules, error) {
+	var errs []string
+	atoi := func(i int) int {
+		s := strings.TrimSpace(fs[i].Value)
+		if s == "" {
+			return 0
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s must be a number", strings.TrimSpace(fs[i].Label)))
+		}
+		return n
+	}
+	r := &cleanup.Rules{
+		Untagged:      fs[cleanupFieldUntagged].Value == "true",
+		NotPulledDays: atoi(cleanupFieldNotPulledDays),
+		TagPattern:    strings.TrimSpace(fs[cleanupFieldTagPattern].Value),
+		KeepNewest:    atoi(cleanupFieldKeepNewest),
+	}
+	if s := strings.TrimSpace(fs[cleanupFieldMinSize].Value); s != "" {
+		size, err := humanize.ParseBytes(s)
+		if err != nil {
+			errs = append(errs, "larger than must be a size such as 500MB")
+		}
+		r.MinSizeByte = int64(size)
+	}
+	for _, k := range strings.Split(fs[cleanupFieldKeep].Value, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			r.Keep = append(r.Keep, k)
+		}
+	}
+	if err := r.Validate(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return r, nil
+}
+
+// editCleanupRules asks for the rules and shows the images they propose to delete.
+func (u *ui) editCleanupRules(repo string) {
+	fields := cleanupFields(cleanupRules)
+	for {
+		if !layout.NewFormDialog(u.baseView.base, u.baseView.es, cleanupRulesTitle, fields).Display() {
+			return
+		}
+		r, err := cleanupRulesFromFields(fields)
+		if err == nil {
+			cleanupRules = r
+			u.showError(u.loadCleanupViews(repo))
+			return
+		}
+		if !u.confirmRetry(err) {
+			return
+		}
+	}
+}
+
+var (
+	cleanupSorters = []*listSorter{
+		{"pushed", func(a, b listViewElement) bool {
+			return a.(*cleanup.Candidate).Image.PushedAt.Before(b.(*cleanup.Candidate).Image.PushedAt)
+		}, true},
+		{"size", func(a, b listViewElement) bool {
+			return a.(*cleanup.Candidate).Image.SizeByte < b.(*cleanup.Candidate).Image.SizeByte
+		}, true},
+		{"pulled", func(a, b listViewElement) bool {
+			return a.(*cleanup.Candidate).Image.LastPulledAt.Before(b.(*cleanup.Candidate).Image.LastPulledAt)
+		}, true},
+	}
+)
+
+type cleanupListView struct {
+	*listViewBase
+	repository string
+	candidates []*cleanup.Candidate
+}
+
+func newCleanupListView(b *goban.Box, repo string, cs []*cleanup.Candidate) *cleanupListView {
+	elems := make([]listViewElement, len(cs))
+	for i, c := range cs {
+		elems[i] = c
+	}
+	v := &cleanupListView{
+		listViewBase: &listViewBase{
+			box:   b,
+			model: newListModel(elems, cleanupSorters...),
+		},
+		repository: repo,
+		candidates: cs,
+	}
+	v.updateTitle()
+	return v
+}
+
+func (v *cleanupListView) operate(key *tcell.EventKey) {
+	dispatch(v.keyBindings(), key)
+}
+
+func (v *cleanupListView) keyBindings() []*keyBindingGroup {
+	return append([]*keyBindingGroup{
+		{
+			title: cleanupListViewTitle,
+			bindings: []*keyBinding{
+				{runes: []rune{' '}, desc: "select / unselect", action: v.toggle},
+				{runes: []rune{'a'}, desc: "select / unselect all", action: v.toggleAll},
+				{runes: []rune{'x'}, desc: "delete selected images", action: v.deleteSelected},
+				{runes: []rune{'w'}, desc: "export dry-run report", action: v.exportReport},
+				{runes: []rune{'e'}, desc: "edit rules", action: func() { v.ui.editCleanupRules(v.repository) }},
+				{runes: []rune{'h'}, desc: "move to image list", action: func() { v.ui.loadImageViews(v.repository) }},
+			},
+		},
+	}, v.listViewBase.keyBindings()...)
+}
+
+func (v *cleanupListView) updateTitle() {
+	selected := cleanup.Selected(v.candidates)
+	v.title = fmt.Sprintf("%s %d/%d %s", cleanupListViewTitle, len(selected), len(v.candidates), reclaimStr(selected))
+}
+
+// reclaimStr is the size of cs and what storing it costs a month.
+func reclaimStr(cs []*cleanup.Candidate) string {
+	size := cleanup.TotalSize(cs)
+	return fmt.Sprintf("%s, %s / month", humanize.Bytes(uint64(size)), cost.Format(cost.Monthly(size, currentRegion())))
+}
+
+func (v *cleanupListView) toggle() {
+	c, ok := v.current().(*cleanup.Candidate)
+	if !ok {
+		return
+	}
+	c.Selected = !c.Selected
+	v.updateTitle()
+	v.selectNext()
+}
+
+func (v *cleanupListView) toggleAll() {
+	selected := len(cleanup.Selected(v.candidates)) < len(v.candidates)
+	for _, c := range v.candidates {
+		c.Selected = selected
+	}
+	v.updateTitle()
+	v.notify()
+}
+
+func (v *cleanupListView) exportReport() {
+	b := v.ui.baseView.base
+	box := goban.NewBox(b.Pos.X+1, b.Pos.Y+b.Size.Y-1, b.Size.X-2, 1)
+	init := fmt.Sprintf("cleanup-%s-%s.csv", strings.Replace(v.repository, "/", "-", -1), time.Now().Format("20060102"))
+	name, ok := layout.NewInputLine(box, v.ui.baseView.es, cleanupReportPrompt).Read(init)
+	if !ok || name == "" {
+		return
+	}
+	if err := exportCSV(name, v.model.all()); err != nil {
+		v.ui.showError(err)
+		return
+	}
+	v.ui.showMessage(fmt.Sprintf("wrote %d candidates to %s", len(v.candidates), name))
+}
+
+func (v *cleanupListView) deleteSelected() {
+	selected := cleanup.Selected(v.candidates)
+	if len(selected) == 0 {
+		v.ui.showMessage("no images selected")
+		return
+	}
+	c, err := imageDeleteClient()
+	if err != nil {
+		v.ui.showError(err)
+		return
+	}
+	lines := []string{
+		fmt.Sprintf("Delete %d images (%s) from %s?", len(selected), reclaimStr(selected), v.repository),
+		"",
+	}
+	imgs := make([]*domain.Image, len(selected))
+	for i, s := range selected {
+		imgs[i] = s.Image
+		lines = append(lines, fmt.Sprintf("  %s  %s  %s", s.Image.GetTag(), s.Image.PushedAtShortStr(), s.Image.SizeStr()))
+	}
+	if !layout.NewConfirmDialog(v.ui.baseView.base, v.ui.baseView.es, deleteImagesTitle, lines).Display() {
+		return
+	}
+	failures, err := v.deleteImages(c, imgs)
+	if err == nil {
+		err = v.ui.loadCleanupViews(v.repository)
+	}
+	if err != nil {
+		v.ui.showError(err)
+		return
+	}
+	msg := fmt.Sprintf("deleted %d images", len(imgs)-len(failures))
+	if len(failures) > 0 {
+		msg += fmt.Sprintf(", %d failed: %s", len(failures), failures[0].Reason)
+	}
+	v.ui.showMessage(msg)
+}
+
+func (v *cleanupListView) deleteImages(c domain.ImageDeleteClient, imgs []*domain.Image) ([]*domain.ImageDeleteFailure, error) {
+	loading := layout.NewLoadingDialog(v.ui.baseView.base, v.ui.baseView.es)
+	go loading.Display()
+	defer loading.Close()
+	return c.DeleteImages(v.repository, imgs)
+}
+
+type cleanupDetailView struct {
+	*detailViewBase
+	selected *cleanup.Candidate
+}
+
+func newCleanupDetailView(b *goban.Box) *cleanupDetailView {
+	return &cleanupDetailView{newDetailViewBase(b), nil}
+}
+
+func (v *cleanupDetailView) update(e listViewElement) {
+	v.selected, _ = e.(*cleanup.Candidate)
+	v.SetLines(v.lines())
+}
+
+func (v *cleanupDetailView) lines() []string {
+	if v.selected == nil {
+		return nil
+	}
+	img := v.selected.Image
+	ls := []string{"REASONS:"}
+	for _, r := range v.selected.Reasons {
+		ls = append(ls, "  "+r)
+	}
+	ls = append(ls, "TAGS:")
+	for _, t := range img.GetTags() {
+		ls = append(ls, "  "+t)
+	}
+	return append(ls,
+		"PUSHED AT:",
+		"  "+img.PushedAtStr(),
+		"LAST PULLED AT:",
+		"  "+img.LastPulledAtStr(),
+		"SIZE:",
+		"  "+img.SizeStr(),
+		"DIGEST:",
+		"  "+img.Digest,
+	)
+}
+
+func (u *ui) loadCleanupViews(repo string) error {
+	loading := layout.NewLoadingDialog(u.baseView.base, u.baseView.es)
+	go loading.Display()
+	defer loading.Close()
+
+	imgs, err := client.FetchAllImages(repo)
+	if err != nil {
+		return err
+	}
+	recordImages(repo, imgs)
+	children, err := indexChildren(repo, imgs)
+	if err != nil {
+		return err
+	}
+	cs := cleanup.Propose(cleanupRules, imgs, children, time.Now())
+	lv := newCleanupListView(u.baseView.gridLayout.list, repo, cs)
+	dv := newCleanupDetailView(u.baseView.gridLayout.detail)
+	lv.addObserver(dv)
+	lv.setBaseUI(u)
+	u.baseView.resetBreadcrumb()
+	u.popViews()
+	u.pushViews(lv, dv)
+	u.setPanes(lv, dv)
+	u.baseView.pushBreadcrumb(repo)
+	u.baseView.pushBreadcrumb(cleanupBreadcrumb)
+	u.baseView.showMessage(fmt.Sprintf("%d of %d images proposed, %s reclaimable", len(cs), len(imgs), reclaimStr(cs)))
+	return nil
+}
@@ -0,0 +1,234 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/lusingander/ecr-browser/cost"
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	columnSeparator = "  "
+	columnMinWidth  = 3
+	ellipsis        = "…"
+	noValue         = "-"
+)
+
+type cellAlign int
+
+const (
+	alignLeft cellAlign = iota
+	alignRight
+)
+
+type listCell struct {
+	text  string
+	align cellAlign
+}
+
+func textCell(s string) listCell {
+	return listCell{s, alignLeft}
+}
+
+func numberCell(s string) listCell {
+	return listCell{s, alignRight}
+}
+
+type listColumn struct {
+	name string
+	cell func(e listViewElement) listCell
+}
+
+// columnSet is the columns a list can show, and the ones it shows by default.
+type columnSet struct {
+	all      []*listColumn
+	defaults []string
+}
+
+func (s *columnSet) names() []string {
+	names := make([]string, len(s.all))
+	for i, c := range s.all {
+		names[i] = c.name
+	}
+	return names
+}
+
+func (s *columnSet) defaultColumns() []*listColumn {
+	cs, _ := selectColumns(s.all, s.defaults)
+	return cs
+}
+
+var (
+	imageColumns = &columnSet{[]*listColumn{
+		{"tag", func(e listViewElement) listCell { return textCell(e.(*domain.Image).GetTag()) }},
+		{"pushed", func(e listViewElement) listCell { return textCell(e.(*domain.Image).PushedAtShortStr()) }},
+		{"pulled", func(e listViewElement) listCell { return textCell(e.(*domain.Image).LastPulledAtShortStr()) }},
+		{"size", func(e listViewElement) listCell { return numberCell(e.(*domain.Image).SizeStr()) }},
+		{"scan", func(e listViewElement) listCell { return textCell(e.(*domain.Image).ScanStatusStr()) }},
+		{"digest", func(e listViewElement) listCell { return textCell(e.(*domain.Image).Digest) }},
+		{"media", func(e listViewElement) listCell { return textCell(e.(*domain.Image).ArtifactMediaTypeShortStr()) }},
+		{"expire", func(e listViewElement) listCell {
+			if r, ok := lifecycleExpirations[e.(*domain.Image)]; ok {
+				return textCell(fmt.Sprintf("#%d", r.RulePriority))
+			}
+			return textCell("")
+		}},
+	}, []string{"tag", "pushed", "size", "scan"}}
+
+	repositoryColumns = &columnSet{[]*listColumn{
+		{"name", func(e listViewElement) listCell { return textCell(e.(*domain.Repository).Name) }},
+		{"images", func(e listViewElement) listCell {
+			if s, ok := repositoryStats[e.(*domain.Repository).Name]; ok {
+				return numberCell(strconv.Itoa(s.count))
+			}
+			return numberCell(noValue)
+		}},
+		{"size", func(e listViewElement) listCell {
+			if s, ok := repositoryStats[e.(*domain.Repository).Name]; ok {
+				return numberCell(humanize.Bytes(uint64(s.sizeByte)))
+			}
+			return numberCell(noValue)
+		}},
+		{"cost", func(e listViewElement) listCell {
+			if s, ok := repositoryStats[e.(*domain.Repository).Name]; ok {
+				return numberCell(cost.Format(s.monthlyCost()))
+			}
+			return numberCell(noValue)
+		}},
+		{"mutability", func(e listViewElement) listCell { return textCell(e.(*domain.Repository).TagMutability) }},
+		{"scan", func(e listViewElement) listCell { return textCell(e.(*domain.Repository).ScanOnPushStr()) }},
+		{"encryption", func(e listViewElement) listCell { return textCell(e.(*domain.Repository).EncryptionType) }},
+		{"created", func(e listViewElement) listCell { return textCell(e.(*domain.Repository).CreatedAtShortStr()) }},
+		{"uri", func(e listViewElement) listCell { return textCell(e.(*domain.Repository).Uri) }},
+		{"cache", func(e listViewElement) listCell {
+			if r := domain.FindPullThroughCacheRule(pullThroughCacheRules, e.(*domain.Repository).Name); r != nil {
+				return textCell(r.UpstreamUrl)
+			}
+			return textCell("")
+		}},
+	}, []string{"name", "images", "size", "mutability"}}
+)
+
+func selectColumns(all []*listColumn, names []string) ([]*listColumn, error) {
+	var ret []*listColumn
+	for _, n := range names {
+		c := findColumn(all, n)
+		if c == nil {
+			return nil, fmt.Errorf("unknown column: %s", n)
+		}
+		ret = append(ret, c)
+	}
+	return ret, nil
+}
+
+func findColumn(cs []*listColumn, name string) *listColumn {
+	for _, c := range cs {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// negotiateWidths fits the natural widths of the columns into avail.
+// It first gives back the room only headers need (down to content widths),
+// then shrinks the widest column, but never below columnMinWidth.
+func negotiateWidths(natural, content []int, avail int) []int {
+	ws := make([]int, len(natural))
+	copy(ws, natural)
+	total := runewidth.StringWidth(columnSeparator) * (len(ws) - 1)
+	for _, w := range ws {
+		total += w
+	}
+	for total > avail {
+		most := -1
+		for i, w := range ws {
+			min := content[i]
+			if min < columnMinWidth {
+				min = columnMinWidth
+			}
+			if w > min && (most < 0 || w-content[i] > ws[most]-content[most]) {
+				most = i
+			}
+		}
+		if most < 0 {
+			break
+		}
+		ws[most]--
+		total--
+	}
+	for total > avail {
+		widest := -1
+		for i, w := range ws {
+			if w > columnMinWidth && (widest < 0 || w > ws[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			break
+		}
+		ws[widest]--
+		total--
+	}
+	return ws
+}
+
+func formatCells(cells []listCell, widths []int) string {
+	strs := make([]string, len(cells))
+	for i, c := range cells {
+		strs[i] = fitCell(c, widths[i])
+	}
+	return strings.TrimRight(strings.Join(strs, columnSeparator), " ")
+}
+
+func fitCell(c listCell, w int) string {
+	s := c.text
+	if runewidth.StringWidth(s) > w {
+		s = runewidth.Truncate(s, w, ellipsis)
+	}
+	pad := strings.Repeat(" ", w-runewidth.StringWidth(s))
+	if c.align == alignRight {
+		return pad + s
+	}
+	return s + pad
+}
+
+// tableLines renders the header and the rows of es with columns, within avail cells.
+func tableLines(columns []*listColumn, es []listViewElement, avail int) (string, []string) {
+	header := make([]listCell, len(columns))
+	natural := make([]int, len(columns))
+	content := make([]int, len(columns))
+	for i, c := range columns {
+		header[i] = textCell(strings.ToUpper(c.name))
+		natural[i] = runewidth.StringWidth(c.name)
+	}
+	rows := make([][]listCell, len(es))
+	for r, e := range es {
+		rows[r] = make([]listCell, len(columns))
+		for i, c := range columns {
+			cell := c.cell(e)
+			rows[r][i] = cell
+			if w := runewidth.StringWidth(cell.text); w > content[i] {
+				content[i] = w
+			}
+			if content[i] > natural[i] {
+				natural[i] = content[i]
+			}
+		}
+	}
+	for i := range columns {
+		if len(rows) > 0 && rows[0][i].align == alignRight {
+			header[i].align = alignRight
+		}
+	}
+	widths := negotiateWidths(natural, content, avail)
+	lines := make([]string, len(rows))
+	for r, row := range rows {
+		lines[r] = formatCells(row, widths)
+	}
+	return formatCells(header, widths), lines
+}
@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNegotiateWidths(t *testing.T) {
+	tests := []struct {
+		natural []int
+		content []int
+		avail   int
+		want    []int
+	}{
+		{[]int{10, 5, 4}, []int{10, 5, 4}, 30, []int{10, 5, 4}},
+		{[]int{10, 5, 4}, []int{10, 5, 4}, 19, []int{6, 5, 4}},
+		{[]int{10, 8, 4}, []int{10, 8, 4}, 16, []int{4, 4, 4}},
+		{[]int{14, 6, 10}, []int{14, 1, 7}, 30, []int{14, 3, 9}},
+		{[]int{10, 10}, []int{10, 10}, 2, []int{3, 3}},
+	}
+	for _, test := range tests {
+		got := negotiateWidths(test.natural, test.content, test.avail)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("negotiateWidths(%v, %v, %v) = %v; want = %v", test.natural, test.content, test.avail, got, test.want)
+		}
+	}
+}
+
+func TestFormatCells(t *testing.T) {
+	cells := []listCell{textCell("sample-repo"), numberCell("12"), textCell("MUTABLE")}
+	got := formatCells(cells, []int{6, 4, 7})
+	want := "sampl…    12  MUTABLE"
+	if got != want {
+		t.Errorf("formatCells() = %q; want = %q", got, want)
+	}
+}
@@ -0,0 +1,381 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eihigh/goban"
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/layout"
+)
+
+const (
+	commandPrompt = ":"
+)
+
+type command struct {
+	name     string
+	usage    string
+	run      func(args []string) error
+	complete func(args []string) []string
+}
+
+// listOperator is implemented by the views built on listViewBase.
+type listOperator interface {
+	list() *listViewBase
+}
+
+func (v *listViewBase) list() *listViewBase {
+	return v
+}
+
+func (u *ui) commands() []*command {
+	return []*command{
+		{"repo", "repo <name>", u.runRepoCommand, u.completeRepoCommand},
+		{"region", "region <region>", u.runRegionCommand, u.completeRegionCommand},
+		{"profile", "profile <name>", u.runProfileCommand, nil},
+		{"tz", "tz <local|UTC|zone>", u.runTimeZoneCommand, u.completeTimeZoneCommand},
+		{"sort", "sort <key> [asc|desc]", u.runSortCommand, u.completeSortCommand},
+		{"filter", "filter [text|field=pattern|field>days]", u.runFilterCommand, u.completeFilterCommand},
+		{"columns", "columns [name,...]", u.runColumnsCommand, u.completeColumnsCommand},
+		{"export", "export <file.csv>", u.runExportCommand, nil},
+		{"dashboard", "dashboard", func([]string) error { return u.loadDashboardViews() }, nil},
+		{"cache", "cache", func([]string) error { return u.loadCacheRuleViews() }, nil},
+		{"q", "q", func([]string) error { u.quit = true; return nil }, nil},
+	}
+}
+
+func (u *ui) findCommand(name string) *command {
+	for _, c := range u.commands() {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func (u *ui) commandLine() {
+	b := u.baseView.base
+	box := goban.NewBox(b.Pos.X+1, b.Pos.Y+b.Size.Y-1, b.Size.X-2, 1)
+	input := layout.NewInputLine(box, u.baseView.es, commandPrompt).
+		Completion(u.completeCommand).
+		History(u.commandHistory)
+	line, ok := input.Read("")
+	if !ok {
+		return
+	}
+	u.showError(u.runCommand(line))
+}
+
+func (u *ui) runCommand(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	c := u.findCommand(fields[0])
+	if c == nil {
+		return fmt.Errorf("unknown command: %s", fields[0])
+	}
+	return c.run(fields[1:])
+}
+
+// completeCommand returns the candidate lines for the command line input s.
+func (u *ui) completeCommand(s string) []string {
+	fields := strings.Fields(s)
+	if strings.HasSuffix(s, " ") || len(fields) == 0 {
+		fields = append(fields, "")
+	}
+	last := fields[len(fields)-1]
+	prefix := strings.Join(fields[:len(fields)-1], " ")
+	if prefix != "" {
+		prefix += " "
+	}
+	var cands []string
+	if len(fields) == 1 {
+		for _, c := range u.commands() {
+			cands = append(cands, c.name)
+		}
+	} else if c := u.findCommand(fields[0]); c != nil && c.complete != nil {
+		cands = c.complete(fields[1:])
+	}
+	var ret []string
+	for _, c := range cands {
+		if strings.HasPrefix(c, last) {
+			ret = append(ret, prefix+c)
+		}
+	}
+	return ret
+}
+
+func (u *ui) focusedList() (*listViewBase, error) {
+	for _, o := range u.panes {
+		if l, ok := o.(listOperator); ok {
+			return l.list(), nil
+		}
+	}
+	return nil, fmt.Errorf("not in a list view")
+}
+
+func (u *ui) runRepoCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: %s", u.findCommand("repo").usage)
+	}
+	return u.loadImageViews(args[0])
+}
+
+func (u *ui) completeRepoCommand(args []string) []string {
+	if len(args) != 1 {
+		return nil
+	}
+	repos, err := client.FetchAllRepositories()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, r := range repos {
+		names = append(names, r.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (u *ui) sessionClient() (domain.SessionClient, error) {
+	if c, ok := client.(domain.SessionClient); ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("current client does not support changing session")
+}
+
+func (u *ui) runRegionCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: %s", u.findCommand("region").usage)
+	}
+	return u.switchSession(func(c domain.SessionClient) error {
+		return c.SetRegion(args[0])
+	})
+}
+
+func (u *ui) completeRegionCommand(args []string) []string {
+	c, err := u.sessionClient()
+	if err != nil || len(args) != 1 {
+		return nil
+	}
+	return c.Regions()
+}
+
+func (u *ui) runProfileCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: %s", u.findCommand("profile").usage)
+	}
+	return u.switchSession(func(c domain.SessionClient) error {
+		return c.SetProfile(args[0])
+	})
+}
+
+// switchSession changes the session with set and reloads the repositories.
+// If they cannot be loaded, the previous region and profile are restored.
+func (u *ui) switchSession(set func(c domain.SessionClient) error) error {
+	c, err := u.sessionClient()
+	if err != nil {
+		return err
+	}
+	region, profile := c.Region(), c.Profile()
+	if err := set(c); err != nil {
+		return err
+	}
+	if err := u.loadRepositoryView(false); err != nil {
+		if rerr := restoreSession(c, region, profile); rerr != nil {
+			return fmt.Errorf("%v (cannot restore the previous session: %v)", err, rerr)
+		}
+		return err
+	}
+	// the detail of the first repository was shown with what was recorded in the previous session
+	resetSessionCaches()
+	u.refreshDetail()
+	return nil
+}
+
+func restoreSession(c domain.SessionClient, region, profile string) error {
+	if err := c.SetProfile(profile); err != nil {
+		return err
+	}
+	return c.SetRegion(region)
+}
+
+func (u *ui) runTimeZoneCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: %s", u.findCommand("tz").usage)
+	}
+	if err := domain.SetDisplayTimeZone(args[0]); err != nil {
+		return err
+	}
+	u.refreshDetail()
+	return nil
+}
+
+func (u *ui) completeTimeZoneCommand(args []string) []string {
+	if len(args) != 1 {
+		return nil
+	}
+	return domain.TimeZoneNames()
+}
+
+func (u *ui) runSortCommand(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("usage: %s", u.findCommand("sort").usage)
+	}
+	v, err := u.focusedList()
+	if err != nil {
+		return err
+	}
+	desc := false
+	if len(args) == 2 {
+		switch args[1] {
+		case "asc":
+		case "desc":
+			desc = true
+		default:
+			return fmt.Errorf("sort order must be asc or desc: %s", args[1])
+		}
+	}
+	if !v.model.setSorterByName(args[0], desc) {
+		return fmt.Errorf("unknown sort key: %s", args[0])
+	}
+	v.selectFirst()
+	return nil
+}
+
+func (u *ui) completeSortCommand(args []string) []string {
+	v, err := u.focusedList()
+	if err != nil {
+		return nil
+	}
+	switch len(args) {
+	case 1:
+		return v.model.sorterNames()
+	case 2:
+		return []string{"asc", "desc"}
+	}
+	return nil
+}
+
+func (u *ui) runFilterCommand(args []string) error {
+	v, err := u.focusedList()
+	if err != nil {
+		return err
+	}
+	matcher, query, fetches, err := parseFilterArgs(v, args, time.Now())
+	if err != nil {
+		return err
+	}
+	if !fetches {
+		v.model.setMatcher(matcher)
+		v.filter(query)
+		return nil
+	}
+	// tags that could not be fetched are tried again, and nothing matched
+	// with the tags of the last time is reused
+	tagFilterErr = nil
+	repositoryTagErrors = make(map[string]error)
+	loading := layout.NewLoadingDialog(u.baseView.base, u.baseView.es)
+	go loading.Display()
+	defer loading.Close()
+	v.model.refreshMatcher(matcher)
+	v.filter(query)
+	if tagFilterErr != nil {
+		v.model.forget()
+		return fmt.Errorf("cannot filter by resource tags: %v", tagFilterErr)
+	}
+	return nil
+}
+
+func (u *ui) completeFilterCommand(args []string) []string {
+	v, err := u.focusedList()
+	if err != nil {
+		return nil
+	}
+	last := args[len(args)-1]
+	kv := strings.SplitN(last, "=", 2)
+	if len(kv) == 1 {
+		var names []string
+		for _, f := range v.fields {
+			names = append(names, f.name+"=")
+		}
+		for _, f := range v.ageFields {
+			names = append(names, f.name+">")
+		}
+		if v.tagKeys != nil {
+			for _, k := range v.tagKeys() {
+				names = append(names, k+"=")
+			}
+		}
+		return names
+	}
+	f, fetches := v.findField(kv[0])
+	if f == nil || fetches {
+		// completing values would fetch them for every element
+		return nil
+	}
+	seen := make(map[string]bool)
+	var values []string
+	for i := 0; i < v.model.length(); i++ {
+		e, _ := v.model.get(i)
+		for _, val := range f.values(e) {
+			if !seen[val] {
+				seen[val] = true
+				values = append(values, kv[0]+"="+val)
+			}
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+func (u *ui) runColumnsCommand(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: %s", u.findCommand("columns").usage)
+	}
+	v, err := u.focusedList()
+	if err != nil {
+		return err
+	}
+	var names []string
+	if len(args) == 1 {
+		names = strings.Split(args[0], ",")
+	}
+	return v.setColumns(names)
+}
+
+func (u *ui) completeColumnsCommand(args []string) []string {
+	v, err := u.focusedList()
+	if err != nil || len(args) != 1 || v.columnSet == nil {
+		return nil
+	}
+	prefix := ""
+	if i := strings.LastIndex(args[0], ","); i >= 0 {
+		prefix = args[0][:i+1]
+	}
+	var ret []string
+	for _, n := range v.columnSet.names() {
+		ret = append(ret, prefix+n)
+	}
+	return ret
+}
+
+func (u *ui) runExportCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: %s", u.findCommand("export").usage)
+	}
+	v, err := u.focusedList()
+	if err != nil {
+		return err
+	}
+	es := v.model.all()
+	if err := exportCSV(args[0], es); err != nil {
+		return err
+	}
+	u.baseView.showMessage(fmt.Sprintf("exported %d rows to %s", len(es), args[0]))
+	return nil
+}
@@ -0,0 +1,217 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+	"github.com/eihigh/goban"
+	"github.com/gdamore/tcell"
+	"github.com/lusingander/ecr-browser/compare"
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/layout"
+)
+
+const (
+	compareListViewTitle = "COMPARE"
+	compareBreadcrumb    = "COMPARE"
+)
+
+// compareMark is the image marked to be compared with the next one, in any repository.
+var compareMark *compare.Side
+
+func imageManifestClient() (domain.ImageManifestClient, error) {
+	if c, ok := client.(domain.ImageManifestClient); ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("current client does not support reading image manifests")
+}
+
+// markOrCompare marks img, or compares the marked image with it.
+func (u *ui) markOrCompare(repo string, img *domain.Image) {
+	if img == nil {
+		return
+	}
+	if compareMark == nil || compareMark.Image == img {
+		if compareMark != nil {
+			compareMark = nil
+			u.baseView.showMessage("unmarked")
+			return
+		}
+		compareMark = &compare.Side{Repository: repo, Image: img}
+		u.baseView.showMessage(fmt.Sprintf("marked %s, press v on another image to compare", compareMark.Name()))
+		return
+	}
+	from := compareMark
+	compareMark = nil
+	u.showError(u.loadCompareViews(&compare.Side{Repository: from.Repository, Image: from.Image}, &compare.Side{Repository: repo, Image: img}))
+}
+
+// compareSection is a part of the comparison, listed with a summary and detailed on selection.
+type compareSection struct {
+	name    string
+	summary string
+	lines   []string
+}
+
+func (s *compareSection) Display() string {
+	return fmt.Sprintf("%-8s %s", s.name, s.summary)
+}
+
+func compareSections(r *compare.Result) []listViewElement {
+	added, removed, shared := r.LayerCounts()
+	layerSummary := fmt.Sprintf("+%d -%d =%d", added, removed, shared)
+	return []listViewElement{
+		&compareSection{"SUMMARY", sizeDeltaStr(r.SizeDelta), summaryLines(r, layerSummary)},
+		&compareSection{"TAGS", changesStr(compare.Changes(r.Tags)), valueChangeLines(r.Tags)},
+		&compareSection{"LAYERS", layerSummary, layerChangeLines(r.Layers)},
+		&compareSection{"CONFIG", changesStr(compare.Changes(r.Config)), valueChangeLines(r.Config)},
+		&compareSection{"ENV", changesStr(compare.Changes(r.Env)), valueChangeLines(r.Env)},
+		&compareSection{"LABELS", changesStr(compare.Changes(r.Labels)), valueChangeLines(r.Labels)},
+		&compareSection{"HISTORY", changesStr(r.HistoryChanges()), colorDiffLines(r.History)},
+	}
+}
+
+func summaryLines(r *compare.Result, layerSummary string) []string {
+	side := func(title string, s *compare.Side) []string {
+		return []string{
+			title + ":",
+			"  " + s.Name(),
+			"  " + s.Image.Digest,
+			"  pushed " + s.Image.PushedAtStr(),
+			"  " + s.Image.SizeStr(),
+		}
+	}
+	ls := append(side("FROM", r.From), side("TO", r.To)...)
+	return append(ls,
+		"SIZE DELTA:",
+		"  "+sizeDeltaStr(r.SizeDelta),
+		"LAYERS (ADDED REMOVED SHARED):",
+		fmt.Sprintf("  %s, %s shared", layerSummary, humanize.Bytes(uint64(r.SharedSize()))),
+	)
+}
+
+func valueChangeLines(cs []*compare.ValueChange) []string {
+	if len(cs) == 0 {
+		return []string{"  " + noValue}
+	}
+	ls := make([]string, len(cs))
+	for i, c := range cs {
+		ls[i] = c.String()
+	}
+	return colorDiffLines(ls)
+}
+
+func layerChangeLines(cs []*compare.LayerChange) []string {
+	if len(cs) == 0 {
+		return []string{"  " + noValue}
+	}
+	ls := make([]string, len(cs))
+	for i, c := range cs {
+		ls[i] = fmt.Sprintf("%s %s %8s", c.Status.Mark(), c.Layer.ShortDigest(), c.Layer.SizeStr())
+	}
+	return colorDiffLines(ls)
+}
+
+func changesStr(n int) string {
+	if n == 0 {
+		return "no changes"
+	}
+	if n == 1 {
+		return "1 change"
+	}
+	return fmt.Sprintf("%d changes", n)
+}
+
+func sizeDeltaStr(d int64) string {
+	if d < 0 {
+		return "-" + humanize.Bytes(uint64(-d))
+	}
+	return "+" + humanize.Bytes(uint64(d))
+}
+
+type compareListView struct {
+	*listViewBase
+	result *compare.Result
+}
+
+func newCompareListView(b *goban.Box, r *compare.Result) *compareListView {
+	return &compareListView{
+		listViewBase: &listViewBase{
+			box:   b,
+			model: newListModel(compareSections(r)),
+			title: compareListViewTitle,
+		},
+		result: r,
+	}
+}
+
+func (v *compareListView) operate(key *tcell.EventKey) {
+	dispatch(v.keyBindings(), key)
+}
+
+func (v *compareListView) keyBindings() []*keyBindingGroup {
+	return append([]*keyBindingGroup{
+		{
+			title: compareListViewTitle,
+			bindings: []*keyBinding{
+				{runes: []rune{'x'}, desc: "swap images", action: v.swap},
+				{runes: []rune{'h'}, desc: "move to image list", action: func() { v.ui.loadImageViews(v.result.To.Repository) }},
+			},
+		},
+	}, v.listViewBase.keyBindings()...)
+}
+
+func (v *compareListView) swap() {
+	r := v.result
+	v.ui.showError(v.ui.showCompareViews(compare.Compare(r.To, r.From)))
+}
+
+type compareDetailView struct {
+	*detailViewBase
+}
+
+func newCompareDetailView(b *goban.Box) *compareDetailView {
+	return &compareDetailView{newDetailViewBase(b)}
+}
+
+func (v *compareDetailView) update(e listViewElement) {
+	if s, ok := e.(*compareSection); ok {
+		v.SetLines(s.lines)
+		return
+	}
+	v.SetLines(nil)
+}
+
+// loadCompareViews fetches the manifests and configs of both images and compares them.
+func (u *ui) loadCompareViews(from, to *compare.Side) error {
+	c, err := imageManifestClient()
+	if err != nil {
+		return err
+	}
+	loading := layout.NewLoadingDialog(u.baseView.base, u.baseView.es)
+	go loading.Display()
+	from.Manifest, err = c.FetchImageManifest(from.Repository, from.Image)
+	if err == nil {
+		to.Manifest, err = c.FetchImageManifest(to.Repository, to.Image)
+	}
+	loading.Close()
+	if err != nil {
+		return err
+	}
+	return u.showCompareViews(compare.Compare(from, to))
+}
+
+func (u *ui) showCompareViews(r *compare.Result) error {
+	lv := newCompareListView(u.baseView.gridLayout.list, r)
+	dv := newCompareDetailView(u.baseView.gridLayout.detail)
+	lv.addObserver(dv)
+	lv.setBaseUI(u)
+	u.baseView.resetBreadcrumb()
+	u.popViews()
+	u.pushViews(lv, dv)
+	u.setPanes(lv, dv)
+	u.baseView.pushBreadcrumb(r.To.Repository)
+	u.baseView.pushBreadcrumb(compareBreadcrumb)
+	u.baseView.showMessage(fmt.Sprintf("%s -> %s", r.From.Name(), r.To.Name()))
+	return nil
+}
@@ -0,0 +1,305 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/eihigh/goban"
+	"github.com/gdamore/tcell"
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/layout"
+)
+
+const (
+	copyListViewTitle = "COPY"
+	copyBreadcrumb    = "COPY"
+	copyImageTitle    = "COPY IMAGE"
+	copyTransferTitle = "uploading blobs..."
+)
+
+const (
+	copyFieldRepository = iota
+	copyFieldTag
+	copyFieldRegion
+	copyFieldRegistryId
+)
+
+const (
+	copyStatusExists = "exists"
+	copyStatusUpload = "upload"
+	copyStatusDone   = "done"
+)
+
+func imageCopyClient() (domain.ImageCopyClient, error) {
+	if c, ok := client.(domain.ImageCopyClient); ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("current client does not support copying images")
+}
+
+func copyFields(repo string, img *domain.Image, dst *domain.CopyDestination) []*layout.FormField {
+	region := currentRegion()
+	var regions []string
+	if c, ok := client.(domain.SessionClient); ok {
+		regions = c.Regions()
+	}
+	if dst == nil {
+		var tag string
+		if len(img.Tags) > 0 {
+			tag = img.Tags[0]
+		}
+		dst = &domain.CopyDestination{Repository: repo, Tag: tag}
+	}
+	if dst.Region != "" {
+		region = dst.Region
+	}
+	return []*layout.FormField{
+		copyFieldRepository: {Label: "repository", Value: dst.Repository},
+		copyFieldTag:        {Label: "tag", Value: dst.Tag},
+		copyFieldRegion:     {Label: "region", Value: region, Options: regions},
+		copyFieldRegistryId: {Label: "registry id", Value: dst.RegistryId},
+	}
+}
+
+// copyDestinationFromFields reads the destination, leaving the region empty if it is the current one.
+func copyDestinationFromFields(fs []*layout.FormField) *domain.CopyDestination {
+	dst := &domain.CopyDestination{
+		Repository: strings.TrimSpace(fs[copyFieldRepository].Value),
+		Tag:        strings.TrimSpace(fs[copyFieldTag].Value),
+		Region:     strings.TrimSpace(fs[copyFieldRegion].Value),
+		RegistryId: strings.TrimSpace(fs[copyFieldRegistryId].Value),
+	}
+	if dst.Region == currentRegion() {
+		dst.Region = ""
+	}
+	return dst
+}
+
+// copyImage asks for the destination of img and shows what copying it transfers.
+// dst is the destination to edit, or nil to start from the source repository.
+func (u *ui) copyImage(repo string, img *domain.Image, dst *domain.CopyDestination) {
+	if img == nil {
+		return
+	}
+	c, err := imageCopyClient()
+	if err != nil {
+		u.showError(err)
+		return
+	}
+	fields := copyFields(repo, img, dst)
+	for {
+		if !layout.NewFormDialog(u.baseView.base, u.baseView.es, copyImageTitle, fields).Display() {
+			return
+		}
+		plan, err := u.planImageCopy(c, repo, img, copyDestinationFromFields(fields))
+		if err == nil {
+			u.showCopyViews(plan)
+			return
+		}
+		if !u.confirmRetry(err) {
+			return
+		}
+	}
+}
+
+func (u *ui) planImageCopy(c domain.ImageCopyClient, repo string, img *domain.Image, dst *domain.CopyDestination) (*domain.CopyPlan, error) {
+	loading := layout.NewLoadingDialog(u.baseView.base, u.baseView.es)
+	go loading.Display()
+	defer loading.Close()
+	return c.PlanImageCopy(repo, img, dst)
+}
+
+// copyBlob is a blob of the image shown in the copy view.
+type copyBlob struct {
+	*domain.CopyBlob
+	uploaded bool
+}
+
+func (b *copyBlob) Display() string {
+	return b.ShortDigest()
+}
+
+func (b *copyBlob) status() string {
+	switch {
+	case b.uploaded:
+		return copyStatusDone
+	case b.Exists:
+		return copyStatusExists
+	default:
+		return copyStatusUpload
+	}
+}
+
+var (
+	copyColumns = &columnSet{[]*listColumn{
+		{"kind", func(e listViewElement) listCell { return textCell(e.(*copyBlob).Kind) }},
+		{"digest", func(e listViewElement) listCell { return textCell(e.(*copyBlob).ShortDigest()) }},
+		{"size", func(e listViewElement) listCell { return numberCell(e.(*copyBlob).SizeStr()) }},
+		{"status", func(e listViewElement) listCell { return textCell(e.(*copyBlob).status()) }},
+		{"media", func(e listViewElement) listCell { return textCell(e.(*copyBlob).MediaType) }},
+	}, []string{"kind", "digest", "size", "status"}}
+)
+
+type copyListView struct {
+	*listViewBase
+	plan   *domain.CopyPlan
+	blobs  []*copyBlob
+	copied bool
+}
+
+func newCopyListView(b *goban.Box, plan *domain.CopyPlan) *copyListView {
+	blobs := make([]*copyBlob, len(plan.Blobs))
+	elems := make([]listViewElement, len(plan.Blobs))
+	for i, cb := range plan.Blobs {
+		blobs[i] = &copyBlob{CopyBlob: cb}
+		elems[i] = blobs[i]
+	}
+	return &copyListView{
+		listViewBase: &listViewBase{
+			box:       b,
+			model:     newListModel(elems),
+			columnSet: copyColumns,
+			columns:   copyColumns.defaultColumns(),
+			title:     copyListViewTitle + " to " + plan.Destination.String(),
+		},
+		plan:  plan,
+		blobs: blobs,
+	}
+}
+
+func (v *copyListView) operate(key *tcell.EventKey) {
+	dispatch(v.keyBindings(), key)
+}
+
+func (v *copyListView) keyBindings() []*keyBindingGroup {
+	return append([]*keyBindingGroup{
+		{
+			title: copyListViewTitle,
+			bindings: []*keyBinding{
+				{runes: []rune{'x'}, desc: "copy the image", action: v.copy},
+				{runes: []rune{'e'}, desc: "edit destination", action: func() { v.ui.copyImage(v.plan.Repository, v.plan.Image, v.plan.Destination) }},
+				{runes: []rune{'h'}, desc: "move to image list", action: func() { v.ui.showError(v.ui.loadImageViews(v.plan.Repository)) }},
+			},
+		},
+	}, v.listViewBase.keyBindings()...)
+}
+
+// transferStr is what copying plan uploads and skips.
+func transferStr(plan *domain.CopyPlan) string {
+	n, size := plan.Transfer()
+	m, skipped := plan.Skipped()
+	return fmt.Sprintf("upload %d blobs (%s), skip %d already in destination (%s)", n, humanize.Bytes(uint64(size)), m, humanize.Bytes(uint64(skipped)))
+}
+
+func (v *copyListView) copy() {
+	if v.copied {
+		v.ui.showMessage("already copied")
+		return
+	}
+	c, err := imageCopyClient()
+	if err != nil {
+		v.ui.showError(err)
+		return
+	}
+	lines := []string{
+		fmt.Sprintf("Copy %s:%s to %s?", v.plan.Repository, v.plan.Image.GetTag(), v.plan.Destination),
+		"",
+		transferStr(v.plan),
+	}
+	if !layout.NewConfirmDialog(v.ui.baseView.base, v.ui.baseView.es, copyImageTitle, lines).Display() {
+		return
+	}
+	if err := v.transfer(c); err != nil {
+		v.ui.showError(err)
+		return
+	}
+	v.copied = true
+	for _, b := range v.blobs {
+		b.uploaded = !b.Exists
+	}
+	v.notify()
+	v.ui.showMessage(fmt.Sprintf("copied to %s", v.plan.Destination))
+}
+
+// transfer copies the image, with a bar for each blob to upload.
+func (v *copyListView) transfer(c domain.ImageCopyClient) error {
+	rows := make(map[int]int)
+	var labels []string
+	var totals []int64
+	for i, b := range v.plan.Blobs {
+		if !b.Exists {
+			rows[i] = len(labels)
+			labels = append(labels, b.Kind+" "+b.ShortDigest())
+			totals = append(totals, b.SizeByte)
+		}
+	}
+	if len(labels) == 0 {
+		loading := layout.NewLoadingDialog(v.ui.baseView.base, v.ui.baseView.es)
+		go loading.Display()
+		defer loading.Close()
+		return c.CopyImage(v.plan, func(int, int64) {})
+	}
+	progress := layout.NewTransferDialog(v.ui.baseView.base, v.ui.baseView.es, copyTransferTitle, labels, totals)
+	go progress.Display()
+	defer progress.Close()
+	return c.CopyImage(v.plan, func(i int, uploaded int64) { progress.Set(rows[i], uploaded) })
+}
+
+type copyDetailView struct {
+	*detailViewBase
+	plan     *domain.CopyPlan
+	selected *copyBlob
+}
+
+func newCopyDetailView(b *goban.Box, plan *domain.CopyPlan) *copyDetailView {
+	return &copyDetailView{newDetailViewBase(b), plan, nil}
+}
+
+func (v *copyDetailView) update(e listViewElement) {
+	v.selected, _ = e.(*copyBlob)
+	v.SetLines(v.lines())
+}
+
+func (v *copyDetailView) lines() []string {
+	ls := []string{
+		"SOURCE:",
+		"  " + v.plan.Repository + ":" + v.plan.Image.GetTag(),
+		"DESTINATION:",
+		"  " + v.plan.Destination.String(),
+		"MANIFESTS:",
+	}
+	for _, m := range v.plan.Manifests {
+		ls = append(ls, "  "+m.Digest)
+	}
+	if v.selected == nil {
+		return ls
+	}
+	return append(ls,
+		"DIGEST:",
+		"  "+v.selected.Digest,
+		"KIND:",
+		"  "+v.selected.Kind,
+		"SIZE:",
+		"  "+v.selected.SizeStr(),
+		"MEDIA TYPE:",
+		"  "+valueOrNone(v.selected.MediaType),
+		"STATUS:",
+		"  "+v.selected.status(),
+	)
+}
+
+func (u *ui) showCopyViews(plan *domain.CopyPlan) {
+	lv := newCopyListView(u.baseView.gridLayout.list, plan)
+	dv := newCopyDetailView(u.baseView.gridLayout.detail, plan)
+	lv.addObserver(dv)
+	lv.setBaseUI(u)
+	u.baseView.resetBreadcrumb()
+	u.popViews()
+	u.pushViews(lv, dv)
+	u.setPanes(lv, dv)
+	u.baseView.pushBreadcrumb(plan.Repository)
+	u.baseView.pushBreadcrumb(plan.Image.GetTag())
+	u.baseView.pushBreadcrumb(copyBreadcrumb)
+	u.baseView.showMessage(transferStr(plan))
+}
@@ -0,0 +1,213 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/eihigh/goban"
+	"github.com/gdamore/tcell"
+	"github.com/lusingander/ecr-browser/cost"
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/layout"
+	"github.com/lusingander/ecr-browser/usage"
+	"github.com/lusingander/ecr-browser/util"
+)
+
+const (
+	dashboardListViewTitle = "STORAGE"
+	dashboardBreadcrumb    = "DASHBOARD"
+	dashboardProgress      = "Fetching images..."
+	dashboardTopImages     = 10
+	dashboardWorkers       = 8
+	dashboardListBarWidth  = 10
+	dashboardBarWidth      = 20
+)
+
+var (
+	dashboardSorters = []*listSorter{
+		{"size", func(a, b listViewElement) bool {
+			return a.(*usage.RepositoryUsage).SizeByte < b.(*usage.RepositoryUsage).SizeByte
+		}, true},
+		{"untagged", func(a, b listViewElement) bool {
+			return a.(*usage.RepositoryUsage).UntaggedByte < b.(*usage.RepositoryUsage).UntaggedByte
+		}, true},
+		{"images", func(a, b listViewElement) bool {
+			return a.(*usage.RepositoryUsage).Images < b.(*usage.RepositoryUsage).Images
+		}, true},
+		{"cost", func(a, b listViewElement) bool {
+			return a.(*usage.RepositoryUsage).StoredByte < b.(*usage.RepositoryUsage).StoredByte
+		}, true},
+		{"name", func(a, b listViewElement) bool {
+			return a.(*usage.RepositoryUsage).Name < b.(*usage.RepositoryUsage).Name
+		}, false},
+	}
+)
+
+// dashboardColumns is built for each report, since the bars are scaled to its largest repository.
+func dashboardColumns(r *usage.Report) *columnSet {
+	var max int64
+	if len(r.Repositories) > 0 {
+		max = r.Repositories[0].SizeByte
+	}
+	return &columnSet{[]*listColumn{
+		{"name", func(e listViewElement) listCell { return textCell(e.(*usage.RepositoryUsage).Name) }},
+		{"images", func(e listViewElement) listCell { return numberCell(strconv.Itoa(e.(*usage.RepositoryUsage).Images)) }},
+		{"size", func(e listViewElement) listCell {
+			return numberCell(humanize.Bytes(uint64(e.(*usage.RepositoryUsage).SizeByte)))
+		}},
+		{"untagged", func(e listViewElement) listCell {
+			return numberCell(humanize.Bytes(uint64(e.(*usage.RepositoryUsage).UntaggedByte)))
+		}},
+		{"cost", func(e listViewElement) listCell {
+			return numberCell(cost.Format(cost.Monthly(e.(*usage.RepositoryUsage).StoredByte, currentRegion())))
+		}},
+		{"bar", func(e listViewElement) listCell {
+			return textCell(util.Bar(e.(*usage.RepositoryUsage).SizeByte, max, dashboardListBarWidth))
+		}},
+	}, []string{"name", "size", "cost", "bar"}}
+}
+
+type dashboardListView struct {
+	*listViewBase
+}
+
+func newDashboardListView(b *goban.Box, r *usage.Report) *dashboardListView {
+	elems := make([]listViewElement, len(r.Repositories))
+	for i, u := range r.Repositories {
+		elems[i] = u
+	}
+	cs := dashboardColumns(r)
+	return &dashboardListView{
+		listViewBase: &listViewBase{
+			box:       b,
+			model:     newListModel(elems, dashboardSorters...),
+			columnSet: cs,
+			columns:   cs.defaultColumns(),
+			title:     dashboardListViewTitle,
+		},
+	}
+}
+
+func (v *dashboardListView) operate(key *tcell.EventKey) {
+	dispatch(v.keyBindings(), key)
+}
+
+func (v *dashboardListView) keyBindings() []*keyBindingGroup {
+	return append([]*keyBindingGroup{
+		{
+			title: dashboardListViewTitle,
+			bindings: []*keyBinding{
+				{runes: []rune{'l'}, desc: "move to image list", action: func() { v.ui.loadImageViews(v.currentRepositoryName()) }},
+				{runes: []rune{'h'}, desc: "move to repository list", action: func() { v.ui.loadRepositoryView(false) }},
+				{runes: []rune{'r'}, desc: "reload", action: func() { v.ui.showError(v.ui.loadDashboardViews()) }},
+			},
+		},
+	}, v.listViewBase.keyBindings()...)
+}
+
+func (v *dashboardListView) currentRepositoryName() string {
+	if u, ok := v.current().(*usage.RepositoryUsage); ok {
+		return u.Name
+	}
+	return ""
+}
+
+type dashboardDetailView struct {
+	*detailViewBase
+	report   *usage.Report
+	selected *usage.RepositoryUsage
+}
+
+func newDashboardDetailView(b *goban.Box, r *usage.Report) *dashboardDetailView {
+	return &dashboardDetailView{newDetailViewBase(b), r, nil}
+}
+
+func (v *dashboardDetailView) update(e listViewElement) {
+	v.selected, _ = e.(*usage.RepositoryUsage)
+	v.SetLines(v.lines())
+}
+
+func (v *dashboardDetailView) lines() []string {
+	var ls []string
+	if v.selected != nil {
+		ls = append(ls,
+			"REPOSITORY:",
+			"  "+v.selected.Name,
+			"SIZE:",
+			fmt.Sprintf("  %s (%s of total)", humanize.Bytes(uint64(v.selected.SizeByte)), percentStr(v.selected.SizeByte, v.report.TotalByte)),
+			"UNTAGGED:",
+			"  "+humanize.Bytes(uint64(v.selected.UntaggedByte)),
+			"ESTIMATED COST:",
+			"  "+monthlyCostStr(v.selected.StoredByte),
+			"",
+		)
+	}
+	r := v.report
+	ls = append(ls,
+		"TOTAL:",
+		fmt.Sprintf("  %s in %d images, %d repositories", humanize.Bytes(uint64(r.TotalByte)), r.Images, len(r.Repositories)),
+		"ESTIMATED COST TOTAL:",
+		"  "+monthlyCostStr(r.StoredByte),
+		"UNTAGGED TOTAL:",
+		fmt.Sprintf("  %s (%s of total)", humanize.Bytes(uint64(r.UntaggedByte)), percentStr(r.UntaggedByte, r.TotalByte)),
+		fmt.Sprintf("LARGEST %d IMAGES:", dashboardTopImages),
+	)
+	for _, u := range r.Largest {
+		ls = append(ls, fmt.Sprintf("  %8s  %s:%s", u.Image.SizeStr(), u.Repository, u.Image.GetTag()))
+	}
+	ls = append(ls, fmt.Sprintf("PUSHES IN THE LAST %d DAYS (PER %d DAYS):", usage.PushHistoryDays, usage.PushBucketDays))
+	max := r.MaxPushes()
+	for _, b := range r.Pushes {
+		ls = append(ls, fmt.Sprintf("  %s %4d %s", domain.FormatDate(b.From), b.Count, util.Bar(int64(b.Count), int64(max), dashboardBarWidth)))
+	}
+	return ls
+}
+
+func monthlyCostStr(storedByte int64) string {
+	return fmt.Sprintf("%s / month (%s stored)", cost.Format(cost.Monthly(storedByte, currentRegion())), humanize.Bytes(uint64(storedByte)))
+}
+
+func percentStr(n, total int64) string {
+	if total == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total))
+}
+
+// loadDashboardViews fetches the images of all repositories, a few at a time, and summarizes them.
+func (u *ui) loadDashboardViews() error {
+	repos, err := client.FetchAllRepositories()
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(repos))
+	for i, repo := range repos {
+		names[i] = repo.Name
+	}
+
+	progress := layout.NewProgressDialog(u.baseView.base, u.baseView.es, dashboardProgress, len(names))
+	go progress.Display()
+	imgs, err := usage.Fetch(client.FetchAllImages, names, dashboardWorkers, func(done, _ int) { progress.Set(done) })
+	progress.Close()
+	if err != nil {
+		return err
+	}
+	for repo, is := range imgs {
+		recordImages(repo, is)
+	}
+	r := usage.Summarize(imgs, dashboardTopImages, time.Now())
+
+	lv := newDashboardListView(u.baseView.gridLayout.list, r)
+	dv := newDashboardDetailView(u.baseView.gridLayout.detail, r)
+	lv.addObserver(dv)
+	lv.setBaseUI(u)
+	u.baseView.resetBreadcrumb()
+	u.popViews()
+	u.pushViews(lv, dv)
+	u.setPanes(lv, dv)
+	u.baseView.pushBreadcrumb(dashboardBreadcrumb)
+	u.baseView.showMessage(fmt.Sprintf("%s in %d repositories, %s untagged, %s / month", humanize.Bytes(uint64(r.TotalByte)), len(r.Repositories), humanize.Bytes(uint64(r.UntaggedByte)), cost.Format(cost.Monthly(r.StoredByte, currentRegion()))))
+	return nil
+}
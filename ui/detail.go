@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"github.com/eihigh/goban"
+	"github.com/gdamore/tcell"
+	"github.com/lusingander/ecr-browser/layout"
+)
+
+const (
+	detailViewTitle = "DETAIL"
+)
+
+type detailViewBase struct {
+	*layout.ScrollView
+}
+
+func newDetailViewBase(b *goban.Box) *detailViewBase {
+	return &detailViewBase{layout.NewScrollView(b, detailViewTitle)}
+}
+
+func (v *detailViewBase) operate(key *tcell.EventKey) {
+	dispatch(v.keyBindings(), key)
+}
+
+func (v *detailViewBase) keyBindings() []*keyBindingGroup {
+	return []*keyBindingGroup{
+		{
+			title: detailViewTitle,
+			bindings: []*keyBinding{
+				{runes: []rune{'j'}, desc: "scroll down", action: v.ScrollDown},
+				{runes: []rune{'k'}, desc: "scroll up", action: v.ScrollUp},
+				{runes: []rune{'g'}, desc: "scroll to the top", action: v.ScrollTop},
+				{runes: []rune{'G'}, desc: "scroll to the bottom", action: v.ScrollBottom},
+			},
+		},
+	}
+}
+
+func (v *detailViewBase) setFocus(focused bool) {
+	v.SetFocus(focused)
+}
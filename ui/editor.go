@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/eihigh/goban"
+	"github.com/lusingander/ecr-browser/layout"
+	"github.com/lusingander/ecr-browser/util"
+)
+
+const (
+	defaultEditor = "vi"
+	invalidTitle  = "INVALID INPUT"
+)
+
+// externalCommand is run with the screen suspended.
+// then is called with the result once the app has resumed.
+type externalCommand struct {
+	cmd  *exec.Cmd
+	then func(error)
+	err  error
+}
+
+// suspend leaves the screen, runs cmd on the terminal and calls then on return.
+func (u *ui) suspend(cmd *exec.Cmd, then func(error)) {
+	u.external = &externalCommand{cmd: cmd, then: then}
+}
+
+func (u *ui) runExternal() {
+	e := u.external
+	e.cmd.Stdin = os.Stdin
+	e.cmd.Stdout = os.Stdout
+	e.cmd.Stderr = os.Stderr
+	e.err = e.cmd.Run()
+}
+
+func (u *ui) resume(es goban.Events) {
+	u.baseView.es = es
+	e := u.external
+	u.external = nil
+	if e != nil {
+		goban.Show()
+		e.then(e.err)
+	}
+}
+
+func editorCommand(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = defaultEditor
+	}
+	args := strings.Fields(editor)
+	return exec.Command(args[0], append(args[1:], path)...)
+}
+
+// editText opens text in $EDITOR and calls then with the saved content.
+func (u *ui) editText(text, pattern string, then func(string, error)) {
+	f, err := ioutil.TempFile("", pattern)
+	if err != nil {
+		then("", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.WriteString(text); err != nil {
+		os.Remove(f.Name())
+		then("", err)
+		return
+	}
+	u.suspend(editorCommand(f.Name()), func(err error) {
+		defer os.Remove(f.Name())
+		if err != nil {
+			then("", err)
+			return
+		}
+		b, err := ioutil.ReadFile(f.Name())
+		then(string(b), err)
+	})
+}
+
+// confirmRetry shows the validation errors in err and asks whether to edit again.
+func (u *ui) confirmRetry(err error) bool {
+	lines := append(strings.Split(err.Error(), "; "), "", "Edit again?")
+	return layout.NewConfirmDialog(u.baseView.base, u.baseView.es, invalidTitle, lines).Display()
+}
+
+// coloredDiff returns the line diff from one text to another, colored for a ConfirmDialog.
+// An empty text has no lines.
+func coloredDiff(from, to string) []string {
+	split := func(s string) []string {
+		if s == "" {
+			return nil
+		}
+		return strings.Split(s, "\n")
+	}
+	return colorDiffLines(util.DiffLines(split(from), split(to)))
+}
+
+// colorDiffLines colors the lines marked added ("+ "), removed ("- ") or changed ("~ ").
+func colorDiffLines(lines []string) []string {
+	ret := make([]string, len(lines))
+	for i, l := range lines {
+		switch {
+		case strings.HasPrefix(l, "+ "):
+			ret[i] = "\x1b[32m" + l + "\x1b[0m"
+		case strings.HasPrefix(l, "- "):
+			ret[i] = "\x1b[31m" + l + "\x1b[0m"
+		case strings.HasPrefix(l, "~ "):
+			ret[i] = "\x1b[33m" + l + "\x1b[0m"
+		default:
+			ret[i] = l
+		}
+	}
+	return ret
+}
@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lusingander/ecr-browser/cleanup"
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+var (
+	imageCSVHeader      = []string{"tags", "pushed_at", "digest", "size_bytes", "scan_status", "last_pulled_at", "manifest_media_type", "artifact_media_type"}
+	cleanupCSVHeader    = []string{"selected", "tags", "digest", "pushed_at", "last_pulled_at", "size_bytes", "reasons"}
+	repositoryCSVHeader = []string{"name", "uri", "arn", "tag_mutability", "scan_on_push", "encryption_type", "kms_key", "created_at"}
+)
+
+func exportCSV(name string, es []listViewElement) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	for i, e := range es {
+		switch e := e.(type) {
+		case *domain.Image:
+			if i == 0 {
+				w.Write(imageCSVHeader)
+			}
+			w.Write([]string{
+				strings.Join(e.Tags, " "),
+				e.PushedAt.Format(time.RFC3339),
+				e.Digest,
+				strconv.FormatInt(e.SizeByte, 10),
+				e.ScanStatus,
+				formatCSVTime(e.LastPulledAt),
+				e.ManifestMediaType,
+				e.ArtifactMediaType,
+			})
+		case *cleanup.Candidate:
+			if i == 0 {
+				w.Write(cleanupCSVHeader)
+			}
+			w.Write([]string{
+				strconv.FormatBool(e.Selected),
+				strings.Join(e.Image.Tags, " "),
+				e.Image.Digest,
+				e.Image.PushedAt.Format(time.RFC3339),
+				formatCSVTime(e.Image.LastPulledAt),
+				strconv.FormatInt(e.Image.SizeByte, 10),
+				strings.Join(e.Reasons, "; "),
+			})
+		case *domain.Repository:
+			if i == 0 {
+				w.Write(repositoryCSVHeader)
+			}
+			w.Write([]string{
+				e.Name,
+				e.Uri,
+				e.Arn,
+				e.TagMutability,
+				strconv.FormatBool(e.ScanOnPush),
+				e.EncryptionType,
+				e.KmsKey,
+				e.CreatedAt.Format(time.RFC3339),
+			})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// formatCSVTime leaves unknown times empty.
+func formatCSVTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,296 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dustin/go-humanize"
+	"github.com/eihigh/goban"
+	"github.com/gdamore/tcell"
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/layerfs"
+	"github.com/lusingander/ecr-browser/layout"
+)
+
+const (
+	layerFileListViewTitle = "LAYER FILES"
+	imageFileListViewTitle = "IMAGE FILES"
+	layerFileBreadcrumb    = "FILES"
+	layerDownloadProgress  = "Downloading layers..."
+)
+
+// layerEntries holds the indexed files of the layers read so far, by digest.
+var layerEntries = make(map[string][]*layerfs.Entry)
+
+var layerCache *layerfs.Cache
+
+func layerBlobClient() (domain.LayerBlobClient, error) {
+	if c, ok := client.(domain.LayerBlobClient); ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("current client does not support downloading layers")
+}
+
+// readLayer indexes the files of a layer, downloading its blob to the disk cache first.
+func readLayer(repo string, l *domain.Layer) ([]*layerfs.Entry, error) {
+	if es, ok := layerEntries[l.Digest]; ok {
+		return es, nil
+	}
+	c, err := layerBlobClient()
+	if err != nil {
+		return nil, err
+	}
+	if layerCache == nil {
+		dir, err := layerfs.DefaultCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		layerCache = layerfs.NewCache(dir)
+	}
+	rc, err := layerCache.Open(l.Digest, func() (io.ReadCloser, error) { return c.FetchLayerBlob(repo, l.Digest) })
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	es, err := layerfs.Read(rc)
+	if err != nil {
+		return nil, err
+	}
+	layerEntries[l.Digest] = es
+	return es, nil
+}
+
+// fileNode is a file shown in the file list.
+type fileNode struct {
+	*layerfs.Node
+}
+
+func (n *fileNode) Display() string {
+	return n.displayName()
+}
+
+func (n *fileNode) displayName() string {
+	e := n.Entry
+	switch {
+	case e != nil && e.Whiteout:
+		return n.Name + " (deleted)"
+	case e != nil && e.Opaque:
+		return n.Name + "/ (replaced)"
+	case n.IsDir():
+		return n.Name + "/"
+	case e.Type == layerfs.TypeSymlink:
+		return n.Name + " -> " + e.Linkname
+	case e.Type == layerfs.TypeHardlink:
+		return n.Name + " => /" + e.Linkname
+	default:
+		return n.Name
+	}
+}
+
+func (n *fileNode) modeStr() string {
+	if n.Entry == nil || n.Entry.Whiteout {
+		return noValue
+	}
+	return n.Entry.Mode.String()
+}
+
+// fileListView lists a directory of a layer, or of the image filesystem merged from all layers.
+type fileListView struct {
+	*listViewBase
+	repository string
+	image      *domain.Image
+	manifest   *domain.Manifest
+	dir        *layerfs.Node
+	merged     bool
+}
+
+func newFileListView(b *goban.Box, repo string, img *domain.Image, m *domain.Manifest, root *layerfs.Node, merged bool) *fileListView {
+	v := &fileListView{
+		listViewBase: &listViewBase{box: b},
+		repository:   repo,
+		image:        img,
+		manifest:     m,
+		merged:       merged,
+	}
+	v.columnSet = v.fileColumns()
+	v.columns = v.columnSet.defaultColumns()
+	v.setDir(root)
+	return v
+}
+
+func (v *fileListView) fileColumns() *columnSet {
+	all := []*listColumn{
+		{"name", func(e listViewElement) listCell { return textCell(e.(*fileNode).displayName()) }},
+		{"size", func(e listViewElement) listCell { return numberCell(humanize.Bytes(uint64(e.(*fileNode).Size()))) }},
+		{"mode", func(e listViewElement) listCell { return textCell(e.(*fileNode).modeStr()) }},
+		{"layer", func(e listViewElement) listCell { return numberCell(fmt.Sprintf("#%d", e.(*fileNode).Layer)) }},
+	}
+	if v.merged {
+		return &columnSet{all, []string{"name", "size", "layer"}}
+	}
+	return &columnSet{all[:3], []string{"name", "size", "mode"}}
+}
+
+func (v *fileListView) setDir(dir *layerfs.Node) {
+	cs := dir.Children()
+	elems := make([]listViewElement, len(cs))
+	for i, c := range cs {
+		elems[i] = &fileNode{c}
+	}
+	v.dir = dir
+	v.model = newListModel(elems)
+	v.title = layerFileListViewTitle
+	if v.merged {
+		v.title = imageFileListViewTitle
+	}
+	v.title += " " + dir.Path()
+	v.cur, v.viewTop = 0, 0
+	v.notify()
+}
+
+func (v *fileListView) operate(key *tcell.EventKey) {
+	dispatch(v.keyBindings(), key)
+}
+
+func (v *fileListView) keyBindings() []*keyBindingGroup {
+	return append([]*keyBindingGroup{
+		{
+			title: layerFileBreadcrumb,
+			bindings: []*keyBinding{
+				{runes: []rune{'l'}, keys: []tcell.Key{tcell.KeyEnter}, desc: "open directory", action: v.open},
+				{runes: []rune{'h'}, desc: "move to parent directory", action: v.up},
+			},
+		},
+	}, v.listViewBase.keyBindings()...)
+}
+
+func (v *fileListView) open() {
+	if n, ok := v.current().(*fileNode); ok && n.IsDir() && n.Count() > 1 {
+		v.setDir(n.Node)
+	}
+}
+
+// up moves to the parent directory, or back to the layers at the root.
+func (v *fileListView) up() {
+	parent := v.dir.Parent()
+	if parent == nil {
+		v.ui.showManifestViews(v.repository, v.image, v.manifest)
+		return
+	}
+	from := v.dir
+	v.setDir(parent)
+	v.selectWhere(func(e listViewElement) bool { return e.(*fileNode).Node == from })
+}
+
+type fileDetailView struct {
+	*detailViewBase
+	manifest *domain.Manifest
+}
+
+func newFileDetailView(b *goban.Box, m *domain.Manifest) *fileDetailView {
+	return &fileDetailView{newDetailViewBase(b), m}
+}
+
+func (v *fileDetailView) update(e listViewElement) {
+	n, ok := e.(*fileNode)
+	if !ok {
+		v.SetLines(nil)
+		return
+	}
+	ls := []string{
+		"PATH:",
+		"  " + n.Path(),
+	}
+	switch {
+	case n.Entry != nil && n.Entry.Whiteout:
+		ls = append(ls, "WHITEOUT:", "  deleted from the layers below")
+	case n.Entry != nil && n.Entry.Opaque:
+		ls = append(ls, "OPAQUE DIRECTORY:", "  replaces the directory of the layers below")
+	case n.IsDir():
+		ls = append(ls,
+			"TYPE:",
+			"  directory",
+			"SIZE:",
+			fmt.Sprintf("  %s in %d entries", humanize.Bytes(uint64(n.Size())), n.Count()-1),
+		)
+	default:
+		ls = append(ls,
+			"TYPE:",
+			"  "+n.Entry.Type.String(),
+			"SIZE:",
+			fmt.Sprintf("  %s (%d bytes)", humanize.Bytes(uint64(n.Size())), n.Size()),
+		)
+		if n.Entry.Linkname != "" {
+			ls = append(ls, "LINK:", "  "+n.Entry.Linkname)
+		}
+	}
+	ls = append(ls, "MODE:", "  "+n.modeStr())
+	if n.Layer >= 0 && n.Layer < len(v.manifest.Layers) {
+		step := v.manifest.LayerHistory()[n.Layer]
+		ls = append(ls, "LAYER:", fmt.Sprintf("  #%d %s", n.Layer, v.manifest.Layers[n.Layer].ShortDigest()))
+		if step != nil {
+			ls = append(ls, "  "+step.CreatedBy)
+		}
+	}
+	v.SetLines(ls)
+}
+
+// loadLayerFileViews browses the files of the i-th layer of img.
+func (u *ui) loadLayerFileViews(repo string, img *domain.Image, m *domain.Manifest, i int) error {
+	loading := layout.NewLoadingDialog(u.baseView.base, u.baseView.es)
+	go loading.Display()
+	es, err := readLayer(repo, m.Layers[i])
+	loading.Close()
+	if err != nil {
+		return err
+	}
+	root := layerfs.Build(es)
+	for _, c := range root.Children() {
+		setLayer(c, i)
+	}
+	u.showFileViews(repo, img, m, root, false)
+	return nil
+}
+
+// setLayer records the index of the layer in the manifest, since Build numbers it 0.
+func setLayer(n *layerfs.Node, i int) {
+	n.Layer = i
+	for _, c := range n.Children() {
+		setLayer(c, i)
+	}
+}
+
+// loadImageFileViews browses the filesystem of img merged from all its layers.
+func (u *ui) loadImageFileViews(repo string, img *domain.Image, m *domain.Manifest) error {
+	progress := layout.NewProgressDialog(u.baseView.base, u.baseView.es, layerDownloadProgress, len(m.Layers))
+	go progress.Display()
+	layers := make([][]*layerfs.Entry, len(m.Layers))
+	var err error
+	for i, l := range m.Layers {
+		if layers[i], err = readLayer(repo, l); err != nil {
+			break
+		}
+		progress.Set(i + 1)
+	}
+	progress.Close()
+	if err != nil {
+		return err
+	}
+	u.showFileViews(repo, img, m, layerfs.Merge(layers), true)
+	return nil
+}
+
+func (u *ui) showFileViews(repo string, img *domain.Image, m *domain.Manifest, root *layerfs.Node, merged bool) {
+	lv := newFileListView(u.baseView.gridLayout.list, repo, img, m, root, merged)
+	dv := newFileDetailView(u.baseView.gridLayout.detail, m)
+	lv.addObserver(dv)
+	lv.setBaseUI(u)
+	u.baseView.resetBreadcrumb()
+	u.popViews()
+	u.pushViews(lv, dv)
+	u.setPanes(lv, dv)
+	u.baseView.pushBreadcrumb(repo)
+	u.baseView.pushBreadcrumb(img.GetTag())
+	u.baseView.pushBreadcrumb(layerFileBreadcrumb)
+	u.baseView.showMessage(fmt.Sprintf("%d files, %s", root.Count()-1, humanize.Bytes(uint64(root.Size()))))
+}
@@ -0,0 +1,207 @@
+package ui
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+type filterField struct {
+	name   string
+	values func(e listViewElement) []string
+}
+
+var (
+	imageFilterFields = []*filterField{
+		{"tag", func(e listViewElement) []string { return e.(*domain.Image).GetTags() }},
+		{"digest", func(e listViewElement) []string { return []string{e.(*domain.Image).Digest} }},
+	}
+	repositoryFilterFields = []*filterField{
+		{"name", func(e listViewElement) []string { return []string{e.(*domain.Repository).Name} }},
+		{"uri", func(e listViewElement) []string { return []string{e.(*domain.Repository).Uri} }},
+		{"mutability", func(e listViewElement) []string { return []string{e.(*domain.Repository).TagMutability} }},
+		{"cache", func(e listViewElement) []string {
+			if r := domain.FindPullThroughCacheRule(pullThroughCacheRules, e.(*domain.Repository).Name); r != nil {
+				return []string{r.Prefix, r.UpstreamUrl}
+			}
+			return nil
+		}},
+	}
+)
+
+// ageField is a time of the elements that can be filtered by age in days.
+type ageField struct {
+	name string
+	at   func(e listViewElement) time.Time
+}
+
+var (
+	imageAgeFields = []*ageField{
+		{"pushed", func(e listViewElement) time.Time { return e.(*domain.Image).PushedAt }},
+		// images never pulled count from their push, so new images are not reported as unused
+		{"pulled", func(e listViewElement) time.Time { return e.(*domain.Image).LastUsedAt() }},
+	}
+	repositoryAgeFields = []*ageField{
+		{"created", func(e listViewElement) time.Time { return e.(*domain.Repository).CreatedAt }},
+	}
+
+	ageExpr = regexp.MustCompile(`^([a-z]+)([<>])([0-9]+)d?$`)
+)
+
+// isAgeExpr reports whether expr is an age filter rather than field=pattern,
+// whose pattern may contain < or > (e.g. tag=<untagged>).
+func isAgeExpr(expr string) bool {
+	return !strings.Contains(expr, "=") && ageExpr.MatchString(expr)
+}
+
+// newAgeMatcher parses expr of the form field>N or field<N, where N is in days.
+// field>N matches elements whose field is more than N days ago.
+func newAgeMatcher(fields []*ageField, expr string, now time.Time) (*listMatcher, error) {
+	m := ageExpr.FindStringSubmatch(expr)
+	if m == nil {
+		return nil, fmt.Errorf("filter must be field>days or field<days: %s", expr)
+	}
+	var f *ageField
+	for _, af := range fields {
+		if af.name == m[1] {
+			f = af
+		}
+	}
+	if f == nil {
+		return nil, fmt.Errorf("unknown filter field: %s", m[1])
+	}
+	days, _ := strconv.Atoi(m[3])
+	threshold := now.AddDate(0, 0, -days)
+	older := m[2] == ">"
+	match := func(e listViewElement) bool {
+		return f.at(e).Before(threshold) == older
+	}
+	return &listMatcher{expr, match}, nil
+}
+
+func findFilterField(fields []*filterField, name string) *filterField {
+	for _, f := range fields {
+		if f.name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// tagFilterErr is the first error fetching tags while filtering by them.
+// It is usually a missing permission that every repository would fail with,
+// so no more tags are fetched until the next filter.
+var tagFilterErr error
+
+// repositoryTagField matches the value of the resource tag key.
+// Tags are fetched for every repository the first time it is matched.
+func repositoryTagField(key string) *filterField {
+	return &filterField{key, func(e listViewElement) []string {
+		if tagFilterErr != nil {
+			return nil
+		}
+		tags, err := fetchRepositoryTags(e.(*domain.Repository))
+		if err != nil {
+			tagFilterErr = err
+			return nil
+		}
+		if v, ok := tags[key]; ok {
+			return []string{v}
+		}
+		return nil
+	}}
+}
+
+// findField looks name up in the fields of v, then in its tag keys.
+// The second result reports whether matching it may call the API.
+func (v *listViewBase) findField(name string) (*filterField, bool) {
+	if f := findFilterField(v.fields, name); f != nil {
+		return f, false
+	}
+	if v.tagField != nil {
+		return v.tagField(name), true
+	}
+	return nil, false
+}
+
+// newFieldMatcher parses expr of the form field=pattern, where pattern is a glob.
+func newFieldMatcher(find func(string) (*filterField, bool), expr string) (*listMatcher, error) {
+	kv := strings.SplitN(expr, "=", 2)
+	if len(kv) != 2 {
+		return nil, fmt.Errorf("filter must be field=pattern: %s", expr)
+	}
+	f, _ := find(kv[0])
+	if f == nil {
+		return nil, fmt.Errorf("unknown filter field: %s", kv[0])
+	}
+	pattern := kv[1]
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern: %s", pattern)
+	}
+	match := func(e listViewElement) bool {
+		for _, v := range f.values(e) {
+			if ok, _ := path.Match(pattern, v); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return &listMatcher{expr, match}, nil
+}
+
+// andMatchers returns a matcher accepting the elements all of ms accept, or nil if ms is empty.
+func andMatchers(ms []*listMatcher) *listMatcher {
+	switch len(ms) {
+	case 0:
+		return nil
+	case 1:
+		return ms[0]
+	}
+	keys := make([]string, len(ms))
+	for i, m := range ms {
+		keys[i] = m.key
+	}
+	match := func(e listViewElement) bool {
+		for _, m := range ms {
+			if !m.match(e) {
+				return false
+			}
+		}
+		return true
+	}
+	return &listMatcher{strings.Join(keys, " "), match}
+}
+
+// parseFilterArgs splits the arguments of :filter into the matcher of all
+// field=pattern and age filters and the free text query.
+// fetches reports whether matching may call the API.
+func parseFilterArgs(v *listViewBase, args []string, now time.Time) (matcher *listMatcher, query string, fetches bool, err error) {
+	var ms []*listMatcher
+	var texts []string
+	for _, arg := range args {
+		var m *listMatcher
+		switch {
+		case isAgeExpr(arg):
+			m, err = newAgeMatcher(v.ageFields, arg, now)
+		case strings.Contains(arg, "="):
+			m, err = newFieldMatcher(v.findField, arg)
+			if err == nil {
+				_, f := v.findField(strings.SplitN(arg, "=", 2)[0])
+				fetches = fetches || f
+			}
+		default:
+			texts = append(texts, arg)
+			continue
+		}
+		if err != nil {
+			return nil, "", false, err
+		}
+		ms = append(ms, m)
+	}
+	return andMatchers(ms), strings.Join(texts, " "), fetches, nil
+}
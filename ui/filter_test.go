@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+func TestNewAgeMatcher(t *testing.T) {
+	now := time.Date(2022, 4, 30, 0, 0, 0, 0, time.UTC)
+	old := domain.NewImage([]string{"old"}, now.AddDate(0, 0, -40), "", 1, "", time.Time{}, "", "")
+	recent := domain.NewImage([]string{"recent"}, now.AddDate(0, 0, -3), "", 1, "", time.Time{}, "", "")
+
+	tests := []struct {
+		expr   string
+		old    bool
+		recent bool
+	}{
+		{"pushed>30", true, false},
+		{"pushed>30d", true, false},
+		{"pushed<7d", false, true},
+		{"pushed>1", true, true},
+	}
+	for _, tt := range tests {
+		m, err := newAgeMatcher(imageAgeFields, tt.expr, now)
+		if err != nil {
+			t.Errorf("newAgeMatcher(%q) returns error: %v", tt.expr, err)
+			continue
+		}
+		if got := m.match(old); got != tt.old {
+			t.Errorf("newAgeMatcher(%q).match(old) = %v; want = %v", tt.expr, got, tt.old)
+		}
+		if got := m.match(recent); got != tt.recent {
+			t.Errorf("newAgeMatcher(%q).match(recent) = %v; want = %v", tt.expr, got, tt.recent)
+		}
+	}
+
+	for _, expr := range []string{"size>30", "pushed>=30", "pushed>"} {
+		if _, err := newAgeMatcher(imageAgeFields, expr, now); err == nil {
+			t.Errorf("newAgeMatcher(%q) returns no error", expr)
+		}
+	}
+}
+
+func TestIsAgeExpr(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"pushed>30", true},
+		{"pulled<7d", true},
+		{"tag=<untagged>", false},
+		{"tag=v1>", false},
+		{"<untagged>", false},
+		{"v1", false},
+	}
+	for _, tt := range tests {
+		if got := isAgeExpr(tt.expr); got != tt.want {
+			t.Errorf("isAgeExpr(%q) = %v; want = %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestNewFieldMatcher_untagged(t *testing.T) {
+	find := func(name string) (*filterField, bool) { return findFilterField(imageFilterFields, name), false }
+	m, err := newFieldMatcher(find, "tag=<untagged>")
+	if err != nil {
+		t.Fatalf("newFieldMatcher() returns error: %v", err)
+	}
+	untagged := domain.NewImage(nil, time.Time{}, "", 1, "", time.Time{}, "", "")
+	tagged := domain.NewImage([]string{"v1"}, time.Time{}, "", 1, "", time.Time{}, "", "")
+	if got := m.match(untagged); !got {
+		t.Errorf("match(untagged) = %v; want = %v", got, true)
+	}
+	if got := m.match(tagged); got {
+		t.Errorf("match(tagged) = %v; want = %v", got, false)
+	}
+}
+
+func TestParseFilterArgs(t *testing.T) {
+	now := time.Date(2022, 4, 30, 0, 0, 0, 0, time.UTC)
+	v := &listViewBase{fields: imageFilterFields, ageFields: imageAgeFields}
+	images := []*domain.Image{
+		domain.NewImage([]string{"v1.0.0"}, now.AddDate(0, 0, -40), "", 1, "", time.Time{}, "", ""),
+		domain.NewImage([]string{"v1.1.0"}, now.AddDate(0, 0, -3), "", 1, "", time.Time{}, "", ""),
+		domain.NewImage([]string{"v2.0.0"}, now.AddDate(0, 0, -40), "", 1, "", time.Time{}, "", ""),
+	}
+
+	matcher, query, fetches, err := parseFilterArgs(v, []string{"tag=v1.*", "pushed>30", "foo"}, now)
+	if err != nil {
+		t.Fatalf("parseFilterArgs() returns error: %v", err)
+	}
+	if query != "foo" {
+		t.Errorf("query = %q; want = %q", query, "foo")
+	}
+	if fetches {
+		t.Errorf("fetches = %v; want = %v", fetches, false)
+	}
+	want := []bool{true, false, false}
+	for i, img := range images {
+		if got := matcher.match(img); got != want[i] {
+			t.Errorf("match(%v) = %v; want = %v", img.Tags, got, want[i])
+		}
+	}
+
+	if _, _, _, err := parseFilterArgs(v, []string{"tag=v1.*", "size=1"}, now); err == nil {
+		t.Errorf("parseFilterArgs() with unknown field returns no error")
+	}
+}
+
+type failingTagClient struct {
+	domain.ContainerClient
+	calls int
+}
+
+func (c *failingTagClient) FetchRepositoryTags(repo *domain.Repository) (map[string]string, error) {
+	c.calls++
+	return nil, fmt.Errorf("AccessDeniedException: not authorized to perform: ecr:ListTagsForResource")
+}
+
+func TestRepositoryTagField_error(t *testing.T) {
+	c := &failingTagClient{}
+	orig := client
+	client, tagFilterErr = c, nil
+	defer func() {
+		client, tagFilterErr = orig, nil
+		repositoryTagErrors = make(map[string]error)
+	}()
+
+	repos := []*domain.Repository{
+		domain.NewRepository("a", "", "arn:a", "", "", false, "", "", time.Time{}),
+		domain.NewRepository("b", "", "arn:b", "", "", false, "", "", time.Time{}),
+	}
+	f := repositoryTagField("team")
+	for _, r := range repos {
+		if got := f.values(r); got != nil {
+			t.Errorf("values(%s) = %v; want = nil", r.Name, got)
+		}
+	}
+	if c.calls != 1 {
+		t.Errorf("FetchRepositoryTags called %v times; want = %v", c.calls, 1)
+	}
+	if tagFilterErr == nil {
+		t.Errorf("tagFilterErr = nil; want the error")
+	}
+}
+
+func TestFetchRepositoryTags_error(t *testing.T) {
+	c := &failingTagClient{}
+	orig := client
+	client = c
+	defer func() {
+		client = orig
+		repositoryTagErrors = make(map[string]error)
+	}()
+
+	repo := domain.NewRepository("a", "", "arn:a", "", "", false, "", "", time.Time{})
+	for i := 0; i < 3; i++ {
+		if _, err := fetchRepositoryTags(repo); err == nil {
+			t.Errorf("fetchRepositoryTags() error = nil; want the error")
+		}
+	}
+	if c.calls != 1 {
+		t.Errorf("FetchRepositoryTags called %v times; want = %v", c.calls, 1)
+	}
+}
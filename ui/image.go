@@ -20,61 +20,121 @@ func newImageListView(b *goban.Box, repoName string) (*imageListView, error) {
 	if err != nil {
 		return nil, err
 	}
-	domain.SortImages(imgs)
+	recordImages(repoName, imgs)
+	columns := imageColumns.defaultColumns()
+	if hasLifecycleExpirations(imgs) {
+		columns = append(columns, findColumn(imageColumns.all, "expire"))
+	}
 	return &imageListView{
 		listViewBase: &listViewBase{
-			box:      b,
-			elements: listViewElementsFromImages(imgs),
-			title:    imageListViewTitle,
+			box:       b,
+			model:     newListModel(listViewElementsFromImages(imgs), imageSorters...),
+			fields:    imageFilterFields,
+			ageFields: imageAgeFields,
+			columnSet: imageColumns,
+			columns:   columns,
+			title:     imageListViewTitle,
 		},
 		repository: repoName,
 	}, nil
 }
 
+var (
+	imageSorters = []*listSorter{
+		{"pushed", func(a, b listViewElement) bool {
+			return a.(*domain.Image).PushedAt.Before(b.(*domain.Image).PushedAt)
+		}, true},
+		{"size", func(a, b listViewElement) bool {
+			return a.(*domain.Image).SizeByte < b.(*domain.Image).SizeByte
+		}, true},
+		{"tag", func(a, b listViewElement) bool {
+			return a.(*domain.Image).GetTag() < b.(*domain.Image).GetTag()
+		}, false},
+		{"pulled", func(a, b listViewElement) bool {
+			return a.(*domain.Image).LastPulledAt.Before(b.(*domain.Image).LastPulledAt)
+		}, true},
+	}
+)
+
 func listViewElementsFromImages(imgs []*domain.Image) []listViewElement {
-	var elems []listViewElement
-	for _, img := range imgs {
-		elems = append(elems, img)
+	elems := make([]listViewElement, len(imgs))
+	for i, img := range imgs {
+		elems[i] = img
 	}
 	return elems
 }
 
-func (v *imageListView) operate(key *tcell.EventKey) {
-	switch key.Rune() {
-	case 'h':
-		v.ui.loadRepositoryView(false)
-	default:
-		v.listViewBase.operate(key)
+// hasLifecycleExpirations reports whether a lifecycle simulation would expire any of imgs.
+func hasLifecycleExpirations(imgs []*domain.Image) bool {
+	for _, img := range imgs {
+		if _, ok := lifecycleExpirations[img]; ok {
+			return true
+		}
 	}
+	return false
+}
+
+func (v *imageListView) operate(key *tcell.EventKey) {
+	dispatch(v.keyBindings(), key)
+}
+
+func (v *imageListView) keyBindings() []*keyBindingGroup {
+	return append([]*keyBindingGroup{
+		{
+			title: imageListViewTitle,
+			bindings: []*keyBinding{
+				{runes: []rune{'h'}, desc: "move to repository list", action: func() { v.ui.loadRepositoryView(false) }},
+				{runes: []rune{'c'}, desc: "clean up stale images", action: func() { v.ui.editCleanupRules(v.repository) }},
+				{runes: []rune{'M'}, desc: "show layers of the image", action: func() { v.ui.showError(v.ui.loadManifestViews(v.repository, v.currentImage())) }},
+				{runes: []rune{'L'}, desc: "analyze layer sharing", action: func() { v.ui.showError(v.ui.loadSharingViews(v.repository)) }},
+				{runes: []rune{'v'}, desc: "mark / compare with marked image", action: func() { v.ui.markOrCompare(v.repository, v.currentImage()) }},
+				{runes: []rune{'C'}, desc: "copy image to another repository / region / account", action: func() { v.ui.copyImage(v.repository, v.currentImage(), nil) }},
+			},
+		},
+	}, v.listViewBase.keyBindings()...)
+}
+
+func (v *imageListView) currentImage() *domain.Image {
+	img, _ := v.current().(*domain.Image)
+	return img
 }
 
 type imageDetailView struct {
-	box      *goban.Box
+	*detailViewBase
 	selected *domain.Image
 }
 
 func newImageDetailView(b *goban.Box) *imageDetailView {
-	return &imageDetailView{b, nil}
+	return &imageDetailView{newDetailViewBase(b), nil}
 }
 
 func (v *imageDetailView) update(e listViewElement) {
-	if img, ok := e.(*domain.Image); ok {
-		v.selected = img
-	}
+	v.selected, _ = e.(*domain.Image)
+	v.SetLines(v.lines())
 }
 
-func (v *imageDetailView) View() {
-	b := v.box.Enclose("DETAIL")
-	if v.selected != nil {
-		b.Puts("TAGS:")
-		for _, t := range v.selected.GetTags() {
-			b.Puts("  " + t)
-		}
-		b.Puts("PUSHED AT:")
-		b.Puts("  " + v.selected.PushedAtStr())
-		b.Puts("DIGEST:")
-		b.Puts("  " + v.selected.Digest)
-		b.Puts("SIZE:")
-		b.Puts("  " + v.selected.SizeStr())
+func (v *imageDetailView) lines() []string {
+	if v.selected == nil {
+		return nil
+	}
+	ls := []string{"TAGS:"}
+	for _, t := range v.selected.GetTags() {
+		ls = append(ls, "  "+t)
 	}
+	return append(ls,
+		"PUSHED AT:",
+		"  "+v.selected.PushedAtStr(),
+		"LAST PULLED AT:",
+		"  "+v.selected.LastPulledAtStr(),
+		"DIGEST:",
+		"  "+v.selected.Digest,
+		"SIZE:",
+		"  "+v.selected.SizeStr(),
+		"SCAN STATUS:",
+		"  "+v.selected.ScanStatusStr(),
+		"MANIFEST MEDIA TYPE:",
+		"  "+v.selected.ManifestMediaTypeStr(),
+		"ARTIFACT MEDIA TYPE:",
+		"  "+v.selected.ArtifactMediaTypeStr(),
+	)
 }
@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/lusingander/ecr-browser/layout"
+)
+
+// keyBinding is a single entry of the table that drives both operate
+// and the help overlay, so the two cannot drift apart.
+type keyBinding struct {
+	runes  []rune
+	keys   []tcell.Key
+	desc   string
+	action func()
+}
+
+type keyBindingGroup struct {
+	title    string
+	bindings []*keyBinding
+}
+
+func (b *keyBinding) match(key *tcell.EventKey) bool {
+	if key.Key() == tcell.KeyRune {
+		for _, r := range b.runes {
+			if key.Rune() == r {
+				return true
+			}
+		}
+		return false
+	}
+	for _, k := range b.keys {
+		if key.Key() == k {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *keyBinding) label() string {
+	var ls []string
+	for _, r := range b.runes {
+		if r == ' ' {
+			ls = append(ls, "Space")
+			continue
+		}
+		ls = append(ls, string(r))
+	}
+	for _, k := range b.keys {
+		ls = append(ls, tcell.KeyNames[k])
+	}
+	return strings.Join(ls, " / ")
+}
+
+// dispatch runs the first binding in groups that matches key.
+func dispatch(groups []*keyBindingGroup, key *tcell.EventKey) bool {
+	for _, g := range groups {
+		for _, b := range g.bindings {
+			if b.match(key) {
+				b.action()
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func helpGroups(groups []*keyBindingGroup) []*layout.KeyHelpGroup {
+	ret := make([]*layout.KeyHelpGroup, 0, len(groups))
+	for _, g := range groups {
+		hg := &layout.KeyHelpGroup{Title: g.title}
+		for _, b := range g.bindings {
+			hg.Keys = append(hg.Keys, &layout.KeyHelp{Key: b.label(), Description: b.desc})
+		}
+		ret = append(ret, hg)
+	}
+	return ret
+}
+
+func hintStr(g *keyBindingGroup) string {
+	var hs []string
+	for _, b := range g.bindings {
+		hs = append(hs, b.label()+": "+b.desc)
+	}
+	return strings.Join(hs, "  ")
+}
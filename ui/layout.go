@@ -6,6 +6,7 @@ import (
 
 	"github.com/eihigh/goban"
 	"github.com/gdamore/tcell"
+	"github.com/lusingander/ecr-browser/layout"
 )
 
 const (
@@ -16,10 +17,17 @@ type listViewBase struct {
 	*ui
 	cur       int
 	box       *goban.Box
-	elements  []listViewElement
+	model     *listModel
+	fields    []*filterField
+	ageFields []*ageField
+	tagField  func(key string) *filterField // nil if the elements have no resource tags
+	tagKeys   func() []string
+	columnSet *columnSet
+	columns   []*listColumn // nil to show Display()
 	observers []listElementObserver
 	title     string
 	viewTop   int
+	focused   bool
 }
 
 type listViewElement interface {
@@ -31,15 +39,23 @@ type listElementObserver interface {
 }
 
 func (v *listViewBase) operate(key *tcell.EventKey) {
-	switch key.Rune() {
-	case 'k':
-		v.selectPrev()
-	case 'j':
-		v.selectNext()
-	case 'g':
-		v.selectFirst()
-	case 'G':
-		v.selectLast()
+	dispatch(v.keyBindings(), key)
+}
+
+func (v *listViewBase) keyBindings() []*keyBindingGroup {
+	return []*keyBindingGroup{
+		{
+			title: "LIST",
+			bindings: []*keyBinding{
+				{runes: []rune{'j'}, desc: "move down", action: v.selectNext},
+				{runes: []rune{'k'}, desc: "move up", action: v.selectPrev},
+				{runes: []rune{'g'}, desc: "move to the top", action: v.selectFirst},
+				{runes: []rune{'G'}, desc: "move to the bottom", action: v.selectLast},
+				{runes: []rune{'/'}, desc: "filter list", action: v.search},
+				{runes: []rune{'s'}, desc: "change sort key", action: v.nextSorter},
+				{runes: []rune{'S'}, desc: "reverse sort order", action: v.toggleReverse},
+			},
+		},
 	}
 }
 
@@ -47,52 +63,102 @@ func (v *listViewBase) setBaseUI(ui *ui) {
 	v.ui = ui
 }
 
+func (v *listViewBase) setFocus(focused bool) {
+	v.focused = focused
+}
+
 func (v *listViewBase) View() {
-	b := v.box.Enclose(v.title)
-	for i := 0; i < v.height(); i++ {
+	b := v.box.Enclose(layout.FocusTitle(v.titleStr(), v.focused))
+	header, lines := v.visibleLines()
+	if v.columns != nil {
+		b.Puts("  " + header)
+	}
+	for i, line := range lines {
 		if v.cur == i {
 			b.Print("> ")
 		} else {
 			b.Print("  ")
 		}
-		if e, ok := v.get(i + v.viewTop); ok {
-			b.Puts(e.Display())
-		} else {
-			break
-		}
+		b.Puts(line)
 	}
 	v.printScroll()
 	v.createFooter().Print(v.currentCountStr())
 }
 
-func (v *listViewBase) get(i int) (listViewElement, bool) {
-	if i >= len(v.elements) {
-		return nil, false
+func (v *listViewBase) titleStr() string {
+	t := v.title
+	if s := v.model.sorterName(); s != "" {
+		t += " (" + s + ")"
+	}
+	if k := v.model.matcherKey(); k != "" {
+		t += " [" + k + "]"
+	}
+	if v.model.query != "" {
+		t += " [/" + v.model.query + "]"
+	}
+	return t
+}
+
+// visibleLines returns the header and the display strings of the elements
+// currently inside the view. Elements outside the view are never touched.
+func (v *listViewBase) visibleLines() (string, []string) {
+	es := v.model.window(v.viewTop, v.height())
+	if v.columns != nil {
+		return tableLines(v.columns, es, v.box.Size.X-5)
+	}
+	lines := make([]string, len(es))
+	for i, e := range es {
+		lines[i] = e.Display()
 	}
-	return v.elements[i], true
+	return "", lines
+}
+
+// setColumns selects the columns to show by name. No names restores the defaults.
+func (v *listViewBase) setColumns(names []string) error {
+	if v.columnSet == nil {
+		return fmt.Errorf("this list has no columns")
+	}
+	if len(names) == 0 {
+		names = v.columnSet.defaults
+	}
+	cs, err := selectColumns(v.columnSet.all, names)
+	if err != nil {
+		return err
+	}
+	v.columns = cs
+	v.selectFirst()
+	return nil
+}
+
+func (v *listViewBase) get(i int) (listViewElement, bool) {
+	return v.model.get(i)
 }
 
 func (v *listViewBase) height() int {
 	h := v.box.Size.Y - 2
-	if len(v.elements) < h {
-		return len(v.elements)
+	if v.columns != nil {
+		h--
+	}
+	if v.length() < h {
+		return v.length()
 	}
 	return h
 }
 
 func (v *listViewBase) empty() bool {
-	return v.elements == nil || len(v.elements) == 0
+	return v.length() == 0
 }
 
 func (v *listViewBase) length() int {
-	return len(v.elements)
+	return v.model.length()
 }
 
 func (v *listViewBase) current() listViewElement {
 	if v.empty() {
 		return nil
 	}
-	return v.elements[v.cursor()]
+	e, _ := v.get(v.cursor())
+	return e
 }
 
 func (v *listViewBase) cursor() int {
@@ -107,7 +173,7 @@ func (v *listViewBase) cursorExistFirst() bool {
 }
 
 func (v *listViewBase) cursorExistLast() bool {
-	return v.cursor() == len(v.elements)-1
+	return v.cursor() == v.length()-1
 }
 
 func (v *listViewBase) selectNext() {
@@ -152,10 +218,73 @@ func (v *listViewBase) selectLast() {
 		return
 	}
 	v.cur = v.height() - 1
-	v.viewTop = len(v.elements) - v.height()
+	v.viewTop = v.length() - v.height()
+	v.notify()
+}
+
+// selectIndex moves the cursor to the i-th visible element, scrolling it into view.
+func (v *listViewBase) selectIndex(i int) {
+	if v.empty() {
+		return
+	}
+	if i >= v.length() {
+		i = v.length() - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	if i < v.viewTop || i >= v.viewTop+v.height() {
+		v.viewTop = i - v.height()/2
+		if max := v.length() - v.height(); v.viewTop > max {
+			v.viewTop = max
+		}
+		if v.viewTop < 0 {
+			v.viewTop = 0
+		}
+	}
+	v.cur = i - v.viewTop
 	v.notify()
 }
 
+// selectWhere moves the cursor to the first visible element satisfying f.
+func (v *listViewBase) selectWhere(f func(listViewElement) bool) bool {
+	for i := 0; i < v.length(); i++ {
+		if e, _ := v.get(i); f(e) {
+			v.selectIndex(i)
+			return true
+		}
+	}
+	return false
+}
+
+func (v *listViewBase) search() {
+	b := v.box
+	line := goban.NewBox(b.Pos.X+1, b.Pos.Y+b.Size.Y-1, b.Size.X-v.calcCountStrMaxLen()-3, 1)
+	prev := v.model.query
+	input := layout.NewInputLine(line, v.ui.baseView.es, "/").OnChange(v.filter)
+	if _, ok := input.Read(prev); !ok {
+		v.filter(prev)
+	}
+}
+
+func (v *listViewBase) nextSorter() {
+	v.model.nextSorter()
+	v.selectFirst()
+}
+
+func (v *listViewBase) toggleReverse() {
+	v.model.toggleReverse()
+	v.selectFirst()
+}
+
+func (v *listViewBase) filter(q string) {
+	v.model.setQuery(q)
+	v.selectFirst()
+	if v.empty() {
+		v.notify()
+	}
+}
+
 func (v *listViewBase) addObserver(o listElementObserver) {
 	v.observers = append(v.observers, o)
 	o.update(v.current())
@@ -193,24 +322,9 @@ func (v *listViewBase) countStr(n int) string {
 }
 
 func (v *listViewBase) printScroll() {
-	l := len(v.elements)
-	if l == 0 || l < v.height() {
-		return
-	}
 	b := v.box
-	barLength := v.height() * v.height() / l
-	x := b.Pos.X + b.Size.X - 2
-	y := b.Pos.Y + 1 + v.calcScrollTopPos(barLength)
-	w := 1
-	s := goban.NewBox(x, y, w, barLength)
-	for i := 0; i < barLength; i++ {
-		s.Puts("│") // ║ (2551) or │ (2503)
+	if v.columns != nil {
+		b = goban.NewBox(b.Pos.X, b.Pos.Y+1, b.Size.X, b.Size.Y-1)
 	}
-}
-
-func (v *listViewBase) calcScrollTopPos(barLength int) int {
-	barMaxMove := float64(v.height() - barLength)
-	viewTopMaxMove := float64(len(v.elements) - v.height())
-	currentViewTop := float64(v.viewTop)
-	return int((currentViewTop / viewTopMaxMove) * barMaxMove)
+	layout.PrintScrollBar(b, v.height(), v.length(), v.viewTop)
 }
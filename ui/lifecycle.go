@@ -0,0 +1,183 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/eihigh/goban"
+	"github.com/gdamore/tcell"
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/layout"
+	"github.com/lusingander/ecr-browser/lifecycle"
+)
+
+const (
+	lifecycleRuleListViewTitle = "LIFECYCLE RULES"
+	lifecycleBreadcrumb        = "LIFECYCLE POLICY"
+)
+
+// lifecycleExpirations holds the rule that would expire each image,
+// from the latest simulation of its repository's lifecycle policy.
+var lifecycleExpirations = make(map[*domain.Image]*lifecycle.Rule)
+
+func lifecycleClient() (domain.LifecyclePolicyClient, error) {
+	if c, ok := client.(domain.LifecyclePolicyClient); ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("current client does not support lifecycle policies")
+}
+
+// simulateLifecycle fetches the lifecycle policy of repo and evaluates it
+// against the images of repo. It returns nil policy if there is none.
+func simulateLifecycle(repo string) (*lifecycle.Policy, []*lifecycle.Result, error) {
+	c, err := lifecycleClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	text, err := c.FetchLifecyclePolicy(repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	imgs, err := client.FetchAllImages(repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	if text == "" {
+		for _, img := range imgs {
+			delete(lifecycleExpirations, img)
+		}
+		return nil, nil, nil
+	}
+	policy, err := lifecycle.Parse(text)
+	if err != nil {
+		return nil, nil, err
+	}
+	results := lifecycle.Simulate(policy, imgs, time.Now())
+	for _, r := range results {
+		if r.ExpiredBy != nil {
+			lifecycleExpirations[r.Image] = r.ExpiredBy
+		} else {
+			delete(lifecycleExpirations, r.Image)
+		}
+	}
+	return policy, results, nil
+}
+
+type lifecycleRuleListView struct {
+	*listViewBase
+	repository string
+	policy     *lifecycle.Policy // has no rules if the repository has no policy
+}
+
+func newLifecycleRuleListView(b *goban.Box, repo string, policy *lifecycle.Policy) *lifecycleRuleListView {
+	if policy == nil {
+		policy = &lifecycle.Policy{}
+	}
+	elems := make([]listViewElement, len(policy.Rules))
+	for i, r := range policy.Rules {
+		elems[i] = r
+	}
+	return &lifecycleRuleListView{
+		listViewBase: &listViewBase{
+			box:   b,
+			model: newListModel(elems),
+			title: lifecycleRuleListViewTitle,
+		},
+		repository: repo,
+		policy:     policy,
+	}
+}
+
+func (v *lifecycleRuleListView) operate(key *tcell.EventKey) {
+	dispatch(v.keyBindings(), key)
+}
+
+func (v *lifecycleRuleListView) keyBindings() []*keyBindingGroup {
+	return append([]*keyBindingGroup{
+		{
+			title: lifecycleRuleListViewTitle,
+			bindings: []*keyBinding{
+				{runes: []rune{'h'}, desc: "move to repository list", action: func() { v.ui.loadRepositoryView(false) }},
+				{runes: []rune{'l'}, desc: "move to image list", action: func() { v.ui.loadImageViews(v.repository) }},
+				{runes: []rune{'a'}, desc: "add rule", action: v.addRule},
+				{runes: []rune{'e'}, desc: "edit rule", action: v.editRule},
+				{runes: []rune{'d'}, desc: "delete rule", action: v.deleteRule},
+				{runes: []rune{'E'}, desc: "edit policy in $EDITOR", action: v.editPolicy},
+				{runes: []rune{'D'}, desc: "delete policy", action: v.deletePolicy},
+			},
+		},
+	}, v.listViewBase.keyBindings()...)
+}
+
+type lifecycleRuleDetailView struct {
+	*detailViewBase
+	results  []*lifecycle.Result
+	selected *lifecycle.Rule
+}
+
+func newLifecycleRuleDetailView(b *goban.Box, results []*lifecycle.Result) *lifecycleRuleDetailView {
+	return &lifecycleRuleDetailView{newDetailViewBase(b), results, nil}
+}
+
+func (v *lifecycleRuleDetailView) update(e listViewElement) {
+	v.selected, _ = e.(*lifecycle.Rule)
+	v.SetLines(v.lines())
+}
+
+func (v *lifecycleRuleDetailView) lines() []string {
+	if v.selected == nil {
+		return nil
+	}
+	var expired []*domain.Image
+	var size int64
+	for _, r := range v.results {
+		if r.ExpiredBy == v.selected {
+			expired = append(expired, r.Image)
+			size += r.Image.SizeByte
+		}
+	}
+	ls := []string{
+		"DESCRIPTION:",
+		"  " + v.selected.Description,
+		"SUMMARY:",
+		"  " + v.selected.Summary(),
+		"RULE:",
+	}
+	for _, l := range strings.Split(v.selected.JSON(), "\n") {
+		ls = append(ls, "  "+l)
+	}
+	ls = append(ls, fmt.Sprintf("WOULD EXPIRE: %d images (%s)", len(expired), humanize.Bytes(uint64(size))))
+	for _, img := range expired {
+		ls = append(ls, "  "+img.GetTag()+"  "+img.PushedAtShortStr())
+	}
+	return ls
+}
+
+func (u *ui) loadLifecyclePolicyViews(repo string) error {
+	loading := layout.NewLoadingDialog(u.baseView.base, u.baseView.es)
+	go loading.Display()
+	defer loading.Close()
+
+	policy, results, err := simulateLifecycle(repo)
+	if err != nil {
+		return err
+	}
+	lv := newLifecycleRuleListView(u.baseView.gridLayout.list, repo, policy)
+	dv := newLifecycleRuleDetailView(u.baseView.gridLayout.detail, results)
+	lv.addObserver(dv)
+	lv.setBaseUI(u)
+	u.baseView.resetBreadcrumb()
+	u.popViews()
+	u.pushViews(lv, dv)
+	u.setPanes(lv, dv)
+	u.baseView.pushBreadcrumb(repo)
+	u.baseView.pushBreadcrumb(lifecycleBreadcrumb)
+	if policy == nil {
+		u.baseView.showMessage(fmt.Sprintf("%s has no lifecycle policy, press a to add a rule", repo))
+		return nil
+	}
+	u.baseView.showMessage(fmt.Sprintf("%d of %d images would expire", len(lifecycle.Expired(results)), len(results)))
+	return nil
+}
@@ -0,0 +1,261 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lusingander/ecr-browser/layout"
+	"github.com/lusingander/ecr-browser/lifecycle"
+)
+
+const (
+	ruleFormAddTitle    = "ADD LIFECYCLE RULE"
+	ruleFormEditTitle   = "EDIT LIFECYCLE RULE"
+	applyPolicyTitle    = "APPLY LIFECYCLE POLICY"
+	deletePolicyTitle   = "DELETE LIFECYCLE POLICY"
+	lifecyclePolicyFile = "lifecycle-policy-*.json"
+
+	defaultRuleCountNumber = 14
+)
+
+const (
+	ruleFieldPriority = iota
+	ruleFieldDescription
+	ruleFieldTagStatus
+	ruleFieldTagPrefixes
+	ruleFieldTagPatterns
+	ruleFieldCountType
+	ruleFieldCountUnit
+	ruleFieldCountNumber
+)
+
+func ruleFields(r *lifecycle.Rule) []*layout.FormField {
+	s := r.Selection
+	return []*layout.FormField{
+		ruleFieldPriority:    {Label: "priority", Value: strconv.Itoa(r.RulePriority)},
+		ruleFieldDescription: {Label: "description", Value: r.Description},
+		ruleFieldTagStatus:   {Label: "tag status", Value: s.TagStatus, Options: lifecycle.TagStatuses},
+		ruleFieldTagPrefixes: {Label: "tag prefixes", Value: strings.Join(s.TagPrefixList, ",")},
+		ruleFieldTagPatterns: {Label: "tag patterns", Value: strings.Join(s.TagPatternList, ",")},
+		ruleFieldCountType:   {Label: "count type", Value: s.CountType, Options: lifecycle.CountTypes},
+		ruleFieldCountUnit:   {Label: "count unit", Value: s.CountUnit, Options: []string{"", lifecycle.CountUnitDays}},
+		ruleFieldCountNumber: {Label: "count number", Value: strconv.Itoa(s.CountNumber)},
+	}
+}
+
+func ruleFromFields(fs []*layout.FormField) (*lifecycle.Rule, error) {
+	priority, err := strconv.Atoi(strings.TrimSpace(fs[ruleFieldPriority].Value))
+	if err != nil {
+		return nil, fmt.Errorf("priority must be a number")
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(fs[ruleFieldCountNumber].Value))
+	if err != nil {
+		return nil, fmt.Errorf("count number must be a number")
+	}
+	return &lifecycle.Rule{
+		RulePriority: priority,
+		Description:  fs[ruleFieldDescription].Value,
+		Selection: lifecycle.Selection{
+			TagStatus:      fs[ruleFieldTagStatus].Value,
+			TagPrefixList:  splitList(fs[ruleFieldTagPrefixes].Value),
+			TagPatternList: splitList(fs[ruleFieldTagPatterns].Value),
+			CountType:      fs[ruleFieldCountType].Value,
+			CountUnit:      fs[ruleFieldCountUnit].Value,
+			CountNumber:    count,
+		},
+		Action: lifecycle.Action{Type: lifecycle.ActionTypeExpire},
+	}, nil
+}
+
+// splitList splits a comma separated field, dropping empty items.
+func splitList(s string) []string {
+	var ret []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+// withRules returns a copy of the current policy whose rules are replaced by f.
+func (v *lifecycleRuleListView) withRules(f func([]*lifecycle.Rule) []*lifecycle.Rule) *lifecycle.Policy {
+	rules := make([]*lifecycle.Rule, len(v.policy.Rules))
+	copy(rules, v.policy.Rules)
+	return &lifecycle.Policy{Rules: f(rules)}
+}
+
+func (v *lifecycleRuleListView) currentRule() *lifecycle.Rule {
+	r, _ := v.current().(*lifecycle.Rule)
+	return r
+}
+
+// newRule returns a rule to start from, prioritized after the existing rules.
+func (v *lifecycleRuleListView) newRule() *lifecycle.Rule {
+	priority := 1
+	for _, r := range v.policy.Rules {
+		if r.RulePriority >= priority {
+			priority = r.RulePriority + 1
+		}
+	}
+	return &lifecycle.Rule{
+		RulePriority: priority,
+		Selection: lifecycle.Selection{
+			TagStatus:   lifecycle.TagStatusUntagged,
+			CountType:   lifecycle.CountTypeSinceImagePushed,
+			CountUnit:   lifecycle.CountUnitDays,
+			CountNumber: defaultRuleCountNumber,
+		},
+		Action: lifecycle.Action{Type: lifecycle.ActionTypeExpire},
+	}
+}
+
+func (v *lifecycleRuleListView) addRule() {
+	v.ruleForm(ruleFormAddTitle, v.newRule(), func(rules []*lifecycle.Rule, r *lifecycle.Rule) []*lifecycle.Rule {
+		return append(rules, r)
+	})
+}
+
+func (v *lifecycleRuleListView) editRule() {
+	target := v.currentRule()
+	if target == nil {
+		return
+	}
+	v.ruleForm(ruleFormEditTitle, target, func(rules []*lifecycle.Rule, r *lifecycle.Rule) []*lifecycle.Rule {
+		for i, old := range rules {
+			if old == target {
+				rules[i] = r
+			}
+		}
+		return rules
+	})
+}
+
+// ruleForm lets the user edit rule in a form, then applies the policy built by merge.
+// The form is shown again with the entered values until they are valid or cancelled.
+func (v *lifecycleRuleListView) ruleForm(title string, rule *lifecycle.Rule, merge func([]*lifecycle.Rule, *lifecycle.Rule) []*lifecycle.Rule) {
+	fields := ruleFields(rule)
+	for {
+		if !layout.NewFormDialog(v.ui.baseView.base, v.ui.baseView.es, title, fields).Display() {
+			return
+		}
+		r, err := ruleFromFields(fields)
+		var policy *lifecycle.Policy
+		if err == nil {
+			policy = v.withRules(func(rules []*lifecycle.Rule) []*lifecycle.Rule { return merge(rules, r) })
+			err = lifecycle.Validate(policy)
+		}
+		if err == nil {
+			v.applyPolicy(policy)
+			return
+		}
+		if !v.ui.confirmRetry(err) {
+			return
+		}
+	}
+}
+
+func (v *lifecycleRuleListView) deleteRule() {
+	target := v.currentRule()
+	if target == nil {
+		return
+	}
+	policy := v.withRules(func(rules []*lifecycle.Rule) []*lifecycle.Rule {
+		ret := rules[:0]
+		for _, r := range rules {
+			if r != target {
+				ret = append(ret, r)
+			}
+		}
+		return ret
+	})
+	if len(policy.Rules) == 0 {
+		// a policy must have at least one rule
+		v.deletePolicy()
+		return
+	}
+	v.applyPolicy(policy)
+}
+
+func (v *lifecycleRuleListView) editPolicy() {
+	policy := v.policy
+	if len(policy.Rules) == 0 {
+		policy = &lifecycle.Policy{Rules: []*lifecycle.Rule{v.newRule()}}
+	}
+	v.editPolicyText(lifecycle.Format(policy))
+}
+
+// editPolicyText opens text in $EDITOR. If the result is not a valid policy,
+// the user can edit it again instead of losing the changes.
+func (v *lifecycleRuleListView) editPolicyText(text string) {
+	v.ui.editText(text, lifecyclePolicyFile, func(edited string, err error) {
+		if err != nil {
+			v.ui.showError(err)
+			return
+		}
+		policy, err := lifecycle.ParseStrict(edited)
+		if err != nil {
+			if v.ui.confirmRetry(err) {
+				v.editPolicyText(edited)
+			}
+			return
+		}
+		if lifecycle.Format(policy) == lifecycle.Format(v.policy) {
+			v.ui.showMessage("lifecycle policy not changed")
+			return
+		}
+		v.applyPolicy(policy)
+	})
+}
+
+// applyPolicy shows the diff from the current policy to policy and puts it after confirmation.
+func (v *lifecycleRuleListView) applyPolicy(policy *lifecycle.Policy) {
+	lines := policyDiff(v.policy, policy)
+	if !layout.NewConfirmDialog(v.ui.baseView.base, v.ui.baseView.es, applyPolicyTitle, lines).Display() {
+		return
+	}
+	c, err := lifecycleClient()
+	if err == nil {
+		err = c.PutLifecyclePolicy(v.repository, lifecycle.Format(policy))
+	}
+	v.reload(err, "lifecycle policy applied")
+}
+
+func (v *lifecycleRuleListView) deletePolicy() {
+	if len(v.policy.Rules) == 0 {
+		return
+	}
+	lines := policyDiff(v.policy, &lifecycle.Policy{})
+	if !layout.NewConfirmDialog(v.ui.baseView.base, v.ui.baseView.es, deletePolicyTitle, lines).Display() {
+		return
+	}
+	c, err := lifecycleClient()
+	if err == nil {
+		err = c.DeleteLifecyclePolicy(v.repository)
+	}
+	v.reload(err, "lifecycle policy deleted")
+}
+
+func (v *lifecycleRuleListView) reload(err error, msg string) {
+	if err == nil {
+		err = v.ui.loadLifecyclePolicyViews(v.repository)
+	}
+	if err != nil {
+		v.ui.showError(err)
+		return
+	}
+	v.ui.showMessage(msg)
+}
+
+// policyDiff returns the colored diff between the formatted policies.
+// A policy without rules is treated as an empty document.
+func policyDiff(from, to *lifecycle.Policy) []string {
+	text := func(p *lifecycle.Policy) string {
+		if len(p.Rules) == 0 {
+			return ""
+		}
+		return lifecycle.Format(p)
+	}
+	return coloredDiff(text(from), text(to))
+}
@@ -0,0 +1,320 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	maxMemoizedQueries = 32
+)
+
+type listSorter struct {
+	name string
+	less func(a, b listViewElement) bool // ascending order
+	desc bool                            // default direction
+}
+
+type listMatcher struct {
+	key   string
+	match func(e listViewElement) bool
+}
+
+// listModel holds the elements of a list view and the filtered/sorted
+// projection of them. Projections are stored as index slices into the
+// original elements, and are memoized by sort key and query so that
+// repeated keystrokes do not rescan or resort the whole list.
+type listModel struct {
+	elements []listViewElement
+	// Lowercased Display() of all elements packed into a single string,
+	// separated by '\n'. offsets[i] is where the key of elements[i] starts.
+	keys    string
+	offsets []int32
+
+	sorters []*listSorter
+	sorter  int
+	desc    bool
+	matcher *listMatcher
+	query   string
+
+	sorted   map[string][]int32
+	filtered map[string][]int32
+	queries  []string // memoized filter keys, oldest first
+
+	visible []int32
+}
+
+func newListModel(elements []listViewElement, sorters ...*listSorter) *listModel {
+	m := &listModel{
+		elements: elements,
+		offsets:  make([]int32, len(elements)),
+		sorters:  sorters,
+		sorted:   make(map[string][]int32),
+		filtered: make(map[string][]int32),
+	}
+	if len(sorters) > 0 {
+		m.desc = sorters[0].desc
+	}
+	var sb strings.Builder
+	for i, e := range elements {
+		m.offsets[i] = int32(sb.Len())
+		sb.WriteString(strings.ToLower(strings.ReplaceAll(e.Display(), "\n", " ")))
+		sb.WriteByte('\n')
+	}
+	m.keys = sb.String()
+	m.update()
+	return m
+}
+
+func (m *listModel) key(i int32) string {
+	end := len(m.keys) - 1
+	if int(i) < len(m.offsets)-1 {
+		end = int(m.offsets[i+1]) - 1
+	}
+	return m.keys[m.offsets[i]:end]
+}
+
+func (m *listModel) length() int {
+	return len(m.visible)
+}
+
+func (m *listModel) get(i int) (listViewElement, bool) {
+	if i < 0 || i >= len(m.visible) {
+		return nil, false
+	}
+	return m.elements[m.visible[i]], true
+}
+
+// window returns at most n elements starting at top, without touching
+// the elements outside of that range.
+func (m *listModel) window(top, n int) []listViewElement {
+	if top < 0 {
+		top = 0
+	}
+	end := top + n
+	if end > len(m.visible) {
+		end = len(m.visible)
+	}
+	if top >= end {
+		return nil
+	}
+	ret := make([]listViewElement, 0, end-top)
+	for _, idx := range m.visible[top:end] {
+		ret = append(ret, m.elements[idx])
+	}
+	return ret
+}
+
+func (m *listModel) setQuery(q string) {
+	q = strings.ToLower(q)
+	if q == m.query {
+		return
+	}
+	m.query = q
+	m.update()
+}
+
+// setMatcher narrows the list to the elements accepted by matcher,
+// in addition to the query. A nil matcher accepts everything.
+func (m *listModel) setMatcher(matcher *listMatcher) {
+	if m.matcherKey() == matcher.keyOrEmpty() {
+		return
+	}
+	m.matcher = matcher
+	m.update()
+}
+
+// refreshMatcher is like setMatcher, but matches again even if matcher has the key of the
+// current one, forgetting the memoized projections. It is for matchers that may match
+// differently each time, such as those fetching tags.
+func (m *listModel) refreshMatcher(matcher *listMatcher) {
+	m.forget()
+	m.matcher = matcher
+	m.update()
+}
+
+// forget drops the memoized projections, keeping the current one displayed.
+func (m *listModel) forget() {
+	m.filtered = make(map[string][]int32)
+	m.queries = nil
+}
+
+func (m *listModel) matcherKey() string {
+	return m.matcher.keyOrEmpty()
+}
+
+func (lm *listMatcher) keyOrEmpty() string {
+	if lm == nil {
+		return ""
+	}
+	return lm.key
+}
+
+func (m *listModel) setSorter(i int, desc bool) {
+	if i < 0 || i >= len(m.sorters) {
+		return
+	}
+	if i == m.sorter && desc == m.desc {
+		return
+	}
+	m.sorter = i
+	m.desc = desc
+	m.update()
+}
+
+// setSorterByName selects the sorter called name. It returns false if there is no such sorter.
+func (m *listModel) setSorterByName(name string, desc bool) bool {
+	for i, s := range m.sorters {
+		if s.name == name {
+			m.setSorter(i, desc)
+			return true
+		}
+	}
+	return false
+}
+
+func (m *listModel) sorterNames() []string {
+	names := make([]string, len(m.sorters))
+	for i, s := range m.sorters {
+		names[i] = s.name
+	}
+	return names
+}
+
+func (m *listModel) nextSorter() {
+	if len(m.sorters) == 0 {
+		return
+	}
+	i := (m.sorter + 1) % len(m.sorters)
+	m.setSorter(i, m.sorters[i].desc)
+}
+
+func (m *listModel) toggleReverse() {
+	m.setSorter(m.sorter, !m.desc)
+}
+
+func (m *listModel) sorterName() string {
+	if len(m.sorters) == 0 {
+		return ""
+	}
+	if m.desc {
+		return m.sorters[m.sorter].name + " desc"
+	}
+	return m.sorters[m.sorter].name + " asc"
+}
+
+func (m *listModel) sortKey() string {
+	if len(m.sorters) == 0 {
+		return ""
+	}
+	if m.desc {
+		return m.sorters[m.sorter].name + "-"
+	}
+	return m.sorters[m.sorter].name + "+"
+}
+
+func (m *listModel) update() {
+	m.visible = m.filter(m.sortKey()+"\x00"+m.matcherKey(), m.query)
+}
+
+// all returns every element that passes the current matcher and query, in display order.
+func (m *listModel) all() []listViewElement {
+	return m.window(0, len(m.visible))
+}
+
+func (m *listModel) order(key string) []int32 {
+	if idx, ok := m.sorted[key]; ok {
+		return idx
+	}
+	idx := make([]int32, len(m.elements))
+	for i := range idx {
+		idx[i] = int32(i)
+	}
+	if len(m.sorters) > 0 {
+		s := m.sorters[m.sorter]
+		if m.desc {
+			sort.SliceStable(idx, func(i, j int) bool { return s.less(m.elements[idx[j]], m.elements[idx[i]]) })
+		} else {
+			sort.SliceStable(idx, func(i, j int) bool { return s.less(m.elements[idx[i]], m.elements[idx[j]]) })
+		}
+	}
+	m.sorted[key] = idx
+	return idx
+}
+
+func (m *listModel) filter(base, q string) []int32 {
+	key := base + "\x00" + q
+	if idx, ok := m.filtered[key]; ok {
+		return idx
+	}
+	if q == "" && m.matcher == nil {
+		return m.order(m.sortKey())
+	}
+	var idx []int32
+	if q == "" {
+		idx = m.match(m.order(m.sortKey()))
+	} else {
+		// A longer query can only match a subset of a shorter one,
+		// so narrow down the longest memoized prefix instead of starting over.
+		for i := len(q) - 1; i > 0; i-- {
+			if src, ok := m.filtered[base+"\x00"+q[:i]]; ok {
+				idx = m.narrow(src, q)
+				break
+			}
+		}
+		if idx == nil {
+			idx = m.scan(m.filter(base, ""), q)
+		}
+	}
+	m.memoize(key, idx)
+	return idx
+}
+
+func (m *listModel) match(src []int32) []int32 {
+	idx := make([]int32, 0)
+	for _, i := range src {
+		if m.matcher.match(m.elements[i]) {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+func (m *listModel) narrow(src []int32, q string) []int32 {
+	idx := make([]int32, 0)
+	for _, i := range src {
+		if strings.Contains(m.key(i), q) {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// scan checks every key in storage order, which keeps memory access
+// sequential, then collects the matches in the given order.
+func (m *listModel) scan(order []int32, q string) []int32 {
+	matched := make([]bool, len(m.elements))
+	n := 0
+	for i := range m.offsets {
+		if strings.Contains(m.key(int32(i)), q) {
+			matched[i] = true
+			n++
+		}
+	}
+	idx := make([]int32, 0, n)
+	for _, i := range order {
+		if matched[i] {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+func (m *listModel) memoize(key string, idx []int32) {
+	if len(m.queries) >= maxMemoizedQueries {
+		delete(m.filtered, m.queries[0])
+		m.queries = m.queries[1:]
+	}
+	m.filtered[key] = idx
+	m.queries = append(m.queries, key)
+}
@@ -0,0 +1,204 @@
+package ui
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eihigh/goban"
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/mock"
+)
+
+const (
+	benchmarkImageCount = 200000
+)
+
+var (
+	benchmarkImagesOnce sync.Once
+	benchmarkImages     []*domain.Image
+)
+
+func loadBenchmarkImages(b *testing.B) []*domain.Image {
+	benchmarkImagesOnce.Do(func() {
+		cli := mock.NewMockClientWithSize(1, benchmarkImageCount, 0)
+		imgs, err := cli.FetchAllImages("bench-repo")
+		if err != nil {
+			b.Fatal(err)
+		}
+		benchmarkImages = imgs
+	})
+	return benchmarkImages
+}
+
+func newBenchmarkListView(b *testing.B) *listViewBase {
+	imgs := loadBenchmarkImages(b)
+	return &listViewBase{
+		box:       goban.NewBox(0, 0, 80, 50),
+		model:     newListModel(listViewElementsFromImages(imgs), imageSorters...),
+		columnSet: imageColumns,
+		columns:   imageColumns.defaultColumns(),
+		title:     imageListViewTitle,
+	}
+}
+
+func TestListModel_setQuery(t *testing.T) {
+	sut := newListModel([]listViewElement{
+		domain.NewImage([]string{"v1.0.0"}, time.Time{}, "", 1, "", time.Time{}, "", ""),
+		domain.NewImage([]string{"v1.1.0", "latest"}, time.Time{}, "", 2, "", time.Time{}, "", ""),
+		domain.NewImage([]string{"v2.0.0"}, time.Time{}, "", 3, "", time.Time{}, "", ""),
+	})
+
+	sut.setQuery("V1")
+	if got := sut.length(); got != 2 {
+		t.Errorf("length() = %v; want = %v", got, 2)
+	}
+	sut.setQuery("v1.1")
+	if got := sut.length(); got != 1 {
+		t.Errorf("length() = %v; want = %v", got, 1)
+	}
+	if e, _ := sut.get(0); e.Display() != "v1.1.0, latest" {
+		t.Errorf("get(0) = %v; want = %v", e.Display(), "v1.1.0, latest")
+	}
+	sut.setQuery("")
+	if got := sut.length(); got != 3 {
+		t.Errorf("length() = %v; want = %v", got, 3)
+	}
+}
+
+func TestListModel_setSorter(t *testing.T) {
+	sut := newListModel([]listViewElement{
+		domain.NewImage([]string{"b"}, time.Time{}, "", 1, "", time.Time{}, "", ""),
+		domain.NewImage([]string{"c"}, time.Time{}, "", 3, "", time.Time{}, "", ""),
+		domain.NewImage([]string{"a"}, time.Time{}, "", 2, "", time.Time{}, "", ""),
+	}, imageSorters...)
+
+	tests := []struct {
+		sorter int
+		desc   bool
+		want   []string
+	}{
+		{1, true, []string{"c", "a", "b"}},
+		{1, false, []string{"b", "a", "c"}},
+		{2, false, []string{"a", "b", "c"}},
+	}
+	for _, test := range tests {
+		sut.setSorter(test.sorter, test.desc)
+		for i, want := range test.want {
+			if e, _ := sut.get(i); e.Display() != want {
+				t.Errorf("sorter %v(%v): get(%v) = %v; want = %v", test.sorter, test.desc, i, e.Display(), want)
+			}
+		}
+	}
+}
+
+func TestListModel_setMatcher(t *testing.T) {
+	sut := newListModel([]listViewElement{
+		domain.NewImage([]string{"v1.0.0"}, time.Time{}, "", 1, "", time.Time{}, "", ""),
+		domain.NewImage([]string{"v1.1.0"}, time.Time{}, "", 2, "", time.Time{}, "", ""),
+		domain.NewImage([]string{"v2.1.0"}, time.Time{}, "", 3, "", time.Time{}, "", ""),
+	})
+
+	sut.setMatcher(&listMatcher{"big", func(e listViewElement) bool { return e.(*domain.Image).SizeByte > 1 }})
+	if got := sut.length(); got != 2 {
+		t.Errorf("length() = %v; want = %v", got, 2)
+	}
+	sut.setQuery("v1")
+	if got := sut.length(); got != 1 {
+		t.Errorf("length() = %v; want = %v", got, 1)
+	}
+	sut.setMatcher(nil)
+	if got := sut.length(); got != 2 {
+		t.Errorf("length() = %v; want = %v", got, 2)
+	}
+}
+
+func TestListModel_refreshMatcher(t *testing.T) {
+	sut := newListModel([]listViewElement{
+		domain.NewImage([]string{"v1.0.0"}, time.Time{}, "", 1, "", time.Time{}, "", ""),
+		domain.NewImage([]string{"v1.1.0"}, time.Time{}, "", 2, "", time.Time{}, "", ""),
+	})
+
+	min := int64(2)
+	matcher := func() *listMatcher {
+		return &listMatcher{"big", func(e listViewElement) bool { return e.(*domain.Image).SizeByte >= min }}
+	}
+	sut.setMatcher(matcher())
+	if got := sut.length(); got != 1 {
+		t.Errorf("length() = %v; want = %v", got, 1)
+	}
+	min = 1
+	sut.setMatcher(matcher())
+	if got := sut.length(); got != 1 {
+		t.Errorf("length() after setMatcher() with the same key = %v; want = %v", got, 1)
+	}
+	sut.refreshMatcher(matcher())
+	if got := sut.length(); got != 2 {
+		t.Errorf("length() after refreshMatcher() = %v; want = %v", got, 2)
+	}
+}
+
+func TestListModel_window(t *testing.T) {
+	sut := newListModel([]listViewElement{
+		domain.NewImage([]string{"a"}, time.Time{}, "", 1, "", time.Time{}, "", ""),
+		domain.NewImage([]string{"b"}, time.Time{}, "", 1, "", time.Time{}, "", ""),
+		domain.NewImage([]string{"c"}, time.Time{}, "", 1, "", time.Time{}, "", ""),
+	})
+
+	if got := len(sut.window(1, 5)); got != 2 {
+		t.Errorf("len(window(1, 5)) = %v; want = %v", got, 2)
+	}
+	if got := len(sut.window(3, 5)); got != 0 {
+		t.Errorf("len(window(3, 5)) = %v; want = %v", got, 0)
+	}
+}
+
+// BenchmarkListView_selectNext measures a cursor move plus rendering the visible lines.
+func BenchmarkListView_selectNext(b *testing.B) {
+	v := newBenchmarkListView(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v.selectNext()
+		v.visibleLines()
+	}
+}
+
+// BenchmarkListView_filterKeystroke measures the first (unmemoized) keystroke of a search.
+func BenchmarkListView_filterKeystroke(b *testing.B) {
+	v := newBenchmarkListView(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v.model.filtered = make(map[string][]int32)
+		v.model.queries = nil
+		v.model.query = ""
+		v.filter("a")
+		v.visibleLines()
+	}
+}
+
+// BenchmarkListView_filterNarrowing measures typing one more character into a search.
+func BenchmarkListView_filterNarrowing(b *testing.B) {
+	v := newBenchmarkListView(b)
+	v.filter("a")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		delete(v.model.filtered, v.model.sortKey()+"\x00\x00ab")
+		v.model.query = "a"
+		v.filter("ab")
+		v.visibleLines()
+	}
+}
+
+// BenchmarkListView_sortToggle measures switching to an already memoized sort order.
+func BenchmarkListView_sortToggle(b *testing.B) {
+	v := newBenchmarkListView(b)
+	for range imageSorters {
+		v.model.nextSorter()
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v.model.nextSorter()
+		v.selectFirst()
+		v.visibleLines()
+	}
+}
@@ -0,0 +1,297 @@
+package ui
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/eihigh/goban"
+	"github.com/gdamore/tcell"
+	"github.com/lusingander/ecr-browser/dockerfile"
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/layout"
+)
+
+const (
+	manifestListViewTitle   = "LAYERS"
+	manifestBreadcrumb      = "LAYERS"
+	dockerfileListViewTitle = "DOCKERFILE"
+	dockerfileBreadcrumb    = "DOCKERFILE"
+	dockerfilePrompt        = "Dockerfile: "
+)
+
+// manifestLayer is a layer of the image shown in the manifest view.
+type manifestLayer struct {
+	index int
+	layer *domain.Layer
+	step  *domain.HistoryEntry // nil if unknown
+}
+
+func (l *manifestLayer) Display() string {
+	return fmt.Sprintf("#%d %s", l.index, l.layer.ShortDigest())
+}
+
+func (l *manifestLayer) createdBy() string {
+	if l.step == nil {
+		return noValue
+	}
+	return l.step.CreatedBy
+}
+
+// buildStep is a step of the build history shown in the Dockerfile tab.
+type buildStep struct {
+	index int
+	*dockerfile.Step
+}
+
+func (s *buildStep) Display() string {
+	return s.String()
+}
+
+var (
+	dockerfileColumns = &columnSet{[]*listColumn{
+		{"#", func(e listViewElement) listCell { return numberCell(strconv.Itoa(e.(*buildStep).index)) }},
+		{"size", func(e listViewElement) listCell { return numberCell(e.(*buildStep).SizeStr()) }},
+		{"instruction", func(e listViewElement) listCell { return textCell(e.(*buildStep).String()) }},
+	}, []string{"#", "size", "instruction"}}
+
+	manifestColumns = &columnSet{[]*listColumn{
+		{"#", func(e listViewElement) listCell { return numberCell(strconv.Itoa(e.(*manifestLayer).index)) }},
+		{"digest", func(e listViewElement) listCell { return textCell(e.(*manifestLayer).layer.ShortDigest()) }},
+		{"size", func(e listViewElement) listCell { return numberCell(e.(*manifestLayer).layer.SizeStr()) }},
+		{"created_by", func(e listViewElement) listCell { return textCell(e.(*manifestLayer).createdBy()) }},
+	}, []string{"#", "digest", "size", "created_by"}}
+)
+
+type manifestListView struct {
+	*listViewBase
+	repository string
+	image      *domain.Image
+	manifest   *domain.Manifest
+	steps      []*dockerfile.Step
+	dockerfile bool
+}
+
+func newManifestListView(b *goban.Box, repo string, img *domain.Image, m *domain.Manifest, showDockerfile bool) *manifestListView {
+	base := &listViewBase{
+		box:       b,
+		model:     newListModel(manifestLayerElements(m)),
+		columnSet: manifestColumns,
+		title:     manifestListViewTitle,
+	}
+	steps := dockerfile.Reconstruct(m)
+	if showDockerfile {
+		base.model = newListModel(buildStepElements(steps))
+		base.columnSet = dockerfileColumns
+		base.title = dockerfileListViewTitle
+	}
+	base.columns = base.columnSet.defaultColumns()
+	return &manifestListView{
+		listViewBase: base,
+		repository:   repo,
+		image:        img,
+		manifest:     m,
+		steps:        steps,
+		dockerfile:   showDockerfile,
+	}
+}
+
+func manifestLayerElements(m *domain.Manifest) []listViewElement {
+	steps := m.LayerHistory()
+	elems := make([]listViewElement, len(m.Layers))
+	for i, l := range m.Layers {
+		elems[i] = &manifestLayer{i, l, steps[i]}
+	}
+	return elems
+}
+
+func buildStepElements(steps []*dockerfile.Step) []listViewElement {
+	elems := make([]listViewElement, len(steps))
+	for i, s := range steps {
+		elems[i] = &buildStep{i, s}
+	}
+	return elems
+}
+
+func (v *manifestListView) operate(key *tcell.EventKey) {
+	dispatch(v.keyBindings(), key)
+}
+
+func (v *manifestListView) keyBindings() []*keyBindingGroup {
+	return append([]*keyBindingGroup{
+		{
+			title: manifestListViewTitle,
+			bindings: []*keyBinding{
+				{runes: []rune{'l'}, keys: []tcell.Key{tcell.KeyEnter}, desc: "browse files of the layer", action: v.browseLayer},
+				{runes: []rune{'f'}, desc: "browse files of the image", action: func() { v.ui.showError(v.ui.loadImageFileViews(v.repository, v.image, v.manifest)) }},
+				{runes: []rune{'d'}, desc: "switch between layers and Dockerfile", action: func() { v.ui.showInspectViews(v.repository, v.image, v.manifest, !v.dockerfile) }},
+				{runes: []rune{'w'}, desc: "write reconstructed Dockerfile", action: v.writeDockerfile},
+				{runes: []rune{'h'}, desc: "move to image list", action: func() { v.ui.loadImageViews(v.repository) }},
+			},
+		},
+	}, v.listViewBase.keyBindings()...)
+}
+
+func (v *manifestListView) browseLayer() {
+	switch e := v.current().(type) {
+	case *manifestLayer:
+		v.ui.showError(v.ui.loadLayerFileViews(v.repository, v.image, v.manifest, e.index))
+	case *buildStep:
+		if e.Layer == nil {
+			return
+		}
+		for i, l := range v.manifest.Layers {
+			if l == e.Layer {
+				v.ui.showError(v.ui.loadLayerFileViews(v.repository, v.image, v.manifest, i))
+				return
+			}
+		}
+	}
+}
+
+func (v *manifestListView) writeDockerfile() {
+	if len(v.steps) == 0 {
+		v.ui.showMessage("image has no build history")
+		return
+	}
+	b := v.ui.baseView.base
+	box := goban.NewBox(b.Pos.X+1, b.Pos.Y+b.Size.Y-1, b.Size.X-2, 1)
+	ref := v.image.Digest[strings.Index(v.image.Digest, ":")+1:]
+	if len(ref) > 12 {
+		ref = ref[:12]
+	}
+	if len(v.image.Tags) > 0 {
+		ref = v.image.Tags[0]
+	}
+	init := fmt.Sprintf("Dockerfile.%s-%s", strings.Replace(v.repository, "/", "-", -1), ref)
+	name, ok := layout.NewInputLine(box, v.ui.baseView.es, dockerfilePrompt).Read(init)
+	if !ok || name == "" {
+		return
+	}
+	content := strings.Join(dockerfile.Render(v.steps), "\n") + "\n"
+	if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+		v.ui.showError(err)
+		return
+	}
+	v.ui.showMessage(fmt.Sprintf("wrote %d steps to %s", len(v.steps), name))
+}
+
+type manifestDetailView struct {
+	*detailViewBase
+}
+
+func newManifestDetailView(b *goban.Box) *manifestDetailView {
+	return &manifestDetailView{newDetailViewBase(b)}
+}
+
+func (v *manifestDetailView) update(e listViewElement) {
+	switch e := e.(type) {
+	case *manifestLayer:
+		v.SetLines(v.layerLines(e))
+	case *buildStep:
+		v.SetLines(v.stepLines(e))
+	default:
+		v.SetLines(nil)
+	}
+}
+
+func (v *manifestDetailView) stepLines(s *buildStep) []string {
+	ls := []string{"INSTRUCTION:"}
+	for _, l := range s.Lines() {
+		ls = append(ls, "  "+l)
+	}
+	if len(s.BuildArgs) > 0 {
+		ls = append(ls, "BUILD ARGS:")
+		for _, a := range s.BuildArgs {
+			ls = append(ls, "  "+a)
+		}
+	}
+	ls = append(ls, "LAYER:")
+	switch {
+	case s.Empty():
+		ls = append(ls, "  (empty layer)")
+	case s.Layer == nil:
+		ls = append(ls, "  unknown (history does not match the layers)")
+	default:
+		ls = append(ls, "  "+s.Layer.Digest, "  "+s.Layer.SizeStr())
+	}
+	ls = append(ls,
+		"CREATED AT:",
+		"  "+domain.FormatTime(s.History.CreatedAt),
+	)
+	if s.History.Comment != "" {
+		ls = append(ls, "COMMENT:", "  "+s.History.Comment)
+	}
+	return append(ls,
+		"CREATED BY:",
+		"  "+s.History.CreatedBy,
+	)
+}
+
+func (v *manifestDetailView) layerLines(l *manifestLayer) []string {
+	ls := []string{
+		"DIGEST:",
+		"  " + l.layer.Digest,
+		"SIZE:",
+		"  " + l.layer.SizeStr(),
+		"MEDIA TYPE:",
+		"  " + l.layer.MediaType,
+	}
+	if l.step != nil {
+		ls = append(ls,
+			"CREATED AT:",
+			"  "+domain.FormatTime(l.step.CreatedAt),
+		)
+	}
+	return append(ls,
+		"CREATED BY:",
+		"  "+l.createdBy(),
+	)
+}
+
+// loadManifestViews shows the layers of img.
+func (u *ui) loadManifestViews(repo string, img *domain.Image) error {
+	if img == nil {
+		return nil
+	}
+	c, err := imageManifestClient()
+	if err != nil {
+		return err
+	}
+	loading := layout.NewLoadingDialog(u.baseView.base, u.baseView.es)
+	go loading.Display()
+	m, err := c.FetchImageManifest(repo, img)
+	loading.Close()
+	if err != nil {
+		return err
+	}
+	u.showManifestViews(repo, img, m)
+	return nil
+}
+
+func (u *ui) showManifestViews(repo string, img *domain.Image, m *domain.Manifest) {
+	u.showInspectViews(repo, img, m, false)
+}
+
+// showInspectViews shows the layers of img, or its build history reconstructed as a Dockerfile.
+func (u *ui) showInspectViews(repo string, img *domain.Image, m *domain.Manifest, showDockerfile bool) {
+	lv := newManifestListView(u.baseView.gridLayout.list, repo, img, m, showDockerfile)
+	dv := newManifestDetailView(u.baseView.gridLayout.detail)
+	lv.addObserver(dv)
+	lv.setBaseUI(u)
+	u.baseView.resetBreadcrumb()
+	u.popViews()
+	u.pushViews(lv, dv)
+	u.setPanes(lv, dv)
+	u.baseView.pushBreadcrumb(repo)
+	u.baseView.pushBreadcrumb(img.GetTag())
+	if showDockerfile {
+		u.baseView.pushBreadcrumb(dockerfileBreadcrumb)
+		u.baseView.showMessage(fmt.Sprintf("%d steps, %d layers, %s", len(lv.steps), len(m.Layers), humanize.Bytes(uint64(m.TotalSize()))))
+		return
+	}
+	u.baseView.pushBreadcrumb(manifestBreadcrumb)
+	u.baseView.showMessage(fmt.Sprintf("%d layers, %s", len(m.Layers), humanize.Bytes(uint64(m.TotalSize()))))
+}
@@ -0,0 +1,248 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eihigh/goban"
+	"github.com/gdamore/tcell"
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/iam"
+	"github.com/lusingander/ecr-browser/layout"
+	"github.com/lusingander/ecr-browser/util"
+)
+
+const (
+	policyStatementListViewTitle = "POLICY STATEMENTS"
+	repositoryPolicyBreadcrumb   = "PERMISSIONS"
+	applyRepositoryPolicyTitle   = "APPLY REPOSITORY POLICY"
+	deleteRepositoryPolicyTitle  = "DELETE REPOSITORY POLICY"
+	repositoryPolicyFile         = "repository-policy-*.json"
+)
+
+// repositoryPolicyTemplate is the starting point for a repository without a policy.
+const repositoryPolicyTemplate = `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Sid": "AllowPull",
+      "Effect": "Allow",
+      "Principal": {
+        "AWS": "arn:aws:iam::123456789012:root"
+      },
+      "Action": [
+        "ecr:BatchGetImage",
+        "ecr:GetDownloadUrlForLayer",
+        "ecr:BatchCheckLayerAvailability"
+      ]
+    }
+  ]
+}`
+
+func repositoryPolicyClient() (domain.RepositoryPolicyClient, error) {
+	if c, ok := client.(domain.RepositoryPolicyClient); ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("current client does not support repository policies")
+}
+
+// repositoryAccount returns the account that owns repo, from its ARN.
+func repositoryAccount(repo string) string {
+	repos, err := client.FetchAllRepositories()
+	if err != nil {
+		return ""
+	}
+	for _, r := range repos {
+		if r.Name == repo {
+			return iam.AccountID(r.Arn)
+		}
+	}
+	return ""
+}
+
+type policyStatementListView struct {
+	*listViewBase
+	repository string
+	text       string // empty if the repository has no policy
+}
+
+func newPolicyStatementListView(b *goban.Box, repo, text string, doc *iam.Document) *policyStatementListView {
+	elems := make([]listViewElement, len(doc.Statement))
+	for i, s := range doc.Statement {
+		elems[i] = s
+	}
+	return &policyStatementListView{
+		listViewBase: &listViewBase{
+			box:   b,
+			model: newListModel(elems),
+			title: policyStatementListViewTitle,
+		},
+		repository: repo,
+		text:       text,
+	}
+}
+
+func (v *policyStatementListView) operate(key *tcell.EventKey) {
+	dispatch(v.keyBindings(), key)
+}
+
+func (v *policyStatementListView) keyBindings() []*keyBindingGroup {
+	return append([]*keyBindingGroup{
+		{
+			title: policyStatementListViewTitle,
+			bindings: []*keyBinding{
+				{runes: []rune{'h'}, desc: "move to repository list", action: func() { v.ui.loadRepositoryView(false) }},
+				{runes: []rune{'l'}, desc: "move to image list", action: func() { v.ui.loadImageViews(v.repository) }},
+				{runes: []rune{'E'}, desc: "edit policy in $EDITOR", action: v.editPolicy},
+				{runes: []rune{'D'}, desc: "delete policy", action: v.deletePolicy},
+			},
+		},
+	}, v.listViewBase.keyBindings()...)
+}
+
+func (v *policyStatementListView) editPolicy() {
+	text := iam.Format(v.text)
+	if v.text == "" {
+		text = repositoryPolicyTemplate
+	}
+	v.editPolicyText(text)
+}
+
+// editPolicyText opens text in $EDITOR. If the result is not a valid policy,
+// the user can edit it again instead of losing the changes.
+func (v *policyStatementListView) editPolicyText(text string) {
+	v.ui.editText(text, repositoryPolicyFile, func(edited string, err error) {
+		if err != nil {
+			v.ui.showError(err)
+			return
+		}
+		doc, err := iam.Parse(edited)
+		if err == nil {
+			err = iam.Validate(doc)
+		}
+		if err != nil {
+			if v.ui.confirmRetry(err) {
+				v.editPolicyText(edited)
+			}
+			return
+		}
+		if iam.Format(edited) == iam.Format(v.text) {
+			v.ui.showMessage("repository policy not changed")
+			return
+		}
+		v.applyPolicy(edited)
+	})
+}
+
+func (v *policyStatementListView) applyPolicy(text string) {
+	lines := coloredDiff(iam.Format(v.text), iam.Format(text))
+	if !layout.NewConfirmDialog(v.ui.baseView.base, v.ui.baseView.es, applyRepositoryPolicyTitle, lines).Display() {
+		return
+	}
+	c, err := repositoryPolicyClient()
+	if err == nil {
+		err = c.PutRepositoryPolicy(v.repository, text)
+	}
+	v.reload(err, "repository policy applied")
+}
+
+func (v *policyStatementListView) deletePolicy() {
+	if v.text == "" {
+		return
+	}
+	lines := coloredDiff(iam.Format(v.text), "")
+	if !layout.NewConfirmDialog(v.ui.baseView.base, v.ui.baseView.es, deleteRepositoryPolicyTitle, lines).Display() {
+		return
+	}
+	c, err := repositoryPolicyClient()
+	if err == nil {
+		err = c.DeleteRepositoryPolicy(v.repository)
+	}
+	v.reload(err, "repository policy deleted")
+}
+
+func (v *policyStatementListView) reload(err error, msg string) {
+	if err == nil {
+		err = v.ui.loadRepositoryPolicyViews(v.repository)
+	}
+	if err != nil {
+		v.ui.showError(err)
+		return
+	}
+	v.ui.showMessage(msg)
+}
+
+type policyStatementDetailView struct {
+	*detailViewBase
+	account  string
+	selected *iam.Statement
+}
+
+func newPolicyStatementDetailView(b *goban.Box, account string) *policyStatementDetailView {
+	return &policyStatementDetailView{newDetailViewBase(b), account, nil}
+}
+
+func (v *policyStatementDetailView) update(e listViewElement) {
+	v.selected, _ = e.(*iam.Statement)
+	v.SetLines(v.lines())
+}
+
+func (v *policyStatementDetailView) lines() []string {
+	if v.selected == nil {
+		return nil
+	}
+	ls := []string{"GRANTS:"}
+	for _, g := range v.selected.Grants(v.account) {
+		ls = append(ls, "  "+g.String())
+	}
+	ls = append(ls, "STATEMENT:")
+	for _, l := range strings.Split(v.selected.JSON(), "\n") {
+		ls = append(ls, "  "+util.HighlightJSON(l))
+	}
+	return ls
+}
+
+func (u *ui) loadRepositoryPolicyViews(repo string) error {
+	loading := layout.NewLoadingDialog(u.baseView.base, u.baseView.es)
+	go loading.Display()
+	defer loading.Close()
+
+	c, err := repositoryPolicyClient()
+	if err != nil {
+		return err
+	}
+	text, err := c.FetchRepositoryPolicy(repo)
+	if err != nil {
+		return err
+	}
+	doc := &iam.Document{}
+	if text != "" {
+		if doc, err = iam.Parse(text); err != nil {
+			return err
+		}
+	}
+	account := repositoryAccount(repo)
+	lv := newPolicyStatementListView(u.baseView.gridLayout.list, repo, text, doc)
+	dv := newPolicyStatementDetailView(u.baseView.gridLayout.detail, account)
+	lv.addObserver(dv)
+	lv.setBaseUI(u)
+	u.baseView.resetBreadcrumb()
+	u.popViews()
+	u.pushViews(lv, dv)
+	u.setPanes(lv, dv)
+	u.baseView.pushBreadcrumb(repo)
+	u.baseView.pushBreadcrumb(repositoryPolicyBreadcrumb)
+	if text == "" {
+		u.baseView.showMessage(fmt.Sprintf("%s has no repository policy, press E to create one", repo))
+		return nil
+	}
+	cross := 0
+	grants := doc.Grants(account)
+	for _, g := range grants {
+		if g.CrossAccount {
+			cross++
+		}
+	}
+	u.baseView.showMessage(fmt.Sprintf("%d grants, %d cross-account", len(grants), cross))
+	return nil
+}
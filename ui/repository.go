@@ -2,9 +2,13 @@ package ui
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/dustin/go-humanize"
 	"github.com/eihigh/goban"
 	"github.com/gdamore/tcell"
+	"github.com/lusingander/ecr-browser/cost"
 	"github.com/lusingander/ecr-browser/domain"
 	"github.com/pkg/browser"
 )
@@ -22,33 +26,67 @@ func newRepositoryListView(b *goban.Box) (*repositoryListView, error) {
 	if err != nil {
 		return nil, err
 	}
-	domain.SortRepositories(repos)
+	refreshPullThroughCacheRules()
+	columns := repositoryColumns.defaultColumns()
+	if hasCachedRepositories(repos) {
+		columns = append(columns, findColumn(repositoryColumns.all, "cache"))
+	}
 	return &repositoryListView{
 		listViewBase: &listViewBase{
-			box:      b,
-			elements: listViewElementsFromRepositories(repos),
-			title:    repositoryListViewTitle,
+			box:       b,
+			model:     newListModel(listViewElementsFromRepositories(repos), repositorySorters...),
+			fields:    repositoryFilterFields,
+			ageFields: repositoryAgeFields,
+			tagField:  repositoryTagField,
+			tagKeys:   knownRepositoryTagKeys,
+			columnSet: repositoryColumns,
+			columns:   columns,
+			title:     repositoryListViewTitle,
 		},
 	}, nil
 }
 
+var (
+	repositorySorters = []*listSorter{
+		{"name", func(a, b listViewElement) bool {
+			return a.(*domain.Repository).Name < b.(*domain.Repository).Name
+		}, false},
+		{"created", func(a, b listViewElement) bool {
+			return a.(*domain.Repository).CreatedAt.Before(b.(*domain.Repository).CreatedAt)
+		}, true},
+	}
+)
+
 func listViewElementsFromRepositories(repos []*domain.Repository) []listViewElement {
-	var elems []listViewElement
-	for _, repo := range repos {
-		elems = append(elems, repo)
+	elems := make([]listViewElement, len(repos))
+	for i, repo := range repos {
+		elems[i] = repo
 	}
 	return elems
 }
 
 func (v *repositoryListView) operate(key *tcell.EventKey) {
-	switch key.Rune() {
-	case 'l':
-		v.ui.loadImageViews(v.currentRepositoryName())
-	case 'o':
-		v.openWebBrowser()
-	default:
-		v.listViewBase.operate(key)
-	}
+	dispatch(v.keyBindings(), key)
+}
+
+func (v *repositoryListView) keyBindings() []*keyBindingGroup {
+	return append([]*keyBindingGroup{
+		{
+			title: repositoryListViewTitle,
+			bindings: []*keyBinding{
+				{runes: []rune{'l'}, desc: "move to image list", action: func() { v.ui.loadImageViews(v.currentRepositoryName()) }},
+				{runes: []rune{'o'}, desc: "open in web browser", action: func() { v.ui.showError(v.openWebBrowser()) }},
+				{runes: []rune{'p'}, desc: "show lifecycle policy", action: func() { v.ui.showError(v.ui.loadLifecyclePolicyViews(v.currentRepositoryName())) }},
+				{runes: []rune{'P'}, desc: "show permissions policy", action: func() { v.ui.showError(v.ui.loadRepositoryPolicyViews(v.currentRepositoryName())) }},
+				{runes: []rune{'m'}, desc: "toggle tag mutability", action: v.toggleTagMutability},
+				{runes: []rune{'i'}, desc: "toggle scan on push", action: v.toggleScanOnPush},
+				{runes: []rune{'n'}, desc: "create repository", action: v.createRepository},
+				{runes: []rune{'D'}, desc: "delete repository", action: v.deleteRepository},
+				{runes: []rune{'U'}, desc: "show storage dashboard", action: func() { v.ui.showError(v.ui.loadDashboardViews()) }},
+				{runes: []rune{'C'}, desc: "show pull-through cache rules", action: func() { v.ui.showError(v.ui.loadCacheRuleViews()) }},
+			},
+		},
+	}, v.listViewBase.keyBindings()...)
 }
 
 func (v *repositoryListView) currentRepositoryName() string {
@@ -59,50 +97,177 @@ func (v *repositoryListView) currentRepositoryName() string {
 }
 
 func (v *repositoryListView) openWebBrowser() error {
-	repo := v.currentRepositoryName()
-	url := createECRConsoleRepositoryURL(repo)
+	repo, ok := v.current().(*domain.Repository)
+	if !ok {
+		return nil
+	}
+	url := createECRConsoleRepositoryURL(repo.Name)
+	if c, ok := client.(domain.ConsoleClient); ok {
+		url = c.RepositoryConsoleURL(repo)
+	}
+	if url == "" {
+		return fmt.Errorf("%s has no web page", repo.Name)
+	}
 	return browser.OpenURL(url)
 }
 
 type repositoryDetailView struct {
-	box      *goban.Box
+	*detailViewBase
 	selected *domain.Repository
 }
 
 func newRepositoryDetailView(b *goban.Box) *repositoryDetailView {
-	return &repositoryDetailView{b, nil}
+	return &repositoryDetailView{newDetailViewBase(b), nil}
 }
 
 func (v *repositoryDetailView) update(e listViewElement) {
-	if repo, ok := e.(*domain.Repository); ok {
-		v.selected = repo
+	v.selected, _ = e.(*domain.Repository)
+	v.SetLines(v.lines())
+}
+
+func (v *repositoryDetailView) lines() []string {
+	if v.selected == nil {
+		return nil
+	}
+	ls := []string{
+		"NAME:",
+		"  " + v.selected.Name,
+		"URI:",
+		"  " + v.selected.Uri,
+		"ARN:",
+		"  " + v.selected.Arn,
+		"REGISTRY ID:",
+		"  " + v.selected.RegistryId,
+	}
+	// public repositories and other registries have no such settings
+	if v.selected.TagMutability != "" {
+		ls = append(ls,
+			"TAG MUTABILITY:",
+			"  "+v.selected.TagMutability,
+			"SCAN ON PUSH:",
+			"  "+v.selected.ScanOnPushStr(),
+			"ENCRYPTION:",
+			"  "+v.selected.EncryptionStr(),
+		)
+	}
+	if r := domain.FindPullThroughCacheRule(pullThroughCacheRules, v.selected.Name); r != nil {
+		ls = append(ls,
+			"PULL-THROUGH CACHE:",
+			"  "+r.UpstreamRepository(v.selected.Name),
+			"  (rule "+r.Prefix+")",
+		)
+	}
+	ls = append(ls,
+		"CREATED AT:",
+		"  "+v.selected.CreatedAtStr(),
+		"ESTIMATED COST:",
+		"  "+v.costStr(),
+		"ACCOUNT TOTAL:",
+		"  "+accountCostStr(),
+	)
+	if _, ok := client.(domain.RepositoryCatalogClient); ok {
+		ls = append(ls, v.catalogLines()...)
+	}
+	return append(append(ls, "TAGS:"), v.tagLines()...)
+}
+
+// catalogLines shows the gallery page of a public repository, fetched on first display.
+func (v *repositoryDetailView) catalogLines() []string {
+	d, err := fetchRepositoryCatalogData(v.selected)
+	if err != nil {
+		return []string{"CATALOG:", "  " + err.Error()}
+	}
+	ls := []string{
+		"GALLERY:",
+		"  " + v.selected.GalleryURL(),
+		"DESCRIPTION:",
+		"  " + valueOrNone(d.Description),
+		"ARCHITECTURES:",
+		"  " + valueOrNone(strings.Join(d.Architectures, ", ")),
+		"OPERATING SYSTEMS:",
+		"  " + valueOrNone(strings.Join(d.OperatingSystems, ", ")),
+	}
+	if d.MarketplaceCertified {
+		ls = append(ls, "MARKETPLACE:", "  certified")
+	}
+	ls = append(ls, "ABOUT:")
+	ls = append(ls, textLines(d.AboutText)...)
+	ls = append(ls, "USAGE:")
+	return append(ls, textLines(d.UsageText)...)
+}
+
+func valueOrNone(s string) string {
+	if s == "" {
+		return noValue
+	}
+	return s
+}
+
+// textLines indents each line of a multi-line text.
+func textLines(text string) []string {
+	if strings.TrimSpace(text) == "" {
+		return []string{"  " + noValue}
+	}
+	var ls []string
+	for _, l := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		ls = append(ls, "  "+strings.TrimRight(l, "\r"))
+	}
+	return ls
+}
+
+// costStr is known once the images of the repository have been fetched.
+func (v *repositoryDetailView) costStr() string {
+	s, ok := repositoryStats[v.selected.Name]
+	if !ok {
+		return noValue
+	}
+	return fmt.Sprintf("%s / month (%s stored)", cost.Format(s.monthlyCost()), humanize.Bytes(uint64(s.storedByte)))
+}
+
+func accountCostStr() string {
+	total, n := accountCost()
+	if n == 0 {
+		return noValue
+	}
+	return fmt.Sprintf("%s / month (fetched repositories: %d)", cost.Format(total), n)
+}
+
+// tagLines fetches the resource tags of the selected repository on first display.
+func (v *repositoryDetailView) tagLines() []string {
+	tags, err := fetchRepositoryTags(v.selected)
+	if err != nil {
+		return []string{"  " + err.Error()}
+	}
+	if len(tags) == 0 {
+		return []string{"  " + noValue}
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var ls []string
+	for _, k := range keys {
+		ls = append(ls, fmt.Sprintf("  %s=%s", k, tags[k]))
 	}
+	return ls
 }
 
-func (v *repositoryDetailView) View() {
-	b := v.box.Enclose("DETAIL")
-	if v.selected != nil {
-		b.Puts("NAME:")
-		b.Puts("  " + v.selected.Name)
-		b.Puts("URI:")
-		b.Puts("  " + v.selected.Uri)
-		b.Puts("ARN:")
-		b.Puts("  " + v.selected.Arn)
-		b.Puts("TAG MUTABILITY:")
-		b.Puts("  " + v.selected.TagMutability)
-		b.Puts("CREATED AT:")
-		b.Puts("  " + v.selected.CreatedAtStr())
+func currentRegion() string {
+	if c, ok := client.(domain.SessionClient); ok {
+		return c.Region()
 	}
+	return domain.TargetRegion
 }
 
 func createECRConsoleURL() string {
-	region := domain.TargetRegion
+	region := currentRegion()
 	url := "https://%s.console.aws.amazon.com/ecr/repositories?region=%s"
 	return fmt.Sprintf(url, region, region)
 }
 
 func createECRConsoleRepositoryURL(repo string) string {
-	region := domain.TargetRegion
+	region := currentRegion()
 	url := "https://%s.console.aws.amazon.com/ecr/repositories/%s/?region=%s"
 	return fmt.Sprintf(url, region, repo, region)
 }
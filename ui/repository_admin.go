@@ -0,0 +1,242 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/eihigh/goban"
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/layout"
+)
+
+const (
+	createRepositoryTitle = "NEW REPOSITORY"
+	deleteRepositoryTitle = "DELETE REPOSITORY"
+	changeSettingTitle    = "CHANGE REPOSITORY SETTING"
+)
+
+const (
+	repositoryFieldName = iota
+	repositoryFieldTagMutability
+	repositoryFieldScanOnPush
+	repositoryFieldEncryptionType
+	repositoryFieldKmsKey
+	repositoryFieldTags
+)
+
+var (
+	boolOptions = []string{"false", "true"}
+)
+
+func repositoryAdminClient() (domain.RepositoryAdminClient, error) {
+	if c, ok := client.(domain.RepositoryAdminClient); ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("current client does not support creating and deleting repositories")
+}
+
+func repositoryFields() []*layout.FormField {
+	return []*layout.FormField{
+		repositoryFieldName:           {Label: "name"},
+		repositoryFieldTagMutability:  {Label: "tag mutability", Value: domain.TagMutabilityMutable, Options: domain.TagMutabilities},
+		repositoryFieldScanOnPush:     {Label: "scan on push", Value: "false", Options: boolOptions},
+		repositoryFieldEncryptionType: {Label: "encryption", Value: domain.EncryptionTypeAES256, Options: domain.EncryptionTypes},
+		repositoryFieldKmsKey:         {Label: "kms key arn"},
+		repositoryFieldTags:           {Label: "tags (k=v,...)"},
+	}
+}
+
+func createRepositoryInputFromFields(fs []*layout.FormField) (*domain.CreateRepositoryInput, error) {
+	var errs []string
+	name := strings.TrimSpace(fs[repositoryFieldName].Value)
+	if err := domain.ValidateRepositoryName(name); err != nil {
+		errs = append(errs, err.Error())
+	}
+	scanOnPush, err := strconv.ParseBool(fs[repositoryFieldScanOnPush].Value)
+	if err != nil {
+		errs = append(errs, "scan on push must be true or false")
+	}
+	encryption := fs[repositoryFieldEncryptionType].Value
+	key := strings.TrimSpace(fs[repositoryFieldKmsKey].Value)
+	if key != "" && encryption != domain.EncryptionTypeKMS {
+		errs = append(errs, "kms key arn is only allowed with KMS encryption")
+	}
+	tags, err := parseTags(fs[repositoryFieldTags].Value)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return &domain.CreateRepositoryInput{
+		Name:           name,
+		TagMutability:  fs[repositoryFieldTagMutability].Value,
+		ScanOnPush:     scanOnPush,
+		EncryptionType: encryption,
+		KmsKey:         key,
+		Tags:           tags,
+	}, nil
+}
+
+// parseTags reads "key=value" pairs separated by commas.
+func parseTags(s string) (map[string]string, error) {
+	tags := make(map[string]string)
+	for _, kv := range strings.Split(s, ",") {
+		if strings.TrimSpace(kv) == "" {
+			continue
+		}
+		i := strings.Index(kv, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("tag %q must be key=value", strings.TrimSpace(kv))
+		}
+		tags[strings.TrimSpace(kv[:i])] = strings.TrimSpace(kv[i+1:])
+	}
+	return tags, nil
+}
+
+func (v *repositoryListView) createRepository() {
+	c, err := repositoryAdminClient()
+	if err != nil {
+		v.ui.showError(err)
+		return
+	}
+	fields := repositoryFields()
+	for {
+		if !layout.NewFormDialog(v.ui.baseView.base, v.ui.baseView.es, createRepositoryTitle, fields).Display() {
+			return
+		}
+		input, err := createRepositoryInputFromFields(fields)
+		var repo *domain.Repository
+		if err == nil {
+			repo, err = c.CreateRepository(input)
+		}
+		if err == nil {
+			recordImages(repo.Name, nil)
+			v.ui.reloadRepositoryView(func(l *listViewBase) {
+				l.selectWhere(func(e listViewElement) bool { return e.(*domain.Repository).Name == repo.Name })
+			})
+			v.ui.showMessage(fmt.Sprintf("created %s", repo.Name))
+			return
+		}
+		if !v.ui.confirmRetry(err) {
+			return
+		}
+	}
+}
+
+func (v *repositoryListView) deleteRepository() {
+	repo := v.currentRepositoryName()
+	if repo == "" {
+		return
+	}
+	c, err := repositoryAdminClient()
+	if err != nil {
+		v.ui.showError(err)
+		return
+	}
+	imgs, err := client.FetchAllImages(repo)
+	if err != nil {
+		v.ui.showError(err)
+		return
+	}
+	force := false
+	if len(imgs) > 0 {
+		var size int64
+		for _, img := range imgs {
+			size += img.SizeByte
+		}
+		lines := []string{
+			fmt.Sprintf("%s still has %d images (%s).", repo, len(imgs), humanize.Bytes(uint64(size))),
+			"Delete them together with the repository?",
+		}
+		if !layout.NewConfirmDialog(v.ui.baseView.base, v.ui.baseView.es, deleteRepositoryTitle, lines).Display() {
+			return
+		}
+		force = true
+	}
+	b := v.ui.baseView.base
+	box := goban.NewBox(b.Pos.X+1, b.Pos.Y+b.Size.Y-1, b.Size.X-2, 1)
+	name, ok := layout.NewInputLine(box, v.ui.baseView.es, fmt.Sprintf("type %s to delete: ", repo)).Read("")
+	if !ok {
+		return
+	}
+	if name != repo {
+		v.ui.showMessage("repository name did not match, nothing deleted")
+		return
+	}
+	if err := c.DeleteRepository(repo, force); err != nil {
+		v.ui.showError(err)
+		return
+	}
+	delete(repositoryStats, repo)
+	cursor := v.cursor()
+	v.ui.reloadRepositoryView(func(l *listViewBase) { l.selectIndex(cursor) })
+	v.ui.showMessage(fmt.Sprintf("deleted %s", repo))
+}
+
+// reloadRepositoryView rebuilds the repository list from the client cache
+// and calls f with the new list to restore the selection.
+func (u *ui) reloadRepositoryView(f func(*listViewBase)) {
+	if err := u.loadRepositoryView(false); err != nil {
+		u.showError(err)
+		return
+	}
+	if l, err := u.focusedList(); err == nil {
+		f(l)
+	}
+}
+
+func repositorySettingsClient() (domain.RepositorySettingsClient, error) {
+	if c, ok := client.(domain.RepositorySettingsClient); ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("current client does not support changing repository settings")
+}
+
+func (v *repositoryListView) currentRepository() *domain.Repository {
+	repo, _ := v.current().(*domain.Repository)
+	return repo
+}
+
+func (v *repositoryListView) toggleTagMutability() {
+	repo := v.currentRepository()
+	if repo == nil {
+		return
+	}
+	to := repo.ToggledTagMutability()
+	v.changeSetting(repo, "tag mutability", repo.TagMutability, to, func(c domain.RepositorySettingsClient) error {
+		return c.SetTagMutability(repo, to)
+	})
+}
+
+func (v *repositoryListView) toggleScanOnPush() {
+	repo := v.currentRepository()
+	if repo == nil {
+		return
+	}
+	to := !repo.ScanOnPush
+	v.changeSetting(repo, "scan on push", repo.ScanOnPushStr(), domain.EnabledStr(to), func(c domain.RepositorySettingsClient) error {
+		return c.SetScanOnPush(repo, to)
+	})
+}
+
+// changeSetting asks for confirmation and calls set, which updates repo in place.
+func (v *repositoryListView) changeSetting(repo *domain.Repository, name, from, to string, set func(domain.RepositorySettingsClient) error) {
+	c, err := repositorySettingsClient()
+	if err != nil {
+		v.ui.showError(err)
+		return
+	}
+	lines := []string{fmt.Sprintf("Change %s of %s from %s to %s?", name, repo.Name, from, to)}
+	if !layout.NewConfirmDialog(v.ui.baseView.base, v.ui.baseView.es, changeSettingTitle, lines).Display() {
+		return
+	}
+	if err := set(c); err != nil {
+		v.ui.showError(err)
+		return
+	}
+	v.notify()
+	v.ui.showMessage(fmt.Sprintf("%s of %s is now %s", name, repo.Name, to))
+}
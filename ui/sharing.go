@@ -0,0 +1,264 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/dustin/go-humanize"
+	"github.com/eihigh/goban"
+	"github.com/gdamore/tcell"
+	"github.com/lusingander/ecr-browser/dedup"
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/layout"
+)
+
+const (
+	sharingImageListViewTitle = "LAYER SHARING BY IMAGE"
+	sharingLayerListViewTitle = "LAYER SHARING BY LAYER"
+	sharingBreadcrumb         = "LAYER SHARING"
+)
+
+var (
+	sharingImageSorters = []*listSorter{
+		{"unique", func(a, b listViewElement) bool {
+			return a.(*dedup.ImageUsage).UniqueByte < b.(*dedup.ImageUsage).UniqueByte
+		}, true},
+		{"shared", func(a, b listViewElement) bool {
+			return a.(*dedup.ImageUsage).SharedByte < b.(*dedup.ImageUsage).SharedByte
+		}, true},
+		{"size", func(a, b listViewElement) bool {
+			return a.(*dedup.ImageUsage).Image.SizeByte < b.(*dedup.ImageUsage).Image.SizeByte
+		}, true},
+		{"pushed", func(a, b listViewElement) bool {
+			return a.(*dedup.ImageUsage).Image.PushedAt.Before(b.(*dedup.ImageUsage).Image.PushedAt)
+		}, true},
+		{"tag", func(a, b listViewElement) bool {
+			return a.(*dedup.ImageUsage).Image.GetTag() < b.(*dedup.ImageUsage).Image.GetTag()
+		}, false},
+	}
+
+	sharingLayerSorters = []*listSorter{
+		{"images", func(a, b listViewElement) bool {
+			return len(a.(*dedup.LayerUsage).Images) < len(b.(*dedup.LayerUsage).Images)
+		}, true},
+		{"size", func(a, b listViewElement) bool {
+			return a.(*dedup.LayerUsage).Layer.SizeByte < b.(*dedup.LayerUsage).Layer.SizeByte
+		}, true},
+	}
+
+	sharingImageColumns = &columnSet{[]*listColumn{
+		{"tag", func(e listViewElement) listCell { return textCell(e.(*dedup.ImageUsage).Image.GetTag()) }},
+		{"layers", func(e listViewElement) listCell { return numberCell(strconv.Itoa(e.(*dedup.ImageUsage).Layers())) }},
+		{"unique", func(e listViewElement) listCell {
+			return numberCell(humanize.Bytes(uint64(e.(*dedup.ImageUsage).UniqueByte)))
+		}},
+		{"shared", func(e listViewElement) listCell {
+			return numberCell(humanize.Bytes(uint64(e.(*dedup.ImageUsage).SharedByte)))
+		}},
+		{"size", func(e listViewElement) listCell { return numberCell(e.(*dedup.ImageUsage).Image.SizeStr()) }},
+		{"pushed", func(e listViewElement) listCell { return textCell(e.(*dedup.ImageUsage).Image.PushedAtShortStr()) }},
+		{"dup", func(e listViewElement) listCell {
+			if d := e.(*dedup.ImageUsage).DuplicateOf; d != nil {
+				return textCell(d.GetTag())
+			}
+			return textCell("")
+		}},
+	}, []string{"tag", "unique", "shared", "dup"}}
+
+	sharingLayerColumns = &columnSet{[]*listColumn{
+		{"digest", func(e listViewElement) listCell { return textCell(e.(*dedup.LayerUsage).Layer.ShortDigest()) }},
+		{"images", func(e listViewElement) listCell { return numberCell(strconv.Itoa(len(e.(*dedup.LayerUsage).Images))) }},
+		{"size", func(e listViewElement) listCell { return numberCell(e.(*dedup.LayerUsage).Layer.SizeStr()) }},
+	}, []string{"digest", "images", "size"}}
+)
+
+type sharingListView struct {
+	*listViewBase
+	repository string
+	analysis   *dedup.Analysis
+	byLayer    bool
+}
+
+func newSharingListView(b *goban.Box, repo string, a *dedup.Analysis, byLayer bool) *sharingListView {
+	base := &listViewBase{
+		box:       b,
+		model:     newListModel(sharingImageElements(a), sharingImageSorters...),
+		columnSet: sharingImageColumns,
+		title:     sharingImageListViewTitle,
+	}
+	if byLayer {
+		base.model = newListModel(sharingLayerElements(a), sharingLayerSorters...)
+		base.columnSet = sharingLayerColumns
+		base.title = sharingLayerListViewTitle
+	}
+	base.columns = base.columnSet.defaultColumns()
+	return &sharingListView{base, repo, a, byLayer}
+}
+
+func sharingImageElements(a *dedup.Analysis) []listViewElement {
+	elems := make([]listViewElement, len(a.Images))
+	for i, u := range a.Images {
+		elems[i] = u
+	}
+	return elems
+}
+
+func sharingLayerElements(a *dedup.Analysis) []listViewElement {
+	elems := make([]listViewElement, len(a.Layers))
+	for i, u := range a.Layers {
+		elems[i] = u
+	}
+	return elems
+}
+
+func (v *sharingListView) operate(key *tcell.EventKey) {
+	dispatch(v.keyBindings(), key)
+}
+
+func (v *sharingListView) keyBindings() []*keyBindingGroup {
+	return append([]*keyBindingGroup{
+		{
+			title: sharingBreadcrumb,
+			bindings: []*keyBinding{
+				{runes: []rune{'L'}, desc: "switch between images and layers", action: func() { v.ui.showSharingViews(v.repository, v.analysis, !v.byLayer) }},
+				{runes: []rune{'h'}, desc: "move to image list", action: func() { v.ui.loadImageViews(v.repository) }},
+			},
+		},
+	}, v.listViewBase.keyBindings()...)
+}
+
+type sharingDetailView struct {
+	*detailViewBase
+	analysis *dedup.Analysis
+}
+
+func newSharingDetailView(b *goban.Box, a *dedup.Analysis) *sharingDetailView {
+	return &sharingDetailView{newDetailViewBase(b), a}
+}
+
+func (v *sharingDetailView) update(e listViewElement) {
+	switch e := e.(type) {
+	case *dedup.ImageUsage:
+		v.SetLines(v.imageLines(e))
+	case *dedup.LayerUsage:
+		v.SetLines(v.layerLines(e))
+	default:
+		v.SetLines(nil)
+	}
+}
+
+func (v *sharingDetailView) imageLines(u *dedup.ImageUsage) []string {
+	ls := []string{"TAGS:"}
+	for _, t := range u.Image.GetTags() {
+		ls = append(ls, "  "+t)
+	}
+	ls = append(ls,
+		"DIGEST:",
+		"  "+u.Image.Digest,
+		"SIZE:",
+		"  "+u.Image.SizeStr(),
+		"UNIQUE (FREED IF DELETED):",
+		"  "+humanize.Bytes(uint64(u.UniqueByte)),
+		"SHARED:",
+		"  "+humanize.Bytes(uint64(u.SharedByte)),
+	)
+	if u.DuplicateOf != nil {
+		ls = append(ls,
+			"IDENTICAL TO:",
+			"  "+u.DuplicateOf.GetTag()+" (pushed "+u.DuplicateOf.PushedAtStr()+")",
+		)
+	}
+	ls = append(ls, "LAYERS:")
+	if u.Manifest == nil {
+		return append(ls, "  manifest could not be read")
+	}
+	users := make(map[string]int, len(v.analysis.Layers))
+	for _, l := range v.analysis.Layers {
+		users[l.Layer.Digest] = len(l.Images)
+	}
+	for _, l := range u.Manifest.Layers {
+		ls = append(ls, fmt.Sprintf("  %s %8s  used by %d", l.ShortDigest(), l.SizeStr(), users[l.Digest]))
+	}
+	return ls
+}
+
+func (v *sharingDetailView) layerLines(u *dedup.LayerUsage) []string {
+	ls := []string{
+		"DIGEST:",
+		"  " + u.Layer.Digest,
+		"SIZE:",
+		"  " + u.Layer.SizeStr(),
+		"MEDIA TYPE:",
+		"  " + u.Layer.MediaType,
+		fmt.Sprintf("USED BY %d IMAGES:", len(u.Images)),
+	}
+	for _, img := range u.Images {
+		ls = append(ls, "  "+img.GetTag())
+	}
+	return ls
+}
+
+// loadSharingViews reads the manifests of all images of repo and analyzes their layers.
+func (u *ui) loadSharingViews(repo string) error {
+	c, err := imageManifestClient()
+	if err != nil {
+		return err
+	}
+	loading := layout.NewLoadingDialog(u.baseView.base, u.baseView.es)
+	go loading.Display()
+	imgs, err := client.FetchAllImages(repo)
+	var children []string
+	if err == nil {
+		children, err = indexChildren(repo, imgs)
+	}
+	var ms []*domain.Manifest
+	if err == nil {
+		// a multi-platform image is analyzed as its index, which resolves to one of the
+		// platform manifests, so they are not analyzed as images of their own
+		imgs = withoutDigests(imgs, children)
+		ms, err = c.FetchImageManifests(repo, imgs)
+	}
+	loading.Close()
+	if err != nil {
+		return err
+	}
+	u.showSharingViews(repo, dedup.Analyze(imgs, ms), false)
+	return nil
+}
+
+func (u *ui) showSharingViews(repo string, a *dedup.Analysis, byLayer bool) {
+	lv := newSharingListView(u.baseView.gridLayout.list, repo, a, byLayer)
+	dv := newSharingDetailView(u.baseView.gridLayout.detail, a)
+	lv.addObserver(dv)
+	lv.setBaseUI(u)
+	u.baseView.resetBreadcrumb()
+	u.popViews()
+	u.pushViews(lv, dv)
+	u.setPanes(lv, dv)
+	u.baseView.pushBreadcrumb(repo)
+	u.baseView.pushBreadcrumb(sharingBreadcrumb)
+	msg := fmt.Sprintf("%d images, %d layers: %s stored for %s of images, %d identical",
+		len(a.Images), len(a.Layers), humanize.Bytes(uint64(a.StoredByte)), humanize.Bytes(uint64(a.ImageByte)), a.Duplicates())
+	if n := a.Unreadable(); n > 0 {
+		msg += fmt.Sprintf(", %d unreadable", n)
+	}
+	u.baseView.showMessage(msg)
+}
+
+// withoutDigests returns the images of imgs whose digest is not in digests.
+func withoutDigests(imgs []*domain.Image, digests []string) []*domain.Image {
+	if len(digests) == 0 {
+		return imgs
+	}
+	exclude := make(map[string]bool, len(digests))
+	for _, d := range digests {
+		exclude[d] = true
+	}
+	ret := make([]*domain.Image, 0, len(imgs))
+	for _, img := range imgs {
+		if !exclude[img.Digest] {
+			ret = append(ret, img)
+		}
+	}
+	return ret
+}
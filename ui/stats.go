@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/lusingander/ecr-browser/cost"
+	"github.com/lusingander/ecr-browser/domain"
+	"github.com/lusingander/ecr-browser/lifecycle"
+)
+
+type repositoryStat struct {
+	count      int
+	sizeByte   int64
+	storedByte int64 // each digest counted once
+}
+
+// monthlyCost is the estimated storage cost of the repository in the current region.
+func (s *repositoryStat) monthlyCost() float64 {
+	return cost.Monthly(s.storedByte, currentRegion())
+}
+
+// repositoryStats holds the image count and total size of the repositories
+// whose images have been fetched, so list views can show them without fetching.
+var repositoryStats = make(map[string]*repositoryStat)
+
+func recordImages(repo string, imgs []*domain.Image) {
+	s := &repositoryStat{count: len(imgs), storedByte: cost.StoredSize(imgs)}
+	for _, img := range imgs {
+		s.sizeByte += img.SizeByte
+	}
+	repositoryStats[repo] = s
+}
+
+// accountCost returns the estimated monthly cost of the repositories whose images
+// have been fetched, and how many of them there are.
+func accountCost() (float64, int) {
+	total := 0.0
+	for _, s := range repositoryStats {
+		total += s.monthlyCost()
+	}
+	return total, len(repositoryStats)
+}
+
+// repositoryTags holds the resource tags of the repositories whose tags
+// have been fetched, keyed by ARN.
+var repositoryTags = make(map[string]map[string]string)
+
+// repositoryTagErrors holds why the tags of a repository could not be fetched, keyed by ARN,
+// so that moving through the list does not call the API again for each repository.
+var repositoryTagErrors = make(map[string]error)
+
+// fetchRepositoryTags returns the resource tags of repo, fetching them on first use.
+func fetchRepositoryTags(repo *domain.Repository) (map[string]string, error) {
+	if tags, ok := repositoryTags[repo.Arn]; ok {
+		return tags, nil
+	}
+	if err, ok := repositoryTagErrors[repo.Arn]; ok {
+		return nil, err
+	}
+	c, ok := client.(domain.RepositoryTagClient)
+	if !ok {
+		return nil, fmt.Errorf("current client does not support resource tags")
+	}
+	tags, err := c.FetchRepositoryTags(repo)
+	if err != nil {
+		repositoryTagErrors[repo.Arn] = err
+		return nil, err
+	}
+	repositoryTags[repo.Arn] = tags
+	return tags, nil
+}
+
+// repositoryCatalogs holds the catalog data of the public repositories
+// displayed so far, keyed by ARN.
+var repositoryCatalogs = make(map[string]*domain.RepositoryCatalogData)
+
+// fetchRepositoryCatalogData returns the catalog data of repo, fetching it on first use.
+func fetchRepositoryCatalogData(repo *domain.Repository) (*domain.RepositoryCatalogData, error) {
+	if d, ok := repositoryCatalogs[repo.Arn]; ok {
+		return d, nil
+	}
+	c, ok := client.(domain.RepositoryCatalogClient)
+	if !ok {
+		return nil, fmt.Errorf("current client does not support catalog data")
+	}
+	d, err := c.FetchRepositoryCatalogData(repo)
+	if err != nil {
+		return nil, err
+	}
+	repositoryCatalogs[repo.Arn] = d
+	return d, nil
+}
+
+// knownRepositoryTagKeys returns the keys of the resource tags fetched so far.
+func knownRepositoryTagKeys() []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, tags := range repositoryTags {
+		for k := range tags {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// resetSessionCaches drops what was recorded of the repositories of the previous
+// region or profile, when the client caches are reset.
+func resetSessionCaches() {
+	repositoryStats = make(map[string]*repositoryStat)
+	lifecycleExpirations = make(map[*domain.Image]*lifecycle.Rule)
+	repositoryTags = make(map[string]map[string]string)
+	repositoryTagErrors = make(map[string]error)
+	tagFilterErr = nil
+	repositoryCatalogs = make(map[string]*domain.RepositoryCatalogData)
+}
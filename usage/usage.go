@@ -0,0 +1,174 @@
+// Package usage summarizes the storage used by the images of many repositories.
+package usage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lusingander/ecr-browser/cost"
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+const (
+	// PushHistoryDays is how far back the push histogram goes.
+	PushHistoryDays = 90
+	// PushBucketDays is the width of a push histogram bucket.
+	PushBucketDays = 7
+)
+
+// RepositoryUsage is the storage used by the images of a repository.
+type RepositoryUsage struct {
+	Name         string
+	Images       int
+	SizeByte     int64
+	StoredByte   int64 // each digest counted once
+	UntaggedByte int64
+}
+
+func (u *RepositoryUsage) Display() string {
+	return u.Name
+}
+
+// ImageUsage is an image and the repository it belongs to.
+type ImageUsage struct {
+	Repository string
+	Image      *domain.Image
+}
+
+// PushBucket counts the images pushed in PushBucketDays days from From.
+type PushBucket struct {
+	From  time.Time
+	Count int
+}
+
+// Report is the storage usage of all repositories.
+type Report struct {
+	Repositories []*RepositoryUsage // largest first
+	Largest      []*ImageUsage      // largest first
+	Pushes       []*PushBucket      // oldest first
+	Images       int
+	TotalByte    int64
+	StoredByte   int64
+	UntaggedByte int64
+}
+
+// Summarize builds the report of imgs, keyed by repository name, keeping the top largest images.
+func Summarize(imgs map[string][]*domain.Image, top int, now time.Time) *Report {
+	r := &Report{Pushes: pushBuckets(now)}
+	var all []*ImageUsage
+	for name, is := range imgs {
+		u := &RepositoryUsage{Name: name, Images: len(is), StoredByte: cost.StoredSize(is)}
+		for _, img := range is {
+			u.SizeByte += img.SizeByte
+			if len(img.Tags) == 0 {
+				u.UntaggedByte += img.SizeByte
+			}
+			all = append(all, &ImageUsage{name, img})
+			r.countPush(img.PushedAt, now)
+		}
+		r.Repositories = append(r.Repositories, u)
+		r.Images += u.Images
+		r.TotalByte += u.SizeByte
+		r.StoredByte += u.StoredByte
+		r.UntaggedByte += u.UntaggedByte
+	}
+	sort.Slice(r.Repositories, func(i, j int) bool {
+		a, b := r.Repositories[i], r.Repositories[j]
+		if a.SizeByte != b.SizeByte {
+			return a.SizeByte > b.SizeByte
+		}
+		return a.Name < b.Name
+	})
+	sort.SliceStable(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+		if a.Image.SizeByte != b.Image.SizeByte {
+			return a.Image.SizeByte > b.Image.SizeByte
+		}
+		return a.Repository < b.Repository
+	})
+	if len(all) > top {
+		all = all[:top]
+	}
+	r.Largest = all
+	return r
+}
+
+func pushBuckets(now time.Time) []*PushBucket {
+	n := (PushHistoryDays + PushBucketDays - 1) / PushBucketDays
+	bs := make([]*PushBucket, n)
+	for i := range bs {
+		bs[i] = &PushBucket{From: now.AddDate(0, 0, -PushBucketDays*(n-i))}
+	}
+	return bs
+}
+
+func (r *Report) countPush(at, now time.Time) {
+	age := now.Sub(at)
+	if age < 0 || age >= PushHistoryDays*24*time.Hour {
+		return
+	}
+	i := len(r.Pushes) - 1 - int(age/(PushBucketDays*24*time.Hour))
+	r.Pushes[i].Count++
+}
+
+// MaxPushes returns the largest bucket count of the push histogram.
+func (r *Report) MaxPushes() int {
+	max := 0
+	for _, b := range r.Pushes {
+		if b.Count > max {
+			max = b.Count
+		}
+	}
+	return max
+}
+
+// Fetch fetches the images of repos with at most workers fetches at a time,
+// calling progress after each one (never concurrently). It stops at the first error.
+func Fetch(fetch func(repo string) ([]*domain.Image, error), repos []string, workers int, progress func(done, total int)) (map[string][]*domain.Image, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan string)
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		ret      = make(map[string][]*domain.Image, len(repos))
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for repo := range jobs {
+				imgs, err := fetch(repo)
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				if err == nil {
+					ret[repo] = imgs
+					if progress != nil {
+						progress(len(ret), len(repos))
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, repo := range repos {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+		jobs <- repo
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return ret, nil
+}
@@ -0,0 +1,103 @@
+package usage
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lusingander/ecr-browser/domain"
+)
+
+var now = time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC)
+
+type testImage struct {
+	digest        string
+	tag           string
+	pushedDaysAgo int
+	size          int64
+}
+
+func images(ts ...testImage) []*domain.Image {
+	ret := make([]*domain.Image, len(ts))
+	for i, ti := range ts {
+		var tags []string
+		if ti.tag != "" {
+			tags = []string{ti.tag}
+		}
+		ret[i] = domain.NewImage(tags, now.AddDate(0, 0, -ti.pushedDaysAgo), ti.digest, ti.size, "", time.Time{}, "", "")
+	}
+	return ret
+}
+
+func TestSummarize(t *testing.T) {
+	imgs := map[string][]*domain.Image{
+		"app": images(testImage{"sha256:app-v1", "v1", 1, 30}, testImage{"sha256:app-untagged", "", 8, 20}),
+		"db":  images(testImage{"sha256:db-v1", "v1", 100, 100}),
+		"web": images(),
+	}
+	r := Summarize(imgs, 2, now)
+
+	var names []string
+	for _, u := range r.Repositories {
+		names = append(names, u.Name)
+	}
+	if want := []string{"db", "app", "web"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Repositories = %v; want = %v", names, want)
+	}
+
+	var largest []string
+	for _, u := range r.Largest {
+		largest = append(largest, u.Repository+":"+u.Image.GetTag())
+	}
+	if want := []string{"db:v1", "app:v1"}; !reflect.DeepEqual(largest, want) {
+		t.Errorf("Largest = %v; want = %v", largest, want)
+	}
+
+	if got := len(r.Pushes); got != 13 {
+		t.Fatalf("len(Pushes) = %v; want = %v", got, 13)
+	}
+	tests := []struct {
+		name string
+		got  int64
+		want int64
+	}{
+		{"Images", int64(r.Images), 3},
+		{"TotalByte", r.TotalByte, 150},
+		{"UntaggedByte", r.UntaggedByte, 20},
+		{"app UntaggedByte", r.Repositories[1].UntaggedByte, 20},
+		{"Pushes[12]", int64(r.Pushes[12].Count), 1},
+		{"Pushes[11]", int64(r.Pushes[11].Count), 1},
+		{"MaxPushes()", int64(r.MaxPushes()), 1},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s = %v; want = %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestFetch(t *testing.T) {
+	repos := []string{"a", "b", "c", "d", "e"}
+	fetch := func(repo string) ([]*domain.Image, error) {
+		return images(testImage{"sha256:" + repo, "latest", 1, 1}), nil
+	}
+	calls := 0
+	ret, err := Fetch(fetch, repos, 2, func(done, total int) { calls++ })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ret) != 5 || calls != 5 {
+		t.Errorf("len(ret), calls = %v, %v; want = 5, 5", len(ret), calls)
+	}
+
+	failing := func(repo string) ([]*domain.Image, error) {
+		if repo == "c" {
+			return nil, fmt.Errorf("denied")
+		}
+		return nil, nil
+	}
+	if _, err := Fetch(failing, repos, 2, nil); err == nil {
+		t.Errorf("Fetch() error = nil; want error")
+	}
+}
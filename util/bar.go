@@ -0,0 +1,21 @@
+package util
+
+import "strings"
+
+var barEighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// Bar draws value as a horizontal bar of width cells for max, in eighths of a cell.
+// Any value above zero gets at least a sliver.
+func Bar(value, max int64, width int) string {
+	if value <= 0 || max <= 0 || width <= 0 {
+		return ""
+	}
+	if value > max {
+		value = max
+	}
+	eighths := int(value * int64(width) * 8 / max)
+	if eighths == 0 {
+		eighths = 1
+	}
+	return strings.Repeat("█", eighths/8) + barEighths[eighths%8]
+}
@@ -0,0 +1,24 @@
+package util
+
+import "testing"
+
+func TestBar(t *testing.T) {
+	tests := []struct {
+		value, max int64
+		width      int
+		want       string
+	}{
+		{0, 10, 10, ""},
+		{10, 10, 4, "████"},
+		{5, 10, 4, "██"},
+		{1, 16, 1, "▏"},
+		{1, 1000, 4, "▏"},
+		{11, 16, 2, "█▍"},
+		{20, 10, 2, "██"},
+	}
+	for _, test := range tests {
+		if got := Bar(test.value, test.max, test.width); got != test.want {
+			t.Errorf("Bar(%v, %v, %v) = %q; want = %q", test.value, test.max, test.width, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package util
+
+// DiffLines returns a line-based diff from a to b. Each line is prefixed
+// with "- " if only in a, "+ " if only in b, or "  " if in both.
+func DiffLines(a, b []string) []string {
+	// longest common subsequence table
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var ret []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ret = append(ret, "  "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ret = append(ret, "- "+a[i])
+			i++
+		default:
+			ret = append(ret, "+ "+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ret = append(ret, "- "+a[i])
+	}
+	for ; j < len(b); j++ {
+		ret = append(ret, "+ "+b[j])
+	}
+	return ret
+}
@@ -0,0 +1,16 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	a := []string{"{", `"a": 1,`, `"b": 2`, "}"}
+	b := []string{"{", `"a": 1,`, `"b": 3,`, `"c": 4`, "}"}
+	got := DiffLines(a, b)
+	want := []string{"  {", `  "a": 1,`, `- "b": 2`, `+ "b": 3,`, `+ "c": 4`, "  }"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffLines() = %q; want = %q", got, want)
+	}
+}
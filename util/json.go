@@ -0,0 +1,69 @@
+package util
+
+import (
+	"strings"
+)
+
+const (
+	jsonKeyColor     = "\x1b[36m"
+	jsonStringColor  = "\x1b[32m"
+	jsonNumberColor  = "\x1b[33m"
+	jsonLiteralColor = "\x1b[35m"
+	resetColor       = "\x1b[0m"
+)
+
+// HighlightJSON colors the tokens of a line of indented JSON with escape sequences.
+// Strings must not span lines, which holds for the output of json.Indent.
+func HighlightJSON(line string) string {
+	var sb strings.Builder
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == '"':
+			j := stringEnd(line, i)
+			color := jsonStringColor
+			if strings.HasPrefix(strings.TrimLeft(line[j:], " "), ":") {
+				color = jsonKeyColor
+			}
+			sb.WriteString(color + line[i:j] + resetColor)
+			i = j
+		case c == '-' || ('0' <= c && c <= '9'):
+			j := i + 1
+			for j < len(line) && strings.IndexByte("0123456789.eE+-", line[j]) >= 0 {
+				j++
+			}
+			sb.WriteString(jsonNumberColor + line[i:j] + resetColor)
+			i = j
+		case hasLiteral(line[i:]) != "":
+			l := hasLiteral(line[i:])
+			sb.WriteString(jsonLiteralColor + l + resetColor)
+			i += len(l)
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// stringEnd returns the index just after the string starting at i.
+func stringEnd(s string, i int) int {
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1
+		}
+	}
+	return len(s)
+}
+
+func hasLiteral(s string) string {
+	for _, l := range []string{"true", "false", "null"} {
+		if strings.HasPrefix(s, l) {
+			return l
+		}
+	}
+	return ""
+}
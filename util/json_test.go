@@ -0,0 +1,21 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestHighlightJSON(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`  "Sid": "a\"b",`, "  \x1b[36m\"Sid\"\x1b[0m: \x1b[32m\"a\\\"b\"\x1b[0m,"},
+		{`  "n": -1.5e3, "ok": true`, "  \x1b[36m\"n\"\x1b[0m: \x1b[33m-1.5e3\x1b[0m, \x1b[36m\"ok\"\x1b[0m: \x1b[35mtrue\x1b[0m"},
+		{`  [`, `  [`},
+	}
+	for _, test := range tests {
+		if got := HighlightJSON(test.line); got != test.want {
+			t.Errorf("HighlightJSON(%q) = %q; want = %q", test.line, got, test.want)
+		}
+	}
+}